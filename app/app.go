@@ -3,13 +3,20 @@ package app
 import (
 	"context"
 	"fmt"
+	"github.com/RVodassa/TaskReward/internal/config"
+	"github.com/RVodassa/TaskReward/internal/domain/interfaces"
+	"github.com/RVodassa/TaskReward/internal/domain/models"
 	"github.com/RVodassa/TaskReward/internal/handlers/http"
+	"github.com/RVodassa/TaskReward/internal/infrastructure/mail"
 	"github.com/RVodassa/TaskReward/internal/infrastructure/postgres"
 	"github.com/RVodassa/TaskReward/internal/infrastructure/postgres/repository"
 	"github.com/RVodassa/TaskReward/internal/serve"
 	"github.com/RVodassa/TaskReward/internal/services"
+	"github.com/RVodassa/TaskReward/internal/services/auth"
+	"github.com/RVodassa/TaskReward/internal/services/leaderboard"
 	"log"
 	"os"
+	"time"
 )
 
 type App struct {
@@ -28,13 +35,37 @@ func (app *App) Run() error {
 		return err
 	}
 	
+	cfg, err := config.Load()
+	if err != nil {
+		return fmt.Errorf("%s: %v", op, err)
+	}
+
 	port := os.Getenv("SERVER_PORT")
-	Repository := repository.NewRepo(database)
-	Service := services.NewService(Repository)
-	Controller := http_handlers.NewHandler(Service)
+	Repository := repository.NewRepo(database, cfg.Referral, cfg.Leaderboard.Location)
+	if err = Repository.RebuildLeaderboardScores(context.Background(), time.Now()); err != nil {
+		return fmt.Errorf("%s: %v", op, err)
+	}
+	hasher, err := auth.NewPasswordHasher(cfg.PasswordHashing)
+	if err != nil {
+		return fmt.Errorf("%s: %v", op, err)
+	}
+	board := newLeaderboardCache(Repository, cfg.Leaderboard, cfg.RequireVerified)
+	Service := services.NewService(Repository, cfg, newMailer(cfg.Mail), hasher, board)
+	tokenManager, err := auth.NewManager(cfg.JWT)
+	if err != nil {
+		return fmt.Errorf("%s: %v", op, err)
+	}
+	Controller := http_handlers.NewHandler(Service, Service, tokenManager, cfg.TrustedProxies)
 	router := http_handlers.NewRouter(Controller)
 	newServe := serve.NewServe(port, router)
 
+	// Создание первого администратора
+	err = BootstrapAdmin(Service)
+	if err != nil {
+		log.Println(err)
+		return err
+	}
+
 	// Генерация задач
 	err = GenerateTask(10, Service)
 	if err != nil {
@@ -49,9 +80,47 @@ func (app *App) Run() error {
 	return nil
 }
 
+// newMailer создает способ отправки писем по настройке MAIL_DRIVER.
+func newMailer(cfg config.Mail) interfaces.Mailer {
+	if cfg.Driver == config.MailDriverSMTP {
+		return mail.NewSMTPMailer(cfg.From, cfg.SMTPHost, cfg.SMTPPort, cfg.SMTPUsername, cfg.SMTPPassword)
+	}
+	return mail.NewFileMailer(cfg.From, cfg.OutboxFile)
+}
+
+// newLeaderboardCache создает кэш таблиц лидеров и запускает его синхронизацию с базой данных.
+// Если LEADERBOARD_CACHE выключен, возвращает nil.
+func newLeaderboardCache(repo interfaces.RepositoryProvider, cfg models.Leaderboard, requireVerified bool) *leaderboard.Cache {
+	if !cfg.Cache {
+		return nil
+	}
+
+	board := leaderboard.NewCache(cfg.Location, requireVerified)
+	go leaderboard.NewSyncer(board, repo, cfg.CacheRefresh).Run(context.Background())
+	return board
+}
+
+// BootstrapAdmin создает администратора из переменных окружения ADMIN_LOGIN и ADMIN_PASSWORD.
+// Если ADMIN_LOGIN не задан, шаг пропускается.
+func BootstrapAdmin(service *services.Service) error {
+	const op = "app.BootstrapAdmin"
+
+	login := os.Getenv("ADMIN_LOGIN")
+	if login == "" {
+		return nil
+	}
+
+	err := service.EnsureAdmin(context.Background(), login, os.Getenv("ADMIN_PASSWORD"))
+	if err != nil {
+		return fmt.Errorf("%s: %v", op, err)
+	}
+	log.Printf("выполнено: администратор %s", login)
+	return nil
+}
+
 func GenerateTask(count int, service *services.Service) error {
 	for i := 0; i < count; i++ {
-		err := service.AddTask(context.Background(), fmt.Sprintf("description%d", i), uint(i*10+10))
+		_, err := service.AddTask(context.Background(), fmt.Sprintf("description%d", i), uint(i*10+10), 0)
 		if err != nil {
 			log.Println(err)
 			return err
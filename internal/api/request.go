@@ -1,6 +1,119 @@
 package api
 
+import "time"
+
 type AuthRequest struct {
 	Login    string `json:"login"`
 	Password string `json:"password"`
 }
+
+type RegisterRequest struct {
+	Login    string `json:"login"`
+	Password string `json:"password"`
+	Email    string `json:"email,omitempty"`
+}
+
+type RefreshRequest struct {
+	RefreshToken string `json:"refresh_token"`
+}
+
+type LogoutRequest struct {
+	RefreshToken string `json:"refresh_token,omitempty"`
+}
+
+type ChangePasswordRequest struct {
+	OldPassword string `json:"old_password"`
+	NewPassword string `json:"new_password"`
+}
+
+type UpdateEmailRequest struct {
+	Email string `json:"email"`
+}
+
+type VerifyEmailRequest struct {
+	Token string `json:"token"`
+}
+
+type ForgotPasswordRequest struct {
+	Email string `json:"email"`
+}
+
+type ResetPasswordRequest struct {
+	Token       string `json:"token"`
+	NewPassword string `json:"new_password"`
+}
+
+type TOTPCodeRequest struct {
+	Code string `json:"code"`
+}
+
+type DisableTOTPRequest struct {
+	Password string `json:"password"`
+	Code     string `json:"code"`
+}
+
+type TwoFactorLoginRequest struct {
+	ChallengeToken string `json:"challenge_token"`
+	Code           string `json:"code"`
+}
+
+type CreateTaskRequest struct {
+	Description    string `json:"description"`
+	Bonus          uint   `json:"bonus"`
+	MaxCompletions uint   `json:"max_completions"`
+}
+
+type UpdateTaskRequest struct {
+	Description    *string `json:"description,omitempty"`
+	Bonus          *uint   `json:"bonus,omitempty"`
+	MaxCompletions *uint   `json:"max_completions,omitempty"`
+}
+
+type SetRoleRequest struct {
+	Role string `json:"role"`
+}
+
+type AdjustBalanceRequest struct {
+	Amount  int64  `json:"amount"`
+	Comment string `json:"comment"`
+}
+
+type CreateRewardRequest struct {
+	Name        string `json:"name"`
+	Description string `json:"description"`
+	Cost        uint   `json:"cost"`
+	Stock       uint   `json:"stock"`
+	Active      *bool  `json:"active,omitempty"`
+}
+
+type UpdateRewardRequest struct {
+	Name        *string `json:"name,omitempty"`
+	Description *string `json:"description,omitempty"`
+	Cost        *uint   `json:"cost,omitempty"`
+	Stock       *uint   `json:"stock,omitempty"`
+	Active      *bool   `json:"active,omitempty"`
+}
+
+type CreateReferralCodeRequest struct {
+	MaxUses   uint       `json:"max_uses"`
+	ExpiresAt *time.Time `json:"expires_at,omitempty"`
+}
+
+type UpdateProfileRequest struct {
+	DisplayName           string `json:"display_name"`
+	AvatarURL             string `json:"avatar_url"`
+	LeaderboardVisibility string `json:"leaderboard_visibility"`
+}
+
+type CreateSeasonRequest struct {
+	Name     string    `json:"name"`
+	StartsAt time.Time `json:"starts_at"`
+	EndsAt   time.Time `json:"ends_at"`
+	Prizes   []uint    `json:"prizes"`
+}
+
+type CreateAPIKeyRequest struct {
+	Name      string     `json:"name"`
+	Scopes    []string   `json:"scopes"`
+	ExpiresAt *time.Time `json:"expires_at,omitempty"`
+}
@@ -8,6 +8,12 @@ type TaskCompletedResponse struct {
 	Task    *models.Task
 }
 
+type TaskResponse struct {
+	Status  bool
+	Message string
+	Task    *models.Task
+}
+
 type StatusUserResponse struct {
 	Status  bool
 	Message string
@@ -15,15 +21,38 @@ type StatusUserResponse struct {
 }
 
 type LoginResponse struct {
-	Status  bool
-	Message string
-	JWToken string
+	Status            bool
+	Message           string
+	JWToken           string
+	RefreshToken      string
+	TwoFactorRequired bool
+	ChallengeToken    string
+}
+
+type TOTPEnrollmentResponse struct {
+	Status     bool
+	Message    string
+	Enrollment *models.TOTPEnrollment
+}
+
+type BackupCodesResponse struct {
+	Status      bool
+	Message     string
+	BackupCodes []string
 }
 
 type LeaderBoardResponse struct {
 	Status     bool
 	Message    string
-	ListLeader []*models.User
+	Period     string
+	ListLeader []*models.LeaderboardEntry
+}
+
+type LeaderboardPositionResponse struct {
+	Status   bool
+	Message  string
+	Period   string
+	Position *models.LeaderboardPosition
 }
 
 type GetAllTasksResponse struct {
@@ -32,6 +61,109 @@ type GetAllTasksResponse struct {
 	Tasks   []*models.Task
 }
 
+type TransactionResponse struct {
+	Status      bool
+	Message     string
+	Transaction *models.BalanceTransaction
+}
+
+type TransactionsResponse struct {
+	Status       bool
+	Message      string
+	Total        uint
+	Transactions []*models.BalanceTransaction
+}
+
+type RewardResponse struct {
+	Status  bool
+	Message string
+	Reward  *models.Reward
+}
+
+type RewardsResponse struct {
+	Status  bool
+	Message string
+	Rewards []*models.Reward
+}
+
+type OrderResponse struct {
+	Status  bool
+	Message string
+	Order   *models.RewardOrder
+}
+
+type OrdersResponse struct {
+	Status  bool
+	Message string
+	Orders  []*models.RewardOrder
+}
+
+type ReferralsResponse struct {
+	Status    bool
+	Message   string
+	Referrals []*models.Referral
+}
+
+type ReferralStatsResponse struct {
+	Status  bool
+	Message string
+	Stats   *models.ReferralStats
+}
+
+type ReferralCodeResponse struct {
+	Status  bool
+	Message string
+	Code    *models.ReferralCode
+}
+
+type ReferralCodesResponse struct {
+	Status  bool
+	Message string
+	Codes   []*models.ReferralCode
+}
+
+type SeasonResponse struct {
+	Status  bool
+	Message string
+	Season  *models.Season
+}
+
+type SeasonsResponse struct {
+	Status  bool
+	Message string
+	Seasons []*models.Season
+}
+
+type SeasonStandingsResponse struct {
+	Status    bool
+	Message   string
+	Season    *models.Season
+	Standings []*models.SeasonStanding
+}
+
+type APIKeyResponse struct {
+	Status  bool
+	Message string
+	APIKey  *models.APIKey
+}
+
+type APIKeysResponse struct {
+	Status  bool
+	Message string
+	APIKeys []*models.APIKey
+}
+
+type MessageResponse struct {
+	Status  bool
+	Message string
+}
+
+type ValidationErrorResponse struct {
+	Status  bool
+	Message string
+	Errors  []models.FieldError
+}
+
 type ErrorResponse struct {
 	Status  bool
 	Message string
@@ -0,0 +1,592 @@
+package config
+
+import (
+	"fmt"
+	"github.com/RVodassa/TaskReward/internal/domain/models"
+	"math"
+	"net/netip"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+	_ "time/tzdata" // часовые пояса для LEADERBOARD_TIMEZONE в образах без системной базы tzdata
+)
+
+// Config настройки приложения из переменных окружения.
+type Config struct {
+	Referral models.ReferralProgram
+	// RefreshTokenTTL время жизни refresh токена (REFRESH_TOKEN_TTL, по умолчанию 720h).
+	RefreshTokenTTL time.Duration
+	JWT             JWT
+	LoginThrottle   models.LoginThrottle
+	// TrustedProxies адреса и подсети обратных прокси (TRUSTED_PROXIES="10.0.0.1,172.16.0.0/12"). Только за ними
+	// IP клиента берется из X-Forwarded-For или X-Real-IP, иначе - из адреса соединения.
+	TrustedProxies []netip.Prefix
+	// TOTPIssuer название сервиса в приложении-аутентификаторе (TOTP_ISSUER, по умолчанию TaskReward).
+	TOTPIssuer string
+	Mail       Mail
+	// PasswordResetTTL время жизни токена сброса пароля (PASSWORD_RESET_TTL, по умолчанию 1h).
+	PasswordResetTTL time.Duration
+	// PasswordResetURL адрес страницы сброса пароля (PASSWORD_RESET_URL), к нему добавляется ?token=.
+	// Если не задан, в письме передается только токен.
+	PasswordResetURL string
+	// RequireVerified запрещает пользователям без подтвержденного email выполнять задачи и
+	// скрывает их из таблицы лидеров (REQUIRE_VERIFIED, по умолчанию false).
+	RequireVerified bool
+	// EmailVerificationTTL время жизни токена подтверждения email (EMAIL_VERIFICATION_TTL, по умолчанию 48h).
+	EmailVerificationTTL time.Duration
+	// EmailVerificationURL адрес страницы подтверждения email (EMAIL_VERIFICATION_URL), к нему добавляется ?token=.
+	// Если не задан, в письме передается только токен.
+	EmailVerificationURL string
+	PasswordHashing      PasswordHashing
+	PasswordPolicy       models.PasswordPolicy
+	LoginPolicy          models.LoginPolicy
+	Leaderboard          models.Leaderboard
+}
+
+// Алгоритмы хэширования паролей
+const (
+	PasswordHashArgon2id = "argon2id"
+	PasswordHashBcrypt   = "bcrypt"
+)
+
+// PasswordHashing настройки хэширования паролей. Хэши, полученные другим алгоритмом или с другими
+// параметрами, перехэшируются при успешном входе пользователя.
+type PasswordHashing struct {
+	// Algorithm алгоритм для новых хэшей (PASSWORD_HASH_ALGORITHM): argon2id (по умолчанию) или bcrypt.
+	Algorithm string
+	// BcryptCost стоимость bcrypt (BCRYPT_COST, по умолчанию 10).
+	BcryptCost int
+	// Argon2Time, Argon2Memory (KiB), Argon2Threads параметры argon2id (ARGON2_TIME, ARGON2_MEMORY,
+	// ARGON2_THREADS, по умолчанию 2, 19456, 1).
+	Argon2Time    uint32
+	Argon2Memory  uint32
+	Argon2Threads uint8
+}
+
+// Драйверы отправки писем
+const (
+	MailDriverFile = "file"
+	MailDriverSMTP = "smtp"
+)
+
+// Mail настройки отправки писем.
+type Mail struct {
+	// Driver способ отправки (MAIL_DRIVER): file - запись в файл или журнал для локальной разработки
+	// (по умолчанию), smtp - отправка через SMTP сервер.
+	Driver string
+	// From адрес отправителя (MAIL_FROM).
+	From string
+	// OutboxFile файл для писем драйвера file (MAIL_OUTBOX_FILE), если не задан - письма пишутся в журнал.
+	OutboxFile string
+	// SMTPHost, SMTPPort, SMTPUsername, SMTPPassword - сервер и учетная запись SMTP
+	// (SMTP_HOST, SMTP_PORT по умолчанию 587, SMTP_USERNAME, SMTP_PASSWORD).
+	SMTPHost     string
+	SMTPPort     uint
+	SMTPUsername string
+	SMTPPassword string
+}
+
+// JWT настройки подписи access токенов.
+type JWT struct {
+	// Secret ключ HS256 (JWT_SECRET). Если Keys пусты - используется для подписи, иначе только
+	// для проверки ранее выданных токенов без kid.
+	Secret string
+	// Keys ключи в PEM файлах (JWT_KEYS="kid=путь,kid=путь"). Закрытый ключ подходит для подписи
+	// и проверки, открытый - только для проверки токенов, подписанных выведенным из оборота ключом.
+	Keys []JWTKey
+	// SigningKeyID kid ключа для подписи новых токенов (JWT_SIGNING_KEY), по умолчанию первый из Keys.
+	SigningKeyID string
+	// AccessTTL время жизни access токена (JWT_EXPIRATION, по умолчанию 1h).
+	AccessTTL time.Duration
+}
+
+// JWTKey ключ подписи JWT с идентификатором kid.
+type JWTKey struct {
+	ID      string
+	PEMFile string
+}
+
+// Load читает настройки приложения из переменных окружения.
+func Load() (*Config, error) {
+	const op = "config.Load"
+
+	referral, err := loadReferral()
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	refreshTTL, err := getDuration("REFRESH_TOKEN_TTL", 720*time.Hour)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	jwt, err := loadJWT()
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	throttle, err := loadLoginThrottle()
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	trustedProxies, err := loadTrustedProxies()
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	mail, err := loadMail()
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	resetTTL, err := getDuration("PASSWORD_RESET_TTL", time.Hour)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	requireVerified, err := getBool("REQUIRE_VERIFIED", false)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	verificationTTL, err := getDuration("EMAIL_VERIFICATION_TTL", 48*time.Hour)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	hashing, err := loadPasswordHashing()
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	passwordPolicy, err := loadPasswordPolicy(hashing)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	loginPolicy, err := loadLoginPolicy()
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	leaderboard, err := loadLeaderboard()
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	return &Config{
+		Referral:             referral,
+		RefreshTokenTTL:      refreshTTL,
+		JWT:                  jwt,
+		LoginThrottle:        throttle,
+		TrustedProxies:       trustedProxies,
+		TOTPIssuer:           getString("TOTP_ISSUER", "TaskReward"),
+		Mail:                 mail,
+		PasswordResetTTL:     resetTTL,
+		PasswordResetURL:     getString("PASSWORD_RESET_URL", ""),
+		RequireVerified:      requireVerified,
+		EmailVerificationTTL: verificationTTL,
+		EmailVerificationURL: getString("EMAIL_VERIFICATION_URL", ""),
+		PasswordHashing:      hashing,
+		PasswordPolicy:       passwordPolicy,
+		LoginPolicy:          loginPolicy,
+		Leaderboard:          leaderboard,
+	}, nil
+}
+
+// bcryptMaxPasswordBytes bcrypt учитывает только первые 72 байта пароля
+const bcryptMaxPasswordBytes = 72
+
+// loadPasswordPolicy читает требования к паролям:
+// PASSWORD_MIN_LENGTH - минимальная длина (по умолчанию 8), PASSWORD_MAX_LENGTH - максимальная (по умолчанию 128),
+// PASSWORD_REQUIRED_CLASSES - обязательные классы символов через запятую: lower, upper, letter, digit, symbol
+// (по умолчанию letter,digit; "none" - без требований), PASSWORD_BLOCKLIST_FILE - файл с запрещенными паролями
+// по одному в строке в дополнение к встроенному списку.
+func loadPasswordPolicy(hashing PasswordHashing) (models.PasswordPolicy, error) {
+	var policy models.PasswordPolicy
+	var err error
+
+	if policy.MinLength, err = getUint("PASSWORD_MIN_LENGTH", 8); err != nil {
+		return policy, err
+	}
+	if policy.MaxLength, err = getUint("PASSWORD_MAX_LENGTH", 128); err != nil {
+		return policy, err
+	}
+	if policy.MinLength == 0 || policy.MaxLength < policy.MinLength {
+		return policy, fmt.Errorf("PASSWORD_MIN_LENGTH, PASSWORD_MAX_LENGTH: некорректные значения")
+	}
+
+	classes := getString("PASSWORD_REQUIRED_CLASSES", models.CharClassLetter+","+models.CharClassDigit)
+	if classes != "none" {
+		for _, class := range strings.Split(classes, ",") {
+			class = strings.TrimSpace(class)
+			if !models.IsValidCharClass(class) {
+				return policy, fmt.Errorf("PASSWORD_REQUIRED_CLASSES: неизвестный класс %q, допустимо: lower, upper, letter, digit, symbol", class)
+			}
+			policy.RequiredClasses = append(policy.RequiredClasses, class)
+		}
+	}
+
+	if hashing.Algorithm == PasswordHashBcrypt {
+		policy.MaxBytes = bcryptMaxPasswordBytes
+	}
+
+	if path := getString("PASSWORD_BLOCKLIST_FILE", ""); path != "" {
+		data, err := os.ReadFile(path)
+		if err != nil {
+			return policy, fmt.Errorf("PASSWORD_BLOCKLIST_FILE: %w", err)
+		}
+		for _, line := range strings.Split(string(data), "\n") {
+			if line = strings.TrimSpace(line); line != "" {
+				policy.Blocklist = append(policy.Blocklist, line)
+			}
+		}
+	}
+
+	return policy, nil
+}
+
+// loadLoginPolicy читает требования к логину: LOGIN_MIN_LENGTH (по умолчанию 3), LOGIN_MAX_LENGTH (по умолчанию 32).
+func loadLoginPolicy() (models.LoginPolicy, error) {
+	var policy models.LoginPolicy
+	var err error
+
+	if policy.MinLength, err = getUint("LOGIN_MIN_LENGTH", 3); err != nil {
+		return policy, err
+	}
+	if policy.MaxLength, err = getUint("LOGIN_MAX_LENGTH", 32); err != nil {
+		return policy, err
+	}
+	if policy.MinLength == 0 || policy.MaxLength < policy.MinLength || policy.MaxLength > 255 {
+		return policy, fmt.Errorf("LOGIN_MIN_LENGTH, LOGIN_MAX_LENGTH: некорректные значения")
+	}
+
+	return policy, nil
+}
+
+// leaderboardMaxLimit максимальное кол-во лидеров в ответе
+const leaderboardMaxLimit = 100
+
+// loadLeaderboard читает настройки таблицы лидеров: LEADERBOARD_LIMIT - кол-во лидеров (по умолчанию 10),
+// LEADERBOARD_TIMEZONE - часовой пояс границ периодов, например Europe/Moscow (по умолчанию UTC),
+// LEADERBOARD_CACHE - кэш таблиц лидеров в памяти (по умолчанию включен), LEADERBOARD_CACHE_REFRESH -
+// период полной перестройки кэша (по умолчанию 5m).
+func loadLeaderboard() (models.Leaderboard, error) {
+	var leaderboard models.Leaderboard
+
+	limit, err := getUint("LEADERBOARD_LIMIT", 10)
+	if err != nil {
+		return leaderboard, err
+	}
+	if limit == 0 || limit > leaderboardMaxLimit {
+		return leaderboard, fmt.Errorf("LEADERBOARD_LIMIT: значение должно быть от 1 до %d", leaderboardMaxLimit)
+	}
+	leaderboard.Limit = limit
+
+	location, err := time.LoadLocation(getString("LEADERBOARD_TIMEZONE", "UTC"))
+	if err != nil {
+		return leaderboard, fmt.Errorf("LEADERBOARD_TIMEZONE: %w", err)
+	}
+	leaderboard.Location = location
+
+	if leaderboard.Cache, err = getBool("LEADERBOARD_CACHE", true); err != nil {
+		return leaderboard, err
+	}
+	refresh, err := getDuration("LEADERBOARD_CACHE_REFRESH", 5*time.Minute)
+	if err != nil {
+		return leaderboard, err
+	}
+	if refresh <= 0 {
+		return leaderboard, fmt.Errorf("LEADERBOARD_CACHE_REFRESH: значение должно быть больше нуля")
+	}
+	leaderboard.CacheRefresh = refresh
+
+	return leaderboard, nil
+}
+
+// loadPasswordHashing читает настройки хэширования паролей: PASSWORD_HASH_ALGORITHM, BCRYPT_COST,
+// ARGON2_TIME, ARGON2_MEMORY, ARGON2_THREADS.
+func loadPasswordHashing() (PasswordHashing, error) {
+	hashing := PasswordHashing{
+		Algorithm: getString("PASSWORD_HASH_ALGORITHM", PasswordHashArgon2id),
+	}
+	if hashing.Algorithm != PasswordHashArgon2id && hashing.Algorithm != PasswordHashBcrypt {
+		return hashing, fmt.Errorf("PASSWORD_HASH_ALGORITHM: неизвестный алгоритм %q, допустимо: argon2id, bcrypt", hashing.Algorithm)
+	}
+
+	cost, err := getUint("BCRYPT_COST", 10)
+	if err != nil {
+		return hashing, err
+	}
+	if cost < 4 || cost > 31 {
+		return hashing, fmt.Errorf("BCRYPT_COST: значение должно быть от 4 до 31")
+	}
+	hashing.BcryptCost = int(cost)
+
+	iterations, err := getUint("ARGON2_TIME", 2)
+	if err != nil {
+		return hashing, err
+	}
+	threads, err := getUint("ARGON2_THREADS", 1)
+	if err != nil {
+		return hashing, err
+	}
+	memory, err := getUint("ARGON2_MEMORY", 19456)
+	if err != nil {
+		return hashing, err
+	}
+	if iterations == 0 || iterations > math.MaxUint32 || threads == 0 || threads > math.MaxUint8 {
+		return hashing, fmt.Errorf("ARGON2_TIME, ARGON2_THREADS: некорректные значения")
+	}
+	if memory < 8*threads || memory > math.MaxUint32 {
+		return hashing, fmt.Errorf("ARGON2_MEMORY: значение должно быть не меньше 8*ARGON2_THREADS KiB")
+	}
+	hashing.Argon2Time = uint32(iterations)
+	hashing.Argon2Memory = uint32(memory)
+	hashing.Argon2Threads = uint8(threads)
+
+	return hashing, nil
+}
+
+// loadMail читает настройки отправки писем: MAIL_DRIVER, MAIL_FROM, MAIL_OUTBOX_FILE,
+// SMTP_HOST, SMTP_PORT, SMTP_USERNAME, SMTP_PASSWORD.
+func loadMail() (Mail, error) {
+	mail := Mail{
+		Driver:       getString("MAIL_DRIVER", MailDriverFile),
+		From:         getString("MAIL_FROM", "noreply@taskreward.local"),
+		OutboxFile:   getString("MAIL_OUTBOX_FILE", ""),
+		SMTPHost:     getString("SMTP_HOST", ""),
+		SMTPUsername: getString("SMTP_USERNAME", ""),
+		SMTPPassword: os.Getenv("SMTP_PASSWORD"),
+	}
+
+	port, err := getUint("SMTP_PORT", 587)
+	if err != nil {
+		return mail, err
+	}
+	mail.SMTPPort = port
+
+	switch mail.Driver {
+	case MailDriverFile:
+	case MailDriverSMTP:
+		if mail.SMTPHost == "" {
+			return mail, fmt.Errorf("SMTP_HOST: не задан сервер для MAIL_DRIVER=smtp")
+		}
+	default:
+		return mail, fmt.Errorf("MAIL_DRIVER: неизвестный драйвер %q, допустимо: file, smtp", mail.Driver)
+	}
+
+	return mail, nil
+}
+
+// loadLoginThrottle читает настройки защиты входа от перебора паролей:
+// LOGIN_MAX_FAILURES - неудачных попыток по логину до блокировки (по умолчанию 5),
+// LOGIN_IP_MAX_FAILURES - неудачных попыток с одного IP до блокировки (по умолчанию 50; за обратным прокси
+// без TRUSTED_PROXIES все клиенты имеют IP прокси и блокируются вместе),
+// LOGIN_LOCKOUT - первая блокировка (по умолчанию 1m), LOGIN_LOCKOUT_MAX - максимальная (по умолчанию 1h),
+// LOGIN_FAILURE_WINDOW - через сколько без неудач счетчик сбрасывается (по умолчанию 1h).
+func loadLoginThrottle() (models.LoginThrottle, error) {
+	var throttle models.LoginThrottle
+	var err error
+
+	if throttle.MaxFailures, err = getUint("LOGIN_MAX_FAILURES", 5); err != nil {
+		return throttle, err
+	}
+	if throttle.IPMaxFailures, err = getUint("LOGIN_IP_MAX_FAILURES", 50); err != nil {
+		return throttle, err
+	}
+	if throttle.MaxFailures == 0 || throttle.IPMaxFailures == 0 {
+		return throttle, fmt.Errorf("LOGIN_MAX_FAILURES, LOGIN_IP_MAX_FAILURES: значение должно быть больше 0")
+	}
+	if throttle.BaseLockout, err = getDuration("LOGIN_LOCKOUT", time.Minute); err != nil {
+		return throttle, err
+	}
+	if throttle.MaxLockout, err = getDuration("LOGIN_LOCKOUT_MAX", time.Hour); err != nil {
+		return throttle, err
+	}
+	if throttle.MaxLockout < throttle.BaseLockout {
+		return throttle, fmt.Errorf("LOGIN_LOCKOUT_MAX: значение должно быть не меньше LOGIN_LOCKOUT")
+	}
+	if throttle.FailureWindow, err = getDuration("LOGIN_FAILURE_WINDOW", time.Hour); err != nil {
+		return throttle, err
+	}
+
+	return throttle, nil
+}
+
+// loadTrustedProxies читает TRUSTED_PROXIES: IP адреса и подсети CIDR обратных прокси через запятую.
+func loadTrustedProxies() ([]netip.Prefix, error) {
+	var proxies []netip.Prefix
+
+	value := os.Getenv("TRUSTED_PROXIES")
+	if value == "" {
+		return proxies, nil
+	}
+
+	for _, part := range strings.Split(value, ",") {
+		part = strings.TrimSpace(part)
+		if strings.Contains(part, "/") {
+			prefix, err := netip.ParsePrefix(part)
+			if err != nil {
+				return nil, fmt.Errorf("TRUSTED_PROXIES: некорректная подсеть %q", part)
+			}
+			proxies = append(proxies, prefix.Masked())
+			continue
+		}
+		addr, err := netip.ParseAddr(part)
+		if err != nil {
+			return nil, fmt.Errorf("TRUSTED_PROXIES: некорректный адрес %q", part)
+		}
+		addr = addr.Unmap()
+		proxies = append(proxies, netip.PrefixFrom(addr, addr.BitLen()))
+	}
+
+	return proxies, nil
+}
+
+// loadJWT читает настройки подписи access токенов: JWT_SECRET, JWT_KEYS, JWT_SIGNING_KEY, JWT_EXPIRATION.
+func loadJWT() (JWT, error) {
+	settings := JWT{
+		Secret:       os.Getenv("JWT_SECRET"),
+		SigningKeyID: strings.TrimSpace(os.Getenv("JWT_SIGNING_KEY")),
+	}
+
+	if value := os.Getenv("JWT_KEYS"); value != "" {
+		for _, part := range strings.Split(value, ",") {
+			id, path, ok := strings.Cut(strings.TrimSpace(part), "=")
+			id, path = strings.TrimSpace(id), strings.TrimSpace(path)
+			if !ok || id == "" || path == "" {
+				return settings, fmt.Errorf("JWT_KEYS: некорректное значение %q, ожидается kid=путь", part)
+			}
+			settings.Keys = append(settings.Keys, JWTKey{ID: id, PEMFile: path})
+		}
+	}
+
+	if settings.Secret == "" && len(settings.Keys) == 0 {
+		return settings, fmt.Errorf("JWT_SECRET или JWT_KEYS: не задан ключ подписи токенов")
+	}
+
+	ttl, err := getDuration("JWT_EXPIRATION", time.Hour)
+	if err != nil {
+		return settings, err
+	}
+	settings.AccessTTL = ttl
+
+	return settings, nil
+}
+
+// loadReferral читает настройки реферальной программы:
+// REFERRAL_SIGNUP_BONUS - бонус пригласившему за первую задачу приглашенного,
+// REFERRAL_COMMISSION_PERCENTS - проценты комиссии по уровням через запятую, например "10,5,2",
+// REFERRAL_TREE_MAX_DEPTH - максимальная глубина дерева приглашенных (по умолчанию 5),
+// REFERRAL_INVITE_URL - адрес страницы регистрации для ссылок-приглашений.
+func loadReferral() (models.ReferralProgram, error) {
+	var program models.ReferralProgram
+
+	bonus, err := getUint("REFERRAL_SIGNUP_BONUS", 0)
+	if err != nil {
+		return program, err
+	}
+	program.SignupBonus = bonus
+
+	percents, err := getUintList("REFERRAL_COMMISSION_PERCENTS")
+	if err != nil {
+		return program, err
+	}
+	for _, percent := range percents {
+		if percent > 100 {
+			return program, fmt.Errorf("REFERRAL_COMMISSION_PERCENTS: процент %d больше 100", percent)
+		}
+	}
+	program.CommissionPercents = percents
+
+	depth, err := getUint("REFERRAL_TREE_MAX_DEPTH", 5)
+	if err != nil {
+		return program, err
+	}
+	if depth == 0 {
+		return program, fmt.Errorf("REFERRAL_TREE_MAX_DEPTH: значение должно быть больше 0")
+	}
+	program.TreeMaxDepth = depth
+
+	program.InviteURL = strings.TrimSpace(os.Getenv("REFERRAL_INVITE_URL"))
+
+	return program, nil
+}
+
+// getString возвращает строку из переменной окружения или значение по умолчанию.
+func getString(key string, defaultValue string) string {
+	value := strings.TrimSpace(os.Getenv(key))
+	if value == "" {
+		return defaultValue
+	}
+	return value
+}
+
+// getUint возвращает неотрицательное целое из переменной окружения или значение по умолчанию.
+func getUint(key string, defaultValue uint) (uint, error) {
+	value := os.Getenv(key)
+	if value == "" {
+		return defaultValue, nil
+	}
+
+	parsed, err := strconv.ParseUint(strings.TrimSpace(value), 10, 64)
+	if err != nil {
+		return 0, fmt.Errorf("%s: некорректное значение %q", key, value)
+	}
+
+	return uint(parsed), nil
+}
+
+// getBool возвращает логическое значение из переменной окружения (true/false, 1/0) или значение по умолчанию.
+func getBool(key string, defaultValue bool) (bool, error) {
+	value := os.Getenv(key)
+	if value == "" {
+		return defaultValue, nil
+	}
+
+	parsed, err := strconv.ParseBool(strings.TrimSpace(value))
+	if err != nil {
+		return false, fmt.Errorf("%s: некорректное значение %q", key, value)
+	}
+
+	return parsed, nil
+}
+
+// getDuration возвращает положительную длительность из переменной окружения (например "24h")
+// или значение по умолчанию.
+func getDuration(key string, defaultValue time.Duration) (time.Duration, error) {
+	value := os.Getenv(key)
+	if value == "" {
+		return defaultValue, nil
+	}
+
+	parsed, err := time.ParseDuration(strings.TrimSpace(value))
+	if err != nil || parsed <= 0 {
+		return 0, fmt.Errorf("%s: некорректное значение %q", key, value)
+	}
+
+	return parsed, nil
+}
+
+// getUintList возвращает список неотрицательных целых, перечисленных через запятую.
+func getUintList(key string) ([]uint, error) {
+	value := os.Getenv(key)
+	if value == "" {
+		return nil, nil
+	}
+
+	parts := strings.Split(value, ",")
+	list := make([]uint, 0, len(parts))
+	for _, part := range parts {
+		parsed, err := strconv.ParseUint(strings.TrimSpace(part), 10, 64)
+		if err != nil {
+			return nil, fmt.Errorf("%s: некорректное значение %q", key, value)
+		}
+		list = append(list, uint(parsed))
+	}
+
+	return list, nil
+}
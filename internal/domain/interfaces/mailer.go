@@ -0,0 +1,11 @@
+package interfaces
+
+import (
+	"context"
+	"github.com/RVodassa/TaskReward/internal/domain/models"
+)
+
+// Mailer отправляет письма пользователям.
+type Mailer interface {
+	Send(ctx context.Context, email *models.Email) error
+}
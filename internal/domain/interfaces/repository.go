@@ -3,14 +3,80 @@ package interfaces
 import (
 	"context"
 	"github.com/RVodassa/TaskReward/internal/domain/models"
+	"time"
 )
 
 type RepositoryProvider interface {
-	RegisterUser(ctx context.Context, user *models.User) error
+	RegisterUser(ctx context.Context, user *models.User, referralCode string) error
 	GetUserByLogin(ctx context.Context, login string) (*models.User, error)
+	GetUserByEmail(ctx context.Context, email string) (*models.User, error)
+	UpdateEmail(ctx context.Context, userID uint, email string) error
 	GetUserByID(ctx context.Context, id uint) (*models.User, error)
+	SetUserRole(ctx context.Context, userID uint, role string) error
 	AddTask(ctx context.Context, task *models.Task) error
 	TaskComplete(ctx context.Context, taskID uint, userID uint) (*models.Task, error)
-	GetListTopUsers(ctx context.Context) ([]*models.User, error)
-	GetAllActiveTask(ctx context.Context) ([]*models.Task, error)
+	GetListTopUsers(ctx context.Context, since *time.Time, limit uint, verifiedOnly bool) ([]*models.LeaderboardEntry, error)
+	AddSeason(ctx context.Context, season *models.Season) error
+	GetSeasons(ctx context.Context, limit, offset uint) ([]*models.Season, error)
+	GetSeason(ctx context.Context, seasonID uint) (*models.Season, error)
+	GetSeasonScores(ctx context.Context, seasonID uint, verifiedOnly bool, limit, offset uint) ([]*models.SeasonStanding, error)
+	GetSeasonStandings(ctx context.Context, seasonID uint, limit, offset uint) ([]*models.SeasonStanding, error)
+	CloseSeason(ctx context.Context, seasonID uint, adminID uint, verifiedOnly bool) (*models.Season, error)
+	GetLeaderboardNeighbors(ctx context.Context, userID uint, period string, since *time.Time, neighbors uint, verifiedOnly bool) ([]*models.LeaderboardEntry, error)
+	GetLeaderboardSnapshot(ctx context.Context, day, week, month time.Time) (*models.LeaderboardSnapshot, error)
+	ListenLeaderboard(ctx context.Context, listening func(), notify func(payload string)) error
+	NotifyLeaderboardUser(ctx context.Context, userID uint) error
+	AdjustBalance(ctx context.Context, txn *models.BalanceTransaction) error
+	GetBalanceTransactions(ctx context.Context, userID uint, limit, offset uint) ([]*models.BalanceTransaction, uint, error)
+	AddReward(ctx context.Context, reward *models.Reward) error
+	GetRewardByID(ctx context.Context, rewardID uint) (*models.Reward, error)
+	GetRewards(ctx context.Context, onlyActive bool, limit, offset uint) ([]*models.Reward, error)
+	UpdateReward(ctx context.Context, reward *models.Reward) error
+	DeleteReward(ctx context.Context, rewardID uint) error
+	RedeemReward(ctx context.Context, userID uint, rewardID uint) (*models.RewardOrder, error)
+	GetRewardOrders(ctx context.Context, userID uint, status string, limit, offset uint) ([]*models.RewardOrder, error)
+	FulfillRewardOrder(ctx context.Context, orderID uint) (*models.RewardOrder, error)
+	CancelRewardOrder(ctx context.Context, orderID uint, userID uint) (*models.RewardOrder, error)
+	GetReferralTree(ctx context.Context, userID uint, depth uint, limit, offset uint) ([]*models.Referral, error)
+	GetReferralStats(ctx context.Context, userID uint, depth uint) (*models.ReferralStats, error)
+	AddReferralCode(ctx context.Context, code *models.ReferralCode) error
+	GetReferralCodes(ctx context.Context, userID uint) ([]*models.ReferralCode, error)
+	DeactivateReferralCode(ctx context.Context, codeID uint, userID uint) (*models.ReferralCode, error)
+	AddRefreshToken(ctx context.Context, token *models.RefreshToken) error
+	RotateRefreshToken(ctx context.Context, oldHash string, newToken *models.RefreshToken) error
+	RevokeAccessToken(ctx context.Context, jti string, userID uint, expiresAt time.Time) error
+	IsAccessTokenRevoked(ctx context.Context, jti string) (bool, error)
+	RevokeRefreshTokenFamily(ctx context.Context, userID uint, tokenHash string) error
+	RevokeAllTokens(ctx context.Context, userID uint) error
+	UpdatePassword(ctx context.Context, userID uint, passwordHash string) error
+	RehashPassword(ctx context.Context, userID uint, oldHash, newHash string) error
+	AddPasswordResetToken(ctx context.Context, token *models.PasswordResetToken) error
+	GetPasswordResetUserID(ctx context.Context, tokenHash string) (uint, error)
+	ResetPassword(ctx context.Context, tokenHash string, passwordHash string) (uint, error)
+	AddEmailVerificationToken(ctx context.Context, token *models.EmailVerificationToken) error
+	VerifyEmail(ctx context.Context, tokenHash string) (uint, error)
+	SetUserBanned(ctx context.Context, userID uint, banned bool) error
+	UpdateProfile(ctx context.Context, userID uint, displayName, avatarURL, visibility string) error
+	SetLeaderboardExcluded(ctx context.Context, userID uint, excluded bool) error
+	AddAPIKey(ctx context.Context, key *models.APIKey) error
+	GetAPIKeys(ctx context.Context, limit, offset uint) ([]*models.APIKey, error)
+	GetAPIKeyByPrefix(ctx context.Context, prefix string) (*models.APIKey, error)
+	RevokeAPIKey(ctx context.Context, keyID uint) (*models.APIKey, error)
+	TouchAPIKey(ctx context.Context, keyID uint, usedAt time.Time) error
+	GetLoginLockedUntil(ctx context.Context, login, ip string) (*time.Time, error)
+	RecordLoginFailure(ctx context.Context, kind, subject string, windowStart time.Time) (uint, error)
+	LockLogin(ctx context.Context, kind, subject string, lockedUntil time.Time) error
+	ResetLoginFailures(ctx context.Context, kind, subject string) error
+	GetTOTPSecret(ctx context.Context, userID uint) (string, bool, error)
+	SetTOTPSecret(ctx context.Context, userID uint, secret string) error
+	EnableTOTP(ctx context.Context, userID uint, step int64, backupHashes []string) error
+	DisableTOTP(ctx context.Context, userID uint) error
+	UseTOTPStep(ctx context.Context, userID uint, step int64) (bool, error)
+	UseBackupCode(ctx context.Context, userID uint, codeHash string) (bool, error)
+	GetAllActiveTask(ctx context.Context, userID uint) ([]*models.Task, error)
+	GetTaskByID(ctx context.Context, taskID uint) (*models.Task, error)
+	GetAllTasks(ctx context.Context, status string, limit, offset uint) ([]*models.Task, error)
+	UpdateTask(ctx context.Context, task *models.Task) error
+	ArchiveTask(ctx context.Context, taskID uint) error
+	DeleteTask(ctx context.Context, taskID uint) error
 }
@@ -0,0 +1,49 @@
+package models
+
+import "time"
+
+// Права API ключей
+const (
+	ScopeTasksRead     = "tasks:read"     // список задач
+	ScopeTasksWrite    = "tasks:write"    // создание, изменение и архивирование задач
+	ScopeTasksComplete = "tasks:complete" // выполнение задач от имени пользователей
+	ScopeUsersRead     = "users:read"     // информация о пользователях и история операций
+	ScopeBalanceWrite  = "balance:write"  // корректировка баланса
+)
+
+// APIKey ключ для вызова API другими сервисами без входа пользователя. Ключ действует от имени
+// создавшего его администратора, но только на маршрутах, разрешенных его правами Scopes.
+// Хранится только хэш секретной части ключа.
+type APIKey struct {
+	ID         uint       `json:"id"`
+	Name       string     `json:"name"`
+	Prefix     string     `json:"prefix"`
+	SecretHash string     `json:"-"`
+	Key        string     `json:"key,omitempty"` // полный ключ, возвращается только при создании
+	Scopes     []string   `json:"scopes"`
+	CreatedBy  uint       `json:"created_by"`
+	CreatedAt  *time.Time `json:"created_at,omitempty"`
+	ExpiresAt  *time.Time `json:"expires_at,omitempty"`
+	LastUsedAt *time.Time `json:"last_used_at,omitempty"`
+	RevokedAt  *time.Time `json:"revoked_at,omitempty"`
+}
+
+// HasScope проверяет, что ключу выдано право scope.
+func (k *APIKey) HasScope(scope string) bool {
+	for _, s := range k.Scopes {
+		if s == scope {
+			return true
+		}
+	}
+	return false
+}
+
+// IsValidScope проверяет, что право известно системе.
+func IsValidScope(scope string) bool {
+	switch scope {
+	case ScopeTasksRead, ScopeTasksWrite, ScopeTasksComplete, ScopeUsersRead, ScopeBalanceWrite:
+		return true
+	default:
+		return false
+	}
+}
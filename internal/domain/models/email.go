@@ -0,0 +1,8 @@
+package models
+
+// Email письмо пользователю.
+type Email struct {
+	To      string
+	Subject string
+	Body    string // текст письма, text/plain
+}
@@ -0,0 +1,147 @@
+package models
+
+import "time"
+
+// Периоды таблицы лидеров
+const (
+	PeriodDay   = "day"
+	PeriodWeek  = "week"
+	PeriodMonth = "month"
+	PeriodAll   = "all"
+)
+
+// Видимость пользователя в таблицах лидеров
+const (
+	VisibilityPublic    = "public"    // имя и аватар видны всем
+	VisibilityAnonymous = "anonymous" // место видно без имени, ID и аватара
+	VisibilityHidden    = "hidden"    // пользователь не участвует в рейтингах
+)
+
+// Leaderboard настройки таблицы лидеров.
+type Leaderboard struct {
+	Limit        uint           // кол-во лидеров в ответе
+	Location     *time.Location // часовой пояс, в котором считаются границы дня, недели и месяца
+	Cache        bool           // таблицы лидеров читаются из кэша в памяти процесса
+	CacheRefresh time.Duration  // период полной перестройки кэша из базы данных
+}
+
+// LeaderboardEntry место пользователя в таблице лидеров. Score - сумма бонусов за задачи, выполненные
+// за период. Пользователи с одинаковым Score делят место, следующее место идет без пропуска.
+type LeaderboardEntry struct {
+	Rank   uint  `json:"rank"`
+	UserID uint  `json:"id,omitempty"`
+	Score  int64 `json:"score"`
+	LeaderboardProfile
+}
+
+// LeaderboardProfile публичные данные пользователя в таблицах лидеров.
+type LeaderboardProfile struct {
+	DisplayName string `json:"display_name,omitempty"`
+	AvatarURL   string `json:"avatar_url,omitempty"`
+	Anonymous   bool   `json:"anonymous,omitempty"` // пользователь скрыл имя, ID и аватар
+	Visibility  string `json:"-"`
+}
+
+// IsValidVisibility проверяет, что видимость в таблицах лидеров известна системе.
+func IsValidVisibility(visibility string) bool {
+	switch visibility {
+	case VisibilityPublic, VisibilityAnonymous, VisibilityHidden:
+		return true
+	default:
+		return false
+	}
+}
+
+// IsValidPeriod проверяет, что период таблицы лидеров известен системе.
+func IsValidPeriod(period string) bool {
+	switch period {
+	case PeriodDay, PeriodWeek, PeriodMonth, PeriodAll:
+		return true
+	default:
+		return false
+	}
+}
+
+// PeriodStart возвращает начало периода таблицы лидеров в UTC, nil - без ограничения (all).
+// Неделя начинается с понедельника. ok = false для неизвестного периода.
+func PeriodStart(period string, now time.Time, location *time.Location) (start *time.Time, ok bool) {
+	now = now.In(location)
+	year, month, day := now.Date()
+
+	var t time.Time
+	switch period {
+	case PeriodAll:
+		return nil, true
+	case PeriodDay:
+		t = time.Date(year, month, day, 0, 0, 0, 0, location)
+	case PeriodWeek:
+		// Weekday: воскресенье - 0, сдвигаем так, чтобы понедельник был 0
+		offset := (int(now.Weekday()) + 6) % 7
+		t = time.Date(year, month, day-offset, 0, 0, 0, 0, location)
+	case PeriodMonth:
+		t = time.Date(year, month, 1, 0, 0, 0, 0, location)
+	default:
+		return nil, false
+	}
+
+	t = t.UTC()
+	return &t, true
+}
+
+// LeaderboardPosition место пользователя в таблице лидеров и соседи по таблице: Above - выше пользователя,
+// Below - ниже, в порядке таблицы. User = nil, если у пользователя нет бонусов за период.
+type LeaderboardPosition struct {
+	User  *LeaderboardEntry   `json:"user"`
+	Above []*LeaderboardEntry `json:"above"`
+	Below []*LeaderboardEntry `json:"below"`
+}
+
+// События изменения таблиц лидеров
+const (
+	LeaderboardEventScore = "score" // пользователь получил бонус за задачу
+	LeaderboardEventUser  = "user"  // изменились профиль, видимость или подтверждение email пользователя
+)
+
+// LeaderboardEvent событие изменения таблиц лидеров, которое рассылается всем экземплярам сервиса.
+// XID - номер транзакции, начислившей бонус: по нему кэш отличает бонусы, уже вошедшие в снимок.
+type LeaderboardEvent struct {
+	Type        string    `json:"type"`
+	UserID      uint      `json:"user_id"`
+	Bonus       int64     `json:"bonus,omitempty"`
+	CompletedAt time.Time `json:"completed_at"`
+	XID         uint64    `json:"xid,string,omitempty"`
+}
+
+// LeaderboardScore суммы бонусов пользователя за текущие день, неделю, месяц и за все время
+// вместе с данными, от которых зависит участие в рейтингах.
+type LeaderboardScore struct {
+	UserID   uint
+	Day      int64
+	Week     int64
+	Month    int64
+	All      int64
+	Verified bool // email подтвержден
+	Excluded bool // исключен администратором
+	LeaderboardProfile
+}
+
+// LeaderboardSnapshot суммы бонусов всех пользователей на момент снимка базы данных.
+// Xmin, Xmax и XIP - границы снимка транзакций (pg_current_snapshot).
+type LeaderboardSnapshot struct {
+	Scores []*LeaderboardScore
+	Xmin   uint64
+	Xmax   uint64
+	XIP    map[uint64]struct{}
+}
+
+// Visible проверяет, что изменения транзакции xid вошли в снимок.
+func (s *LeaderboardSnapshot) Visible(xid uint64) bool {
+	if xid < s.Xmin {
+		return true
+	}
+	if xid >= s.Xmax {
+		return false
+	}
+	_, inProgress := s.XIP[xid]
+	return !inProgress
+}
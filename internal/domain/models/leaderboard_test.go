@@ -0,0 +1,73 @@
+package models
+
+import (
+	"testing"
+	"time"
+)
+
+func TestLeaderboardSnapshotVisible(t *testing.T) {
+	snapshot := &LeaderboardSnapshot{
+		Xmin: 100,
+		Xmax: 110,
+		XIP:  map[uint64]struct{}{100: {}, 105: {}},
+	}
+
+	tests := []struct {
+		name string
+		xid  uint64
+		want bool
+	}{
+		{name: "xid < xmin", xid: 99, want: true},
+		{name: "xid = xmin in progress", xid: 100, want: false},
+		{name: "committed between xmin and xmax", xid: 103, want: true},
+		{name: "xid in xip", xid: 105, want: false},
+		{name: "xid = xmax", xid: 110, want: false},
+		{name: "xid > xmax", xid: 200, want: false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := snapshot.Visible(tt.xid); got != tt.want {
+				t.Errorf("Visible(%d) = %v, want %v", tt.xid, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestPeriodStart(t *testing.T) {
+	utc3 := time.FixedZone("UTC+3", 3*60*60)
+	// Понедельник, 12 октября 2026 22:30 UTC - уже вторник 13 октября по UTC+3
+	now := time.Date(2026, time.October, 12, 22, 30, 0, 0, time.UTC)
+
+	tests := []struct {
+		name     string
+		period   string
+		location *time.Location
+		want     time.Time
+	}{
+		{name: "day UTC", period: PeriodDay, location: time.UTC, want: time.Date(2026, time.October, 12, 0, 0, 0, 0, time.UTC)},
+		{name: "day UTC+3", period: PeriodDay, location: utc3, want: time.Date(2026, time.October, 12, 21, 0, 0, 0, time.UTC)},
+		{name: "week UTC", period: PeriodWeek, location: time.UTC, want: time.Date(2026, time.October, 12, 0, 0, 0, 0, time.UTC)},
+		{name: "week UTC+3", period: PeriodWeek, location: utc3, want: time.Date(2026, time.October, 11, 21, 0, 0, 0, time.UTC)},
+		{name: "month UTC+3", period: PeriodMonth, location: utc3, want: time.Date(2026, time.September, 30, 21, 0, 0, 0, time.UTC)},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			start, ok := PeriodStart(tt.period, now, tt.location)
+			if !ok || start == nil {
+				t.Fatalf("PeriodStart(%q) = %v, %v", tt.period, start, ok)
+			}
+			if !start.Equal(tt.want) || start.Location() != time.UTC {
+				t.Errorf("PeriodStart(%q) = %v, want %v", tt.period, start, tt.want)
+			}
+		})
+	}
+
+	if start, ok := PeriodStart(PeriodAll, now, time.UTC); !ok || start != nil {
+		t.Errorf("PeriodStart(all) = %v, %v, want nil, true", start, ok)
+	}
+	if _, ok := PeriodStart("year", now, time.UTC); ok {
+		t.Errorf("PeriodStart(year): expected ok = false")
+	}
+}
@@ -0,0 +1,22 @@
+package models
+
+import "time"
+
+// Виды счетчиков неудачных попыток входа
+const (
+	LoginFailureByLogin = "login"
+	LoginFailureByIP    = "ip"
+	// LoginFailureByChallenge попытки ввода кода по одному токену второго шага входа, subject - jti токена
+	LoginFailureByChallenge = "challenge"
+)
+
+// LoginThrottle настройки защиты входа от перебора паролей. После MaxFailures неудачных попыток
+// подряд вход блокируется на BaseLockout, каждая следующая неудача удваивает блокировку до MaxLockout.
+// Счетчик сбрасывается после успешного входа или FailureWindow без неудач.
+type LoginThrottle struct {
+	MaxFailures   uint // по логину
+	IPMaxFailures uint // по IP адресу
+	BaseLockout   time.Duration
+	MaxLockout    time.Duration
+	FailureWindow time.Duration
+}
@@ -0,0 +1,52 @@
+package models
+
+import "time"
+
+// ReferralProgram настройки реферальной программы.
+type ReferralProgram struct {
+	// SignupBonus начисляется пригласившему, когда приглашенный выполняет первую задачу. 0 - выключено.
+	SignupBonus uint
+	// CommissionPercents процент от бонусов приглашенного по уровням: [0] - пригласившему,
+	// [1] - пригласившему пригласившего и т.д. Пустой список - комиссия выключена.
+	CommissionPercents []uint
+	// TreeMaxDepth максимальная глубина дерева приглашенных, доступная для просмотра.
+	TreeMaxDepth uint
+	// InviteURL адрес страницы регистрации, к которому добавляется ?ref=<код>. Пусто - ссылки не формируются.
+	InviteURL string
+}
+
+// ReferralCode код приглашения пользователя. Регистрация по коду делает владельца кода пригласившим.
+type ReferralCode struct {
+	ID        uint       `json:"id"`
+	UserID    uint       `json:"user_id"`
+	Code      string     `json:"code"`
+	Link      string     `json:"link,omitempty"`
+	MaxUses   uint       `json:"max_uses"` // 0 - без ограничений
+	Uses      uint       `json:"uses"`
+	ExpiresAt *time.Time `json:"expires_at,omitempty"`
+	Active    bool       `json:"active"`
+	CreatedAt *time.Time `json:"created_at,omitempty"`
+}
+
+// Referral приглашенный пользователь в дереве рефералов.
+type Referral struct {
+	ID        uint       `json:"id"`
+	Login     string     `json:"login"`
+	ReferID   uint       `json:"refer_id"` // кто пригласил
+	Level     uint       `json:"level"`    // 1 - приглашен напрямую
+	Active    bool       `json:"active"`   // выполнил хотя бы одну задачу
+	CreatedAt *time.Time `json:"created_at,omitempty"`
+}
+
+// ReferralStats сводная статистика приглашений пользователя.
+type ReferralStats struct {
+	UserID              uint `json:"user_id"`
+	Invited             uint `json:"invited"`               // приглашено напрямую
+	ActiveInvited       uint `json:"active_invited"`        // из них выполнили хотя бы одну задачу
+	Downline            uint `json:"downline"`              // всего в дереве до максимальной глубины
+	SignupBonusEarnings uint `json:"signup_bonus_earnings"` // заработано бонусами за первые задачи
+	CommissionEarnings  uint `json:"commission_earnings"`   // заработано комиссией
+	TotalEarnings       uint `json:"total_earnings"`
+	// Codes коды приглашения пользователя с кол-вом регистраций по каждому
+	Codes []*ReferralCode `json:"codes"`
+}
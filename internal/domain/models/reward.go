@@ -0,0 +1,43 @@
+package models
+
+import "time"
+
+// Статусы заказов на получение наград
+const (
+	OrderStatusPending   = "pending"
+	OrderStatusFulfilled = "fulfilled"
+	OrderStatusCancelled = "cancelled"
+)
+
+// Reward награда из каталога, которую можно получить в обмен на баллы.
+type Reward struct {
+	ID          uint       `json:"id"`
+	Name        string     `json:"name"`
+	Description string     `json:"description,omitempty"`
+	Cost        uint       `json:"cost"`
+	Stock       uint       `json:"stock"`
+	Active      bool       `json:"active"`
+	CreatedAt   *time.Time `json:"created_at,omitempty"`
+}
+
+// RewardOrder заказ пользователя на получение награды.
+type RewardOrder struct {
+	ID         uint       `json:"id"`
+	UserID     uint       `json:"user_id"`
+	RewardID   uint       `json:"reward_id"`
+	RewardName string     `json:"reward_name,omitempty"`
+	Cost       uint       `json:"cost"`
+	Status     string     `json:"status"` // "pending", "fulfilled", "cancelled"
+	CreatedAt  *time.Time `json:"created_at,omitempty"`
+	UpdatedAt  *time.Time `json:"updated_at,omitempty"`
+}
+
+// IsValidOrderStatus проверяет, что статус заказа известен системе.
+func IsValidOrderStatus(status string) bool {
+	switch status {
+	case OrderStatusPending, OrderStatusFulfilled, OrderStatusCancelled:
+		return true
+	default:
+		return false
+	}
+}
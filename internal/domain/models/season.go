@@ -0,0 +1,49 @@
+package models
+
+import "time"
+
+// Статусы сезонов
+const (
+	SeasonStatusUpcoming = "upcoming" // еще не начался
+	SeasonStatusActive   = "active"   // баллы начисляются
+	SeasonStatusEnded    = "ended"    // завершился, итоги еще не подведены
+	SeasonStatusClosed   = "closed"   // итоги сохранены, призы начислены
+)
+
+// Season соревнование с ограниченным сроком. За задачи, выполненные в течение сезона, пользователь
+// получает баллы сезона, отдельные от баланса. При закрытии сезона итоговая таблица сохраняется,
+// а призы Prizes (Prizes[0] - за 1 место и т.д.) начисляются на баланс.
+type Season struct {
+	ID        uint       `json:"id"`
+	Name      string     `json:"name"`
+	StartsAt  time.Time  `json:"starts_at"`
+	EndsAt    time.Time  `json:"ends_at"`
+	Prizes    []uint     `json:"prizes"`
+	Status    string     `json:"status"` // "upcoming", "active", "ended", "closed"
+	ClosedAt  *time.Time `json:"closed_at,omitempty"`
+	CreatedAt *time.Time `json:"created_at,omitempty"`
+}
+
+// SeasonStanding место пользователя в таблице сезона. Пользователи с одинаковыми баллами
+// делят место и получают одинаковый приз.
+type SeasonStanding struct {
+	Rank   uint  `json:"rank"`
+	UserID uint  `json:"id,omitempty"`
+	Score  int64 `json:"score"`
+	Prize  uint  `json:"prize"`
+	LeaderboardProfile
+}
+
+// StatusAt возвращает статус сезона на момент now.
+func (s *Season) StatusAt(now time.Time) string {
+	switch {
+	case s.ClosedAt != nil:
+		return SeasonStatusClosed
+	case now.Before(s.StartsAt):
+		return SeasonStatusUpcoming
+	case now.Before(s.EndsAt):
+		return SeasonStatusActive
+	default:
+		return SeasonStatusEnded
+	}
+}
@@ -3,13 +3,16 @@ package models
 import "time"
 
 type Task struct {
-	ID          uint       `json:"id"`
-	Status      string     `json:"status,omitempty"` // "не завершено", "завершено"
-	Description string     `json:"description"`
-	Bonus       uint       `json:"bonus"`
-	UserID      uint       `json:"user_id,omitempty"`
-	CreatedAt   *time.Time `json:"created_at,omitempty"`
-	CompletedAt *time.Time `json:"completed_at,omitempty"`
+	ID             uint       `json:"id"`
+	Status         string     `json:"status,omitempty"` // "не завершено", "завершено", "в архиве"
+	Description    string     `json:"description"`
+	Bonus          uint       `json:"bonus"`
+	MaxCompletions uint       `json:"max_completions,omitempty"` // 0 - без ограничений
+	Completions    uint       `json:"completions,omitempty"`     // кол-во выполнений задачи
+	Completed      bool       `json:"completed"`                 // выполнена ли задача текущим пользователем
+	UserID         uint       `json:"user_id,omitempty"`
+	CreatedAt      *time.Time `json:"created_at,omitempty"`
+	CompletedAt    *time.Time `json:"completed_at,omitempty"`
 }
 
 func NewTask(description string, bonus uint) *Task {
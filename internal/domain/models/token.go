@@ -0,0 +1,36 @@
+package models
+
+import "time"
+
+// RefreshToken refresh токен пользователя. Хранится только хэш токена.
+type RefreshToken struct {
+	ID        uint
+	UserID    uint
+	FamilyID  string // общий для всех токенов, полученных ротацией от одного входа
+	TokenHash string
+	ExpiresAt time.Time
+	CreatedAt time.Time
+	RotatedAt *time.Time // токен обменян на новый, повторное использование - признак кражи
+	RevokedAt *time.Time
+}
+
+// PasswordResetToken одноразовый токен сброса пароля. Хранится только хэш токена.
+type PasswordResetToken struct {
+	ID        uint
+	UserID    uint
+	TokenHash string
+	ExpiresAt time.Time
+	CreatedAt time.Time
+	UsedAt    *time.Time
+}
+
+// EmailVerificationToken одноразовый токен подтверждения email. Хранится только хэш токена.
+type EmailVerificationToken struct {
+	ID        uint
+	UserID    uint
+	Email     string // подтверждаемый адрес
+	TokenHash string
+	ExpiresAt time.Time
+	CreatedAt time.Time
+	UsedAt    *time.Time
+}
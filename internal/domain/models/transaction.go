@@ -0,0 +1,28 @@
+package models
+
+import "time"
+
+// Типы операций с балансом
+const (
+	TransactionTaskBonus       = "task_bonus"       // бонус за выполнение задачи, reference_id - ID задачи
+	TransactionAdminAdjustment = "admin_adjustment" // ручная корректировка администратором
+	TransactionRedemption      = "redemption"       // списание за награду, reference_id - ID заказа
+	TransactionRefund          = "refund"           // возврат за отмененный заказ, reference_id - ID заказа
+	TransactionSeasonPrize     = "season_prize"     // приз за место в сезоне, reference_id - ID сезона
+
+	TransactionReferralBonus      = "referral_bonus"      // бонус за первую задачу приглашенного, reference_id - ID приглашенного
+	TransactionReferralCommission = "referral_commission" // комиссия с бонуса приглашенного, reference_id - ID задачи
+)
+
+// BalanceTransaction запись журнала изменений баланса пользователя.
+type BalanceTransaction struct {
+	ID           uint       `json:"id"`
+	UserID       uint       `json:"user_id"`
+	Amount       int64      `json:"amount"`
+	BalanceAfter uint       `json:"balance_after"`
+	Type         string     `json:"type"`
+	ReferenceID  uint       `json:"reference_id,omitempty"`
+	AdminID      uint       `json:"admin_id,omitempty"`
+	Comment      string     `json:"comment,omitempty"`
+	CreatedAt    *time.Time `json:"created_at,omitempty"`
+}
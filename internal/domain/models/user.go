@@ -4,22 +4,57 @@ import (
 	"time"
 )
 
+// Роли пользователей
+const (
+	RoleUser      = "user"
+	RoleModerator = "moderator"
+	RoleAdmin     = "admin"
+)
+
 type User struct {
-	ID           uint       `json:"id"`
-	Login        string     `json:"login,omitempty"`
-	PasswordHash string     `json:"-"`
-	ReferID      uint       `json:"refer_id,omitempty"`
-	Balance      uint       `json:"balance"`
-	CreatedAt    *time.Time `json:"created_at,omitempty"`
+	ID                    uint       `json:"id"`
+	Login                 string     `json:"login,omitempty"`
+	Email                 string     `json:"email,omitempty"` // необязательный, нужен для восстановления пароля
+	Verified              bool       `json:"verified"`        // email подтвержден
+	PasswordHash          string     `json:"-"`
+	ReferID               uint       `json:"refer_id,omitempty"`
+	Balance               uint       `json:"balance"`
+	Role                  string     `json:"role,omitempty"` // "user", "moderator", "admin"
+	CreatedAt             *time.Time `json:"created_at,omitempty"`
+	TokenVersion          uint       `json:"-"`                   // должна совпадать с claim ver токена
+	BannedAt              *time.Time `json:"banned_at,omitempty"` // пользователь заблокирован администратором
+	TOTPEnabled           bool       `json:"totp_enabled"`        // вход требует кода второго фактора
+	DisplayName           string     `json:"display_name,omitempty"`
+	AvatarURL             string     `json:"avatar_url,omitempty"`
+	LeaderboardVisibility string     `json:"leaderboard_visibility,omitempty"` // "public", "anonymous", "hidden"
+	LeaderboardExcluded   bool       `json:"leaderboard_excluded,omitempty"`   // исключен администратором из таблиц лидеров
+}
+
+// TOTPEnrollment данные для подключения приложения-аутентификатора.
+type TOTPEnrollment struct {
+	Secret          string `json:"secret"`
+	ProvisioningURI string `json:"provisioning_uri"`
 }
 
 // NewUser создает новый инстанс пользователя
-func NewUser(login string, password string, referID uint) *User {
+func NewUser(login string, password string, email string, referID uint) *User {
 	now := time.Now().UTC()
 	return &User{
 		Login:        login,
+		Email:        email,
 		PasswordHash: password,
 		ReferID:      referID,
+		Role:         RoleUser,
 		CreatedAt:    &now,
 	}
 }
+
+// IsValidRole проверяет, что роль известна системе.
+func IsValidRole(role string) bool {
+	switch role {
+	case RoleUser, RoleModerator, RoleAdmin:
+		return true
+	default:
+		return false
+	}
+}
@@ -0,0 +1,42 @@
+package models
+
+// Классы символов пароля
+const (
+	CharClassLower  = "lower"  // строчная буква
+	CharClassUpper  = "upper"  // заглавная буква
+	CharClassLetter = "letter" // любая буква
+	CharClassDigit  = "digit"
+	CharClassSymbol = "symbol" // не буква и не цифра
+)
+
+// FieldError ошибка проверки поля запроса.
+type FieldError struct {
+	Field   string `json:"field"`
+	Message string `json:"message"`
+}
+
+// PasswordPolicy требования к новым паролям. Длина считается в символах.
+type PasswordPolicy struct {
+	MinLength       uint
+	MaxLength       uint
+	RequiredClasses []string // классы символов, которые должны присутствовать в пароле
+	MaxBytes        uint     // ограничение алгоритма хэширования в байтах (bcrypt - 72), 0 - без ограничения
+	Blocklist       []string // распространенные пароли в дополнение к встроенному списку
+}
+
+// LoginPolicy требования к логину: от MinLength до MaxLength символов, латинские буквы, цифры,
+// '_', '.', '-', первый символ - буква.
+type LoginPolicy struct {
+	MinLength uint
+	MaxLength uint
+}
+
+// IsValidCharClass проверяет, что класс символов известен системе.
+func IsValidCharClass(class string) bool {
+	switch class {
+	case CharClassLower, CharClassUpper, CharClassLetter, CharClassDigit, CharClassSymbol:
+		return true
+	default:
+		return false
+	}
+}
@@ -0,0 +1,464 @@
+package http_handlers
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"github.com/RVodassa/TaskReward/internal/api"
+	"github.com/RVodassa/TaskReward/internal/domain/models"
+	"github.com/RVodassa/TaskReward/internal/services"
+	"log"
+	"net/http"
+	"time"
+)
+
+var (
+	ErrInvalidTaskData    = errors.New("ошибка: описание (до 255 символов) и бонус (больше 0) задачи обязательны")
+	ErrInvalidTaskStatus  = errors.New("ошибка: неизвестный статус задачи, допустимо: open, closed, archived")
+	ErrTaskHasCompletions = errors.New("ошибка: задача уже выполнялась пользователями, ее можно только архивировать")
+	ErrInvalidPagination  = errors.New("ошибка: некорректные параметры limit/offset")
+	ErrCannotBanSelf      = errors.New("ошибка: нельзя заблокировать самого себя")
+)
+
+type AdminServiceProvider interface {
+	AddTask(ctx context.Context, description string, bonus uint, maxCompletions uint) (*models.Task, error)
+	GetAllTasks(ctx context.Context, status string, limit, offset uint) ([]*models.Task, error)
+	UpdateTask(ctx context.Context, taskID uint, description *string, bonus *uint, maxCompletions *uint) (*models.Task, error)
+	ArchiveTask(ctx context.Context, taskID uint) error
+	DeleteTask(ctx context.Context, taskID uint) error
+	SetUserRole(ctx context.Context, userID uint, role string) error
+	SetUserBanned(ctx context.Context, userID uint, banned bool) error
+	UnlockUser(ctx context.Context, userID uint) error
+	SetLeaderboardExcluded(ctx context.Context, userID uint, excluded bool) error
+	AdjustBalance(ctx context.Context, userID uint, amount int64, adminID uint, comment string) (*models.BalanceTransaction, error)
+	AddReward(ctx context.Context, name, description string, cost, stock uint, active bool) (*models.Reward, error)
+	GetRewards(ctx context.Context, onlyActive bool, limit, offset uint) ([]*models.Reward, error)
+	UpdateReward(ctx context.Context, rewardID uint, name, description *string, cost, stock *uint, active *bool) (*models.Reward, error)
+	DeleteReward(ctx context.Context, rewardID uint) error
+	FulfillRewardOrder(ctx context.Context, orderID uint) (*models.RewardOrder, error)
+	CancelRewardOrder(ctx context.Context, orderID uint, userID uint) (*models.RewardOrder, error)
+	CreateSeason(ctx context.Context, name string, startsAt, endsAt time.Time, prizes []uint) (*models.Season, error)
+	CloseSeason(ctx context.Context, seasonID uint, adminID uint) (*models.Season, error)
+	CreateAPIKey(ctx context.Context, adminID uint, name string, scopes []string, expiresAt *time.Time) (*models.APIKey, error)
+	GetAPIKeys(ctx context.Context, limit, offset uint) ([]*models.APIKey, error)
+	RevokeAPIKey(ctx context.Context, keyID uint) (*models.APIKey, error)
+}
+
+// CreateTask godoc
+// @Summary Создать задачу
+// @Description Создает новую активную задачу. max_completions ограничивает кол-во выполнений, 0 - без ограничений.
+// @Tags Admin
+// @Accept json
+// @Produce json
+// @Param request body api.CreateTaskRequest true "Описание, бонус и лимит выполнений"
+// @Success 201 {object} api.TaskResponse "Задача создана"
+// @Failure 403 {object} api.ErrorResponse "Недостаточно прав"
+// @Failure 400 {object} api.ErrorResponse "Ошибка клиента"
+// @Failure 500 {object} api.ErrorResponse "Ошибка на сервере"
+// @Router /admin/tasks [post]
+// @security BearerAuth
+// @security ApiKeyAuth
+func (h *Handler) CreateTask(w http.ResponseWriter, r *http.Request) {
+	const op = "http_handlers.CreateTask"
+
+	var request api.CreateTaskRequest
+	if err := json.NewDecoder(r.Body).Decode(&request); err != nil {
+		log.Printf("%s: ошибка при декодировании запроса: %v", op, err)
+		Responder(w, http.StatusBadRequest, api.ErrorResponse{Status: false, Message: ErrInvalidJSON.Error()})
+		return
+	}
+
+	task, err := h.adminService.AddTask(r.Context(), request.Description, request.Bonus, request.MaxCompletions)
+	if err != nil {
+		if errors.Is(err, services.ErrInvalidTaskData) {
+			Responder(w, http.StatusBadRequest, api.ErrorResponse{Status: false, Message: ErrInvalidTaskData.Error()})
+			return
+		}
+		log.Printf("%s: %v", op, err)
+		Responder(w, http.StatusInternalServerError, api.ErrorResponse{Status: false, Message: ErrInternalServer.Error()})
+		return
+	}
+
+	Responder(w, http.StatusCreated, api.TaskResponse{
+		Status:  true,
+		Message: "Задача создана",
+		Task:    task,
+	})
+}
+
+// GetAllTasks godoc
+// @Summary Получить список всех задач
+// @Description Возвращает задачи в любом статусе с кол-вом выполнений
+// @Tags Admin
+// @Produce json
+// @Param status query string false "Фильтр по статусу: open, closed, archived"
+// @Param limit query int false "Кол-во записей (по умолчанию 50, максимум 100)"
+// @Param offset query int false "Смещение"
+// @Success 200 {object} api.GetAllTasksResponse "Успешно"
+// @Failure 403 {object} api.ErrorResponse "Недостаточно прав"
+// @Failure 400 {object} api.ErrorResponse "Ошибка клиента"
+// @Failure 500 {object} api.ErrorResponse "Ошибка на сервере"
+// @Router /admin/tasks [get]
+// @security BearerAuth
+// @security ApiKeyAuth
+func (h *Handler) GetAllTasks(w http.ResponseWriter, r *http.Request) {
+	const op = "http_handlers.GetAllTasks"
+
+	limit, offset, err := parsePagination(r)
+	if err != nil {
+		Responder(w, http.StatusBadRequest, api.ErrorResponse{Status: false, Message: ErrInvalidPagination.Error()})
+		return
+	}
+
+	tasks, err := h.adminService.GetAllTasks(r.Context(), r.URL.Query().Get("status"), limit, offset)
+	if err != nil {
+		if errors.Is(err, services.ErrInvalidTaskStatus) {
+			Responder(w, http.StatusBadRequest, api.ErrorResponse{Status: false, Message: ErrInvalidTaskStatus.Error()})
+			return
+		}
+		log.Printf("%s: %v", op, err)
+		Responder(w, http.StatusInternalServerError, api.ErrorResponse{Status: false, Message: ErrInternalServer.Error()})
+		return
+	}
+
+	Responder(w, http.StatusOK, api.GetAllTasksResponse{
+		Status:  true,
+		Message: fmt.Sprintf("Список задач. Кол-во задач: %d", len(tasks)),
+		Tasks:   tasks,
+	})
+}
+
+// UpdateTask godoc
+// @Summary Изменить задачу
+// @Description Изменяет описание, бонус и/или лимит выполнений задачи. Не переданные поля остаются без изменений.
+// @Tags Admin
+// @Accept json
+// @Produce json
+// @Param taskID path string true "ID задачи"
+// @Param request body api.UpdateTaskRequest true "Изменяемые поля"
+// @Success 200 {object} api.TaskResponse "Успешно"
+// @Failure 403 {object} api.ErrorResponse "Недостаточно прав"
+// @Failure 400 {object} api.ErrorResponse "Ошибка клиента"
+// @Failure 404 {object} api.ErrorResponse "Задача не найдена"
+// @Failure 500 {object} api.ErrorResponse "Ошибка на сервере"
+// @Router /admin/tasks/{taskID} [patch]
+// @security BearerAuth
+// @security ApiKeyAuth
+func (h *Handler) UpdateTask(w http.ResponseWriter, r *http.Request) {
+	const op = "http_handlers.UpdateTask"
+
+	taskID, err := parseIDParam(r, "taskID")
+	if err != nil {
+		Responder(w, http.StatusBadRequest, api.ErrorResponse{Status: false, Message: ErrInvalidTaskID.Error()})
+		return
+	}
+
+	var request api.UpdateTaskRequest
+	if err = json.NewDecoder(r.Body).Decode(&request); err != nil {
+		log.Printf("%s: ошибка при декодировании запроса: %v", op, err)
+		Responder(w, http.StatusBadRequest, api.ErrorResponse{Status: false, Message: ErrInvalidJSON.Error()})
+		return
+	}
+
+	task, err := h.adminService.UpdateTask(r.Context(), taskID, request.Description, request.Bonus, request.MaxCompletions)
+	if err != nil {
+		switch {
+		case errors.Is(err, services.ErrTaskNotFound):
+			Responder(w, http.StatusNotFound, api.ErrorResponse{Status: false, Message: ErrTaskNotFound.Error()})
+		case errors.Is(err, services.ErrInvalidTaskData):
+			Responder(w, http.StatusBadRequest, api.ErrorResponse{Status: false, Message: ErrInvalidTaskData.Error()})
+		default:
+			log.Printf("%s: %v", op, err)
+			Responder(w, http.StatusInternalServerError, api.ErrorResponse{Status: false, Message: ErrInternalServer.Error()})
+		}
+		return
+	}
+
+	Responder(w, http.StatusOK, api.TaskResponse{
+		Status:  true,
+		Message: "Задача обновлена",
+		Task:    task,
+	})
+}
+
+// ArchiveTask godoc
+// @Summary Архивировать задачу
+// @Description Переводит задачу в архив, после чего ее нельзя выполнить
+// @Tags Admin
+// @Produce json
+// @Param taskID path string true "ID задачи"
+// @Success 200 {object} api.MessageResponse "Успешно"
+// @Failure 403 {object} api.ErrorResponse "Недостаточно прав"
+// @Failure 400 {object} api.ErrorResponse "Ошибка клиента"
+// @Failure 404 {object} api.ErrorResponse "Задача не найдена"
+// @Failure 500 {object} api.ErrorResponse "Ошибка на сервере"
+// @Router /admin/tasks/{taskID}/archive [post]
+// @security BearerAuth
+// @security ApiKeyAuth
+func (h *Handler) ArchiveTask(w http.ResponseWriter, r *http.Request) {
+	const op = "http_handlers.ArchiveTask"
+
+	taskID, err := parseIDParam(r, "taskID")
+	if err != nil {
+		Responder(w, http.StatusBadRequest, api.ErrorResponse{Status: false, Message: ErrInvalidTaskID.Error()})
+		return
+	}
+
+	if err = h.adminService.ArchiveTask(r.Context(), taskID); err != nil {
+		if errors.Is(err, services.ErrTaskNotFound) {
+			Responder(w, http.StatusNotFound, api.ErrorResponse{Status: false, Message: ErrTaskNotFound.Error()})
+			return
+		}
+		log.Printf("%s: %v", op, err)
+		Responder(w, http.StatusInternalServerError, api.ErrorResponse{Status: false, Message: ErrInternalServer.Error()})
+		return
+	}
+
+	Responder(w, http.StatusOK, api.MessageResponse{Status: true, Message: "Задача перемещена в архив"})
+}
+
+// DeleteTask godoc
+// @Summary Удалить задачу
+// @Description Удаляет задачу, которую еще никто не выполнял
+// @Tags Admin
+// @Produce json
+// @Param taskID path string true "ID задачи"
+// @Success 200 {object} api.MessageResponse "Успешно"
+// @Failure 403 {object} api.ErrorResponse "Недостаточно прав"
+// @Failure 400 {object} api.ErrorResponse "Ошибка клиента"
+// @Failure 404 {object} api.ErrorResponse "Задача не найдена"
+// @Failure 409 {object} api.ErrorResponse "Задача уже выполнялась"
+// @Failure 500 {object} api.ErrorResponse "Ошибка на сервере"
+// @Router /admin/tasks/{taskID} [delete]
+// @security BearerAuth
+// @security ApiKeyAuth
+func (h *Handler) DeleteTask(w http.ResponseWriter, r *http.Request) {
+	const op = "http_handlers.DeleteTask"
+
+	taskID, err := parseIDParam(r, "taskID")
+	if err != nil {
+		Responder(w, http.StatusBadRequest, api.ErrorResponse{Status: false, Message: ErrInvalidTaskID.Error()})
+		return
+	}
+
+	if err = h.adminService.DeleteTask(r.Context(), taskID); err != nil {
+		switch {
+		case errors.Is(err, services.ErrTaskNotFound):
+			Responder(w, http.StatusNotFound, api.ErrorResponse{Status: false, Message: ErrTaskNotFound.Error()})
+		case errors.Is(err, services.ErrTaskHasCompletions):
+			Responder(w, http.StatusConflict, api.ErrorResponse{Status: false, Message: ErrTaskHasCompletions.Error()})
+		default:
+			log.Printf("%s: %v", op, err)
+			Responder(w, http.StatusInternalServerError, api.ErrorResponse{Status: false, Message: ErrInternalServer.Error()})
+		}
+		return
+	}
+
+	Responder(w, http.StatusOK, api.MessageResponse{Status: true, Message: "Задача удалена"})
+}
+
+// SetUserRole godoc
+// @Summary Назначить роль пользователю
+// @Description Назначает пользователю роль user, moderator или admin. Новая роль применяется сразу.
+// @Tags Admin
+// @Accept json
+// @Produce json
+// @Param userID path string true "ID пользователя"
+// @Param request body api.SetRoleRequest true "Роль"
+// @Success 200 {object} api.MessageResponse "Успешно"
+// @Failure 403 {object} api.ErrorResponse "Недостаточно прав"
+// @Failure 400 {object} api.ErrorResponse "Ошибка клиента"
+// @Failure 404 {object} api.ErrorResponse "Пользователь не найден"
+// @Failure 500 {object} api.ErrorResponse "Ошибка на сервере"
+// @Router /admin/users/{userID}/role [put]
+// @security BearerAuth
+func (h *Handler) SetUserRole(w http.ResponseWriter, r *http.Request) {
+	const op = "http_handlers.SetUserRole"
+
+	userID, err := parseIDParam(r, "userID")
+	if err != nil {
+		Responder(w, http.StatusBadRequest, api.ErrorResponse{Status: false, Message: ErrInvalidID.Error()})
+		return
+	}
+
+	var request api.SetRoleRequest
+	if err = json.NewDecoder(r.Body).Decode(&request); err != nil {
+		log.Printf("%s: ошибка при декодировании запроса: %v", op, err)
+		Responder(w, http.StatusBadRequest, api.ErrorResponse{Status: false, Message: ErrInvalidJSON.Error()})
+		return
+	}
+
+	if err = h.adminService.SetUserRole(r.Context(), userID, request.Role); err != nil {
+		switch {
+		case errors.Is(err, services.ErrInvalidRole):
+			Responder(w, http.StatusBadRequest, api.ErrorResponse{Status: false, Message: ErrInvalidRole.Error()})
+		case errors.Is(err, services.ErrUserNotFound):
+			Responder(w, http.StatusNotFound, api.ErrorResponse{Status: false, Message: ErrUserNotFound.Error()})
+		default:
+			log.Printf("%s: %v", op, err)
+			Responder(w, http.StatusInternalServerError, api.ErrorResponse{Status: false, Message: ErrInternalServer.Error()})
+		}
+		return
+	}
+
+	Responder(w, http.StatusOK, api.MessageResponse{Status: true, Message: "Роль пользователя изменена"})
+}
+
+// BanUser godoc
+// @Summary Заблокировать пользователя
+// @Description Блокирует пользователя: вход запрещается, все выданные токены сразу становятся недействительными
+// @Tags Admin
+// @Produce json
+// @Param userID path string true "ID пользователя"
+// @Success 200 {object} api.MessageResponse "Успешно"
+// @Failure 403 {object} api.ErrorResponse "Недостаточно прав"
+// @Failure 400 {object} api.ErrorResponse "Ошибка клиента"
+// @Failure 404 {object} api.ErrorResponse "Пользователь не найден"
+// @Failure 500 {object} api.ErrorResponse "Ошибка на сервере"
+// @Router /admin/users/{userID}/ban [post]
+// @security BearerAuth
+func (h *Handler) BanUser(w http.ResponseWriter, r *http.Request) {
+	h.setUserBanned(w, r, "http_handlers.BanUser", true)
+}
+
+// UnbanUser godoc
+// @Summary Разблокировать пользователя
+// @Description Снимает блокировку пользователя, ранее отозванные токены остаются недействительными
+// @Tags Admin
+// @Produce json
+// @Param userID path string true "ID пользователя"
+// @Success 200 {object} api.MessageResponse "Успешно"
+// @Failure 403 {object} api.ErrorResponse "Недостаточно прав"
+// @Failure 400 {object} api.ErrorResponse "Ошибка клиента"
+// @Failure 404 {object} api.ErrorResponse "Пользователь не найден"
+// @Failure 500 {object} api.ErrorResponse "Ошибка на сервере"
+// @Router /admin/users/{userID}/unban [post]
+// @security BearerAuth
+func (h *Handler) UnbanUser(w http.ResponseWriter, r *http.Request) {
+	h.setUserBanned(w, r, "http_handlers.UnbanUser", false)
+}
+
+// UnlockUser godoc
+// @Summary Снять блокировку входа
+// @Description Сбрасывает счетчик неудачных попыток входа по логину пользователя и снимает временную блокировку входа
+// @Tags Admin
+// @Produce json
+// @Param userID path string true "ID пользователя"
+// @Success 200 {object} api.MessageResponse "Успешно"
+// @Failure 403 {object} api.ErrorResponse "Недостаточно прав"
+// @Failure 400 {object} api.ErrorResponse "Ошибка клиента"
+// @Failure 404 {object} api.ErrorResponse "Пользователь не найден"
+// @Failure 500 {object} api.ErrorResponse "Ошибка на сервере"
+// @Router /admin/users/{userID}/unlock [post]
+// @security BearerAuth
+func (h *Handler) UnlockUser(w http.ResponseWriter, r *http.Request) {
+	const op = "http_handlers.UnlockUser"
+
+	userID, err := parseIDParam(r, "userID")
+	if err != nil {
+		Responder(w, http.StatusBadRequest, api.ErrorResponse{Status: false, Message: ErrInvalidID.Error()})
+		return
+	}
+
+	if err = h.adminService.UnlockUser(r.Context(), userID); err != nil {
+		if errors.Is(err, services.ErrUserNotFound) {
+			Responder(w, http.StatusNotFound, api.ErrorResponse{Status: false, Message: ErrUserNotFound.Error()})
+			return
+		}
+		log.Printf("%s: %v", op, err)
+		Responder(w, http.StatusInternalServerError, api.ErrorResponse{Status: false, Message: ErrInternalServer.Error()})
+		return
+	}
+
+	Responder(w, http.StatusOK, api.MessageResponse{Status: true, Message: "Блокировка входа снята"})
+}
+
+// ExcludeFromLeaderboard godoc
+// @Summary Исключить пользователя из таблиц лидеров
+// @Description Исключает пользователя из таблиц лидеров, соседей в /users/me/rank и итогов сезонов, например за накрутку. Баллы и баланс сохраняются.
+// @Tags Admin
+// @Produce json
+// @Param userID path string true "ID пользователя"
+// @Success 200 {object} api.MessageResponse "Успешно"
+// @Failure 403 {object} api.ErrorResponse "Недостаточно прав"
+// @Failure 400 {object} api.ErrorResponse "Ошибка клиента"
+// @Failure 404 {object} api.ErrorResponse "Пользователь не найден"
+// @Failure 500 {object} api.ErrorResponse "Ошибка на сервере"
+// @Router /admin/users/{userID}/leaderboard/exclude [post]
+// @security BearerAuth
+func (h *Handler) ExcludeFromLeaderboard(w http.ResponseWriter, r *http.Request) {
+	h.setLeaderboardExcluded(w, r, "http_handlers.ExcludeFromLeaderboard", true)
+}
+
+// IncludeInLeaderboard godoc
+// @Summary Вернуть пользователя в таблицы лидеров
+// @Description Снимает исключение пользователя из таблиц лидеров и итогов сезонов
+// @Tags Admin
+// @Produce json
+// @Param userID path string true "ID пользователя"
+// @Success 200 {object} api.MessageResponse "Успешно"
+// @Failure 403 {object} api.ErrorResponse "Недостаточно прав"
+// @Failure 400 {object} api.ErrorResponse "Ошибка клиента"
+// @Failure 404 {object} api.ErrorResponse "Пользователь не найден"
+// @Failure 500 {object} api.ErrorResponse "Ошибка на сервере"
+// @Router /admin/users/{userID}/leaderboard/include [post]
+// @security BearerAuth
+func (h *Handler) IncludeInLeaderboard(w http.ResponseWriter, r *http.Request) {
+	h.setLeaderboardExcluded(w, r, "http_handlers.IncludeInLeaderboard", false)
+}
+
+// setLeaderboardExcluded исключает пользователя из параметра пути userID из таблиц лидеров или возвращает его.
+func (h *Handler) setLeaderboardExcluded(w http.ResponseWriter, r *http.Request, op string, excluded bool) {
+	userID, err := parseIDParam(r, "userID")
+	if err != nil {
+		Responder(w, http.StatusBadRequest, api.ErrorResponse{Status: false, Message: ErrInvalidID.Error()})
+		return
+	}
+
+	if err = h.adminService.SetLeaderboardExcluded(r.Context(), userID, excluded); err != nil {
+		if errors.Is(err, services.ErrUserNotFound) {
+			Responder(w, http.StatusNotFound, api.ErrorResponse{Status: false, Message: ErrUserNotFound.Error()})
+			return
+		}
+		log.Printf("%s: %v", op, err)
+		Responder(w, http.StatusInternalServerError, api.ErrorResponse{Status: false, Message: ErrInternalServer.Error()})
+		return
+	}
+
+	message := "Пользователь возвращен в таблицы лидеров"
+	if excluded {
+		message = "Пользователь исключен из таблиц лидеров"
+	}
+	Responder(w, http.StatusOK, api.MessageResponse{Status: true, Message: message})
+}
+
+// setUserBanned блокирует или разблокирует пользователя из параметра пути userID.
+func (h *Handler) setUserBanned(w http.ResponseWriter, r *http.Request, op string, banned bool) {
+	userID, err := parseIDParam(r, "userID")
+	if err != nil {
+		Responder(w, http.StatusBadRequest, api.ErrorResponse{Status: false, Message: ErrInvalidID.Error()})
+		return
+	}
+
+	if banned && userID == userFromContext(r.Context()).ID {
+		Responder(w, http.StatusBadRequest, api.ErrorResponse{Status: false, Message: ErrCannotBanSelf.Error()})
+		return
+	}
+
+	if err = h.adminService.SetUserBanned(r.Context(), userID, banned); err != nil {
+		if errors.Is(err, services.ErrUserNotFound) {
+			Responder(w, http.StatusNotFound, api.ErrorResponse{Status: false, Message: ErrUserNotFound.Error()})
+			return
+		}
+		log.Printf("%s: %v", op, err)
+		Responder(w, http.StatusInternalServerError, api.ErrorResponse{Status: false, Message: ErrInternalServer.Error()})
+		return
+	}
+
+	message := "Пользователь разблокирован"
+	if banned {
+		message = "Пользователь заблокирован"
+	}
+	Responder(w, http.StatusOK, api.MessageResponse{Status: true, Message: message})
+}
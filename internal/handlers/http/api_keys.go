@@ -0,0 +1,134 @@
+package http_handlers
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"github.com/RVodassa/TaskReward/internal/api"
+	"github.com/RVodassa/TaskReward/internal/services"
+	"log"
+	"net/http"
+)
+
+var (
+	ErrInvalidAPIKey     = errors.New("ошибка: API ключ недействителен")
+	ErrAPIKeyScope       = errors.New("ошибка: у API ключа нет прав на этот запрос")
+	ErrInvalidAPIKeyData = errors.New("ошибка: укажите название (до 100 символов), права (tasks:read, tasks:write, tasks:complete, users:read, balance:write) и срок действия в будущем")
+	ErrAPIKeyNotFound    = errors.New("ошибка: API ключ не найден")
+)
+
+// CreateAPIKey godoc
+// @Summary Создать API ключ
+// @Description Создает API ключ для вызова API другими сервисами. Ключ действует от имени администратора и только на маршрутах, разрешенных правами scopes. Полный ключ возвращается один раз, передается в заголовке X-API-Key.
+// @Tags Admin
+// @Accept json
+// @Produce json
+// @Param request body api.CreateAPIKeyRequest true "Название, права и срок действия (необязательно)"
+// @Success 201 {object} api.APIKeyResponse "Ключ создан"
+// @Failure 403 {object} api.ErrorResponse "Недостаточно прав"
+// @Failure 400 {object} api.ErrorResponse "Ошибка клиента"
+// @Failure 500 {object} api.ErrorResponse "Ошибка на сервере"
+// @Router /admin/api-keys [post]
+// @security BearerAuth
+func (h *Handler) CreateAPIKey(w http.ResponseWriter, r *http.Request) {
+	const op = "http_handlers.CreateAPIKey"
+
+	var request api.CreateAPIKeyRequest
+	if err := json.NewDecoder(r.Body).Decode(&request); err != nil {
+		Responder(w, http.StatusBadRequest, api.ErrorResponse{Status: false, Message: ErrInvalidJSON.Error()})
+		return
+	}
+
+	key, err := h.adminService.CreateAPIKey(r.Context(), userFromContext(r.Context()).ID, request.Name, request.Scopes, request.ExpiresAt)
+	if err != nil {
+		if errors.Is(err, services.ErrInvalidAPIKeyData) {
+			Responder(w, http.StatusBadRequest, api.ErrorResponse{Status: false, Message: ErrInvalidAPIKeyData.Error()})
+			return
+		}
+		log.Printf("%s: %v", op, err)
+		Responder(w, http.StatusInternalServerError, api.ErrorResponse{Status: false, Message: ErrInternalServer.Error()})
+		return
+	}
+
+	Responder(w, http.StatusCreated, api.APIKeyResponse{
+		Status:  true,
+		Message: "API ключ создан, сохраните его: повторно ключ не показывается",
+		APIKey:  key,
+	})
+}
+
+// GetAPIKeys godoc
+// @Summary Список API ключей
+// @Description Возвращает API ключи с правами, сроком действия и временем последнего использования, без секретов
+// @Tags Admin
+// @Produce json
+// @Param limit query int false "Кол-во записей (по умолчанию 50, максимум 100)"
+// @Param offset query int false "Смещение"
+// @Success 200 {object} api.APIKeysResponse "Успешно"
+// @Failure 403 {object} api.ErrorResponse "Недостаточно прав"
+// @Failure 400 {object} api.ErrorResponse "Ошибка клиента"
+// @Failure 500 {object} api.ErrorResponse "Ошибка на сервере"
+// @Router /admin/api-keys [get]
+// @security BearerAuth
+func (h *Handler) GetAPIKeys(w http.ResponseWriter, r *http.Request) {
+	const op = "http_handlers.GetAPIKeys"
+
+	limit, offset, err := parsePagination(r)
+	if err != nil {
+		Responder(w, http.StatusBadRequest, api.ErrorResponse{Status: false, Message: ErrInvalidPagination.Error()})
+		return
+	}
+
+	keys, err := h.adminService.GetAPIKeys(r.Context(), limit, offset)
+	if err != nil {
+		log.Printf("%s: %v", op, err)
+		Responder(w, http.StatusInternalServerError, api.ErrorResponse{Status: false, Message: ErrInternalServer.Error()})
+		return
+	}
+
+	Responder(w, http.StatusOK, api.APIKeysResponse{
+		Status:  true,
+		Message: fmt.Sprintf("API ключи. Кол-во: %d", len(keys)),
+		APIKeys: keys,
+	})
+}
+
+// RevokeAPIKey godoc
+// @Summary Отозвать API ключ
+// @Description Отзывает API ключ, запросы с ним сразу перестают приниматься
+// @Tags Admin
+// @Produce json
+// @Param keyID path string true "ID API ключа"
+// @Success 200 {object} api.APIKeyResponse "Успешно"
+// @Failure 403 {object} api.ErrorResponse "Недостаточно прав"
+// @Failure 400 {object} api.ErrorResponse "Ошибка клиента"
+// @Failure 404 {object} api.ErrorResponse "Ключ не найден"
+// @Failure 500 {object} api.ErrorResponse "Ошибка на сервере"
+// @Router /admin/api-keys/{keyID}/revoke [post]
+// @security BearerAuth
+func (h *Handler) RevokeAPIKey(w http.ResponseWriter, r *http.Request) {
+	const op = "http_handlers.RevokeAPIKey"
+
+	keyID, err := parseIDParam(r, "keyID")
+	if err != nil {
+		Responder(w, http.StatusBadRequest, api.ErrorResponse{Status: false, Message: ErrInvalidID.Error()})
+		return
+	}
+
+	key, err := h.adminService.RevokeAPIKey(r.Context(), keyID)
+	if err != nil {
+		if errors.Is(err, services.ErrAPIKeyNotFound) {
+			Responder(w, http.StatusNotFound, api.ErrorResponse{Status: false, Message: ErrAPIKeyNotFound.Error()})
+			return
+		}
+		log.Printf("%s: %v", op, err)
+		Responder(w, http.StatusInternalServerError, api.ErrorResponse{Status: false, Message: ErrInternalServer.Error()})
+		return
+	}
+
+	Responder(w, http.StatusOK, api.APIKeyResponse{
+		Status:  true,
+		Message: "API ключ отозван",
+		APIKey:  key,
+	})
+}
@@ -0,0 +1,141 @@
+package http_handlers
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"github.com/RVodassa/TaskReward/internal/api"
+	"github.com/RVodassa/TaskReward/internal/services"
+	"log"
+	"net/http"
+)
+
+var (
+	ErrInvalidAmount     = errors.New("ошибка: сумма не должна быть равна 0, комментарий - не длиннее 255 символов")
+	ErrInsufficientFunds = errors.New("ошибка: недостаточно средств на балансе")
+)
+
+// GetMyTransactions godoc
+// @Summary История операций текущего пользователя
+// @Description Возвращает историю изменений баланса текущего пользователя, новые операции первыми
+// @Tags Balance
+// @Produce json
+// @Param limit query int false "Кол-во записей (по умолчанию 50, максимум 100)"
+// @Param offset query int false "Смещение"
+// @Success 200 {object} api.TransactionsResponse "Успешно"
+// @Failure 403 {object} api.ErrorResponse "Unauthorized"
+// @Failure 400 {object} api.ErrorResponse "Ошибка клиента"
+// @Failure 500 {object} api.ErrorResponse "Ошибка на сервере"
+// @Router /users/me/transactions [get]
+// @security BearerAuth
+func (h *Handler) GetMyTransactions(w http.ResponseWriter, r *http.Request) {
+	h.respondTransactions(w, r, userFromContext(r.Context()).ID)
+}
+
+// GetUserTransactions godoc
+// @Summary История операций пользователя
+// @Description Возвращает историю изменений баланса пользователя, новые операции первыми. Пользователь может запросить только свою историю, администратор - любую.
+// @Tags Balance
+// @Produce json
+// @Param userID path string true "ID пользователя"
+// @Param limit query int false "Кол-во записей (по умолчанию 50, максимум 100)"
+// @Param offset query int false "Смещение"
+// @Success 200 {object} api.TransactionsResponse "Успешно"
+// @Failure 403 {object} api.ErrorResponse "Unauthorized"
+// @Failure 400 {object} api.ErrorResponse "Ошибка клиента"
+// @Failure 500 {object} api.ErrorResponse "Ошибка на сервере"
+// @Router /users/{userID}/transactions [get]
+// @security BearerAuth
+// @security ApiKeyAuth
+func (h *Handler) GetUserTransactions(w http.ResponseWriter, r *http.Request) {
+	const op = "http_handlers.GetUserTransactions"
+
+	userID, ok := authorizedUserID(w, r, op)
+	if !ok {
+		return
+	}
+
+	h.respondTransactions(w, r, userID)
+}
+
+// respondTransactions отправляет клиенту страницу истории операций пользователя userID.
+func (h *Handler) respondTransactions(w http.ResponseWriter, r *http.Request, userID uint) {
+	const op = "http_handlers.respondTransactions"
+
+	limit, offset, err := parsePagination(r)
+	if err != nil {
+		Responder(w, http.StatusBadRequest, api.ErrorResponse{Status: false, Message: ErrInvalidPagination.Error()})
+		return
+	}
+
+	transactions, total, err := h.userService.GetBalanceTransactions(r.Context(), userID, limit, offset)
+	if err != nil {
+		log.Printf("%s: %v", op, err)
+		Responder(w, http.StatusInternalServerError, api.ErrorResponse{Status: false, Message: ErrInternalServer.Error()})
+		return
+	}
+
+	Responder(w, http.StatusOK, api.TransactionsResponse{
+		Status:       true,
+		Message:      fmt.Sprintf("История операций. Всего операций: %d", total),
+		Total:        total,
+		Transactions: transactions,
+	})
+}
+
+// AdjustBalance godoc
+// @Summary Корректировка баланса пользователя
+// @Description Начисляет (amount > 0) или списывает (amount < 0) баллы пользователя, операция записывается в историю
+// @Tags Admin
+// @Accept json
+// @Produce json
+// @Param userID path string true "ID пользователя"
+// @Param request body api.AdjustBalanceRequest true "Сумма и комментарий"
+// @Success 200 {object} api.TransactionResponse "Успешно"
+// @Failure 403 {object} api.ErrorResponse "Недостаточно прав"
+// @Failure 400 {object} api.ErrorResponse "Ошибка клиента"
+// @Failure 404 {object} api.ErrorResponse "Пользователь не найден"
+// @Failure 409 {object} api.ErrorResponse "Недостаточно средств"
+// @Failure 500 {object} api.ErrorResponse "Ошибка на сервере"
+// @Router /admin/users/{userID}/balance [post]
+// @security BearerAuth
+// @security ApiKeyAuth
+func (h *Handler) AdjustBalance(w http.ResponseWriter, r *http.Request) {
+	const op = "http_handlers.AdjustBalance"
+
+	userID, err := parseIDParam(r, "userID")
+	if err != nil {
+		Responder(w, http.StatusBadRequest, api.ErrorResponse{Status: false, Message: ErrInvalidID.Error()})
+		return
+	}
+
+	var request api.AdjustBalanceRequest
+	if err = json.NewDecoder(r.Body).Decode(&request); err != nil {
+		log.Printf("%s: ошибка при декодировании запроса: %v", op, err)
+		Responder(w, http.StatusBadRequest, api.ErrorResponse{Status: false, Message: ErrInvalidJSON.Error()})
+		return
+	}
+
+	admin := userFromContext(r.Context())
+	txn, err := h.adminService.AdjustBalance(r.Context(), userID, request.Amount, admin.ID, request.Comment)
+	if err != nil {
+		switch {
+		case errors.Is(err, services.ErrInvalidAmount):
+			Responder(w, http.StatusBadRequest, api.ErrorResponse{Status: false, Message: ErrInvalidAmount.Error()})
+		case errors.Is(err, services.ErrUserNotFound):
+			Responder(w, http.StatusNotFound, api.ErrorResponse{Status: false, Message: ErrUserNotFound.Error()})
+		case errors.Is(err, services.ErrInsufficientFunds):
+			Responder(w, http.StatusConflict, api.ErrorResponse{Status: false, Message: ErrInsufficientFunds.Error()})
+		default:
+			log.Printf("%s: %v", op, err)
+			Responder(w, http.StatusInternalServerError, api.ErrorResponse{Status: false, Message: ErrInternalServer.Error()})
+		}
+		return
+	}
+
+	Responder(w, http.StatusOK, api.TransactionResponse{
+		Status:      true,
+		Message:     "Баланс пользователя изменен",
+		Transaction: txn,
+	})
+}
@@ -0,0 +1,85 @@
+package http_handlers
+
+import (
+	"encoding/json"
+	"errors"
+	"github.com/RVodassa/TaskReward/internal/api"
+	"github.com/RVodassa/TaskReward/internal/services"
+	"log"
+	"net/http"
+)
+
+var (
+	ErrInvalidVerifyToken   = errors.New("ошибка: токен подтверждения email недействителен или истек, запросите новое письмо")
+	ErrEmailNotSet          = errors.New("ошибка: email не указан, задайте его в /users/me/email")
+	ErrEmailAlreadyVerified = errors.New("ошибка: email уже подтвержден")
+	ErrEmailNotVerified     = errors.New("ошибка: подтвердите email, чтобы выполнять задачи")
+)
+
+// VerifyEmail godoc
+// @Summary Подтвердить email
+// @Description Подтверждает email пользователя по токену из письма.
+// @Tags auth
+// @Accept json
+// @Produce json
+// @Param request body api.VerifyEmailRequest true "Токен из письма"
+// @Success 200 {object} api.StatusUserResponse "Email подтвержден"
+// @Failure 400 {object} api.ErrorResponse "Ошибка клиента"
+// @Failure 500 {object} api.ErrorResponse "Ошибка на сервере"
+// @Router /auth/email/verify [post]
+func (h *Handler) VerifyEmail(w http.ResponseWriter, r *http.Request) {
+	const op = "http_handlers.VerifyEmail"
+
+	var request api.VerifyEmailRequest
+	if err := json.NewDecoder(r.Body).Decode(&request); err != nil {
+		Responder(w, http.StatusBadRequest, api.ErrorResponse{Status: false, Message: ErrInvalidJSON.Error()})
+		return
+	}
+
+	user, err := h.userService.VerifyEmail(r.Context(), request.Token)
+	if err != nil {
+		if errors.Is(err, services.ErrInvalidVerifyToken) {
+			Responder(w, http.StatusBadRequest, api.ErrorResponse{Status: false, Message: ErrInvalidVerifyToken.Error()})
+			return
+		}
+		log.Printf("%s: %v", op, err)
+		Responder(w, http.StatusInternalServerError, api.ErrorResponse{Status: false, Message: ErrInternalServer.Error()})
+		return
+	}
+
+	Responder(w, http.StatusOK, api.StatusUserResponse{Status: true, Message: "Email подтвержден", User: user})
+}
+
+// ResendEmailVerification godoc
+// @Summary Повторно отправить подтверждение email
+// @Description Отправляет новое письмо для подтверждения email текущего пользователя, ранее отправленные токены перестают действовать.
+// @Tags Users
+// @Produce json
+// @Success 202 {object} api.MessageResponse "Письмо отправлено"
+// @Failure 403 {object} api.ErrorResponse "Unauthorized"
+// @Failure 400 {object} api.ErrorResponse "Email не указан"
+// @Failure 409 {object} api.ErrorResponse "Email уже подтвержден"
+// @Failure 500 {object} api.ErrorResponse "Ошибка на сервере"
+// @Router /users/me/email/verification [post]
+// @security BearerAuth
+func (h *Handler) ResendEmailVerification(w http.ResponseWriter, r *http.Request) {
+	const op = "http_handlers.ResendEmailVerification"
+
+	err := h.userService.ResendEmailVerification(r.Context(), userFromContext(r.Context()).ID)
+	if err != nil {
+		switch {
+		case errors.Is(err, services.ErrEmailNotSet):
+			Responder(w, http.StatusBadRequest, api.ErrorResponse{Status: false, Message: ErrEmailNotSet.Error()})
+		case errors.Is(err, services.ErrEmailAlreadyVerified):
+			Responder(w, http.StatusConflict, api.ErrorResponse{Status: false, Message: ErrEmailAlreadyVerified.Error()})
+		case errors.Is(err, services.ErrUserNotFound):
+			Responder(w, http.StatusNotFound, api.ErrorResponse{Status: false, Message: ErrUserNotFound.Error()})
+		default:
+			log.Printf("%s: %v", op, err)
+			Responder(w, http.StatusInternalServerError, api.ErrorResponse{Status: false, Message: ErrInternalServer.Error()})
+		}
+		return
+	}
+
+	Responder(w, http.StatusAccepted, api.MessageResponse{Status: true, Message: "Письмо для подтверждения email отправлено"})
+}
@@ -12,8 +12,12 @@ import (
 	"github.com/RVodassa/TaskReward/internal/services/auth"
 	"github.com/go-chi/chi/v5"
 	"log"
+	"net"
 	"net/http"
+	"net/netip"
 	"strconv"
+	"strings"
+	"time"
 )
 
 var (
@@ -22,35 +26,93 @@ var (
 	ErrUserAlreadyExist     = errors.New("ошибка: пользователь с таким логином уже существует")
 	ErrUserNotFound         = errors.New("ошибка: пользователь найден")
 	ErrInternalServer       = errors.New("ошибка: внутренняя ошибка сервера, обратитесь к администратору")
-	ErrInvalidReferID       = errors.New("ошибка: некорректный refer_id")
 	ErrInvalidID            = errors.New("ошибка: некорректный user_id")
 	ErrInvalidTaskID        = errors.New("ошибка: некорректный task_id")
 	ErrReferUserNotFound    = errors.New("ошибка: refer с указанным id не найден")
 	ErrIncorrectPassword    = errors.New("ошибка: не правильный логин или пароль")
 	ErrTaskNotFound         = errors.New("ошибка: задача не найдена")
 	ErrTaskAlreadyCompleted = errors.New("ошибка: задача уже выполнена")
+	ErrTaskCompletionLimit  = errors.New("ошибка: достигнут лимит выполнений задачи")
+	ErrTaskArchived         = errors.New("ошибка: задача в архиве")
+	ErrUnauthorized         = errors.New("ошибка: пользователь не авторизован")
+	ErrForbidden            = errors.New("ошибка: недостаточно прав")
+	ErrInvalidRole          = errors.New("ошибка: неизвестная роль, допустимо: user, moderator, admin")
+	ErrInvalidRefreshToken  = errors.New("ошибка: refresh токен недействителен или истек, выполните вход")
+	ErrRefreshTokenReused   = errors.New("ошибка: refresh токен уже использован, все сессии этого входа отозваны")
+	ErrUserBanned           = errors.New("ошибка: пользователь заблокирован")
+	ErrLoginLocked          = errors.New("ошибка: слишком много неудачных попыток входа, повторите позже")
+	ErrInvalidPeriod        = errors.New("ошибка: неизвестный период, допустимо: day, week, month, all")
+	ErrInvalidNeighbors     = errors.New("ошибка: некорректный параметр neighbors, допустимо от 0 до 10")
+)
+
+const (
+	defaultPageLimit = 50
+	maxPageLimit     = 100
+	// defaultRankNeighbors, maxRankNeighbors кол-во соседей выше и ниже пользователя в /users/me/rank
+	defaultRankNeighbors = 2
+	maxRankNeighbors     = 10
 )
 
 type UserServiceProvider interface {
-	RegisterUser(ctx context.Context, login string, password string, referID uint) (*models.User, error)
-	Login(ctx context.Context, login, password string) error
+	RegisterUser(ctx context.Context, login, password, email string, referID uint, referralCode string) (*models.User, error)
+	Login(ctx context.Context, login, password, ip string) (*models.User, error)
+	IssueRefreshToken(ctx context.Context, userID uint) (string, error)
+	RefreshTokens(ctx context.Context, refreshToken string) (*models.User, string, error)
+	Logout(ctx context.Context, userID uint, jti string, expiresAt time.Time, refreshToken string) error
+	LogoutAll(ctx context.Context, userID uint) error
+	AuthenticateAPIKey(ctx context.Context, rawKey string) (*models.APIKey, *models.User, error)
+	IsAccessTokenRevoked(ctx context.Context, jti string) (bool, error)
+	ChangePassword(ctx context.Context, userID uint, oldPassword, newPassword string) error
+	EnrollTOTP(ctx context.Context, userID uint) (*models.TOTPEnrollment, error)
+	ConfirmTOTP(ctx context.Context, userID uint, code string) ([]string, error)
+	DisableTOTP(ctx context.Context, userID uint, password, code string) error
+	VerifyTwoFactor(ctx context.Context, userID uint, challengeID, code, ip string) (*models.User, error)
+	UpdateEmail(ctx context.Context, userID uint, email string) (*models.User, error)
+	RequestPasswordReset(ctx context.Context, email string) error
+	ResetPassword(ctx context.Context, token, newPassword string) error
+	VerifyEmail(ctx context.Context, token string) (*models.User, error)
+	ResendEmailVerification(ctx context.Context, userID uint) error
 	StatusUser(ctx context.Context, userID uint) (*models.User, error)
 	TaskComplete(ctx context.Context, taskID uint, userID uint) (*models.Task, error)
-	GetListTopUsers(ctx context.Context) ([]*models.User, error)
-	GetAllActiveTask(ctx context.Context) ([]*models.Task, error)
+	GetListTopUsers(ctx context.Context, period string) ([]*models.LeaderboardEntry, error)
+	UpdateProfile(ctx context.Context, userID uint, displayName, avatarURL, visibility string) (*models.User, error)
+	GetSeasons(ctx context.Context, limit, offset uint) ([]*models.Season, error)
+	GetSeasonStandings(ctx context.Context, seasonID uint, limit, offset uint) (*models.Season, []*models.SeasonStanding, error)
+	GetLeaderboardPosition(ctx context.Context, userID uint, period string, neighbors uint) (*models.LeaderboardPosition, error)
+	GetAllActiveTask(ctx context.Context, userID uint) ([]*models.Task, error)
+	GetBalanceTransactions(ctx context.Context, userID uint, limit, offset uint) ([]*models.BalanceTransaction, uint, error)
+	GetRewards(ctx context.Context, onlyActive bool, limit, offset uint) ([]*models.Reward, error)
+	RedeemReward(ctx context.Context, userID uint, rewardID uint) (*models.RewardOrder, error)
+	GetRewardOrders(ctx context.Context, userID uint, status string, limit, offset uint) ([]*models.RewardOrder, error)
+	CancelRewardOrder(ctx context.Context, orderID uint, userID uint) (*models.RewardOrder, error)
+	GetReferralTree(ctx context.Context, userID uint, depth uint, limit, offset uint) ([]*models.Referral, error)
+	GetReferralStats(ctx context.Context, userID uint) (*models.ReferralStats, error)
+	CreateReferralCode(ctx context.Context, userID uint, maxUses uint, expiresAt *time.Time) (*models.ReferralCode, error)
+	GetReferralCodes(ctx context.Context, userID uint) ([]*models.ReferralCode, error)
+	DeactivateReferralCode(ctx context.Context, codeID uint, userID uint) (*models.ReferralCode, error)
 }
 
 type Handler struct {
-	userService UserServiceProvider
+	userService    UserServiceProvider
+	adminService   AdminServiceProvider
+	tokens         *auth.Manager
+	trustedProxies []netip.Prefix
 }
 
-func NewHandler(userService UserServiceProvider) *Handler {
-	return &Handler{userService: userService}
+// NewHandler создает обработчики. trustedProxies - адреса обратных прокси, которым доверяются
+// заголовки X-Forwarded-For и X-Real-IP.
+func NewHandler(userService UserServiceProvider, adminService AdminServiceProvider, tokens *auth.Manager, trustedProxies []netip.Prefix) *Handler {
+	return &Handler{
+		userService:    userService,
+		adminService:   adminService,
+		tokens:         tokens,
+		trustedProxies: trustedProxies,
+	}
 }
 
 // GetAllActiveTask godoc
 // @Summary Получить список активных задач
-// @Description возвращает список активных задач
+// @Description возвращает список активных задач с отметкой о выполнении текущим пользователем
 // @Tags Tasks
 // @Produce json
 // @Success 200 {object} api.GetAllTasksResponse "Успешно"
@@ -62,7 +124,9 @@ func NewHandler(userService UserServiceProvider) *Handler {
 func (h *Handler) GetAllActiveTask(w http.ResponseWriter, r *http.Request) {
 	const op = "http_handlers.GetAllActiveTask"
 
-	listTask, err := h.userService.GetAllActiveTask(r.Context())
+	caller := userFromContext(r.Context())
+
+	listTask, err := h.userService.GetAllActiveTask(r.Context(), caller.ID)
 	if err != nil {
 		log.Printf("%s %v", op, err)
 		Responder(w, http.StatusInternalServerError, api.ErrorResponse{Status: false, Message: ErrInternalServer.Error()})
@@ -89,9 +153,10 @@ func (h *Handler) GetAllActiveTask(w http.ResponseWriter, r *http.Request) {
 
 // LeaderBoard godoc
 // @Summary Получить список лидеров
-// @Description Возвращает топ 10 лидеров по балансу
+// @Description Возвращает лидеров (LEADERBOARD_LIMIT, по умолчанию 10) по сумме бонусов за задачи, выполненные за период: day, week, month - с начала текущего дня, недели или месяца в часовом поясе LEADERBOARD_TIMEZONE, all - за все время. Пользователи с одинаковой суммой делят место. При включенном REQUIRE_VERIFIED в списке только пользователи с подтвержденным email.
 // @Tags Users
 // @Produce json
+// @Param period query string false "Период: day, week, month, all (по умолчанию all)"
 // @Success 200 {object} api.LeaderBoardResponse "Успешно"
 // @Failure 403 {object} api.ErrorResponse "Unauthorized"
 // @Failure 400 {object} api.ErrorResponse "Ошибка клиента"
@@ -101,56 +166,143 @@ func (h *Handler) GetAllActiveTask(w http.ResponseWriter, r *http.Request) {
 func (h *Handler) LeaderBoard(w http.ResponseWriter, r *http.Request) {
 	const op = "http_handlers.LeaderBoard"
 
-	users, err := h.userService.GetListTopUsers(r.Context())
+	period := r.URL.Query().Get("period")
+	if period == "" {
+		period = models.PeriodAll
+	}
+
+	entries, err := h.userService.GetListTopUsers(r.Context(), period)
 	if err != nil {
+		if errors.Is(err, services.ErrInvalidPeriod) {
+			Responder(w, http.StatusBadRequest, api.ErrorResponse{Status: false, Message: ErrInvalidPeriod.Error()})
+			return
+		}
 		log.Printf("%s %v", op, err)
 		Responder(w, http.StatusInternalServerError, api.ErrorResponse{Status: false, Message: ErrInternalServer.Error()})
 		return
 	}
 
-	if len(users) == 0 {
+	if len(entries) == 0 {
 		Responder(w, http.StatusOK, api.LeaderBoardResponse{
 			Status:     true,
 			Message:    "Пользователи не найдены",
-			ListLeader: []*models.User{},
+			Period:     period,
+			ListLeader: []*models.LeaderboardEntry{},
 		})
 		return
 	}
 
 	resp := api.LeaderBoardResponse{
 		Status:     true,
-		Message:    fmt.Sprintf("Доска лидеров. Кол.во: %d", len(users)),
-		ListLeader: users,
+		Message:    fmt.Sprintf("Доска лидеров. Кол.во: %d", len(entries)),
+		Period:     period,
+		ListLeader: entries,
 	}
 
 	Responder(w, http.StatusOK, resp)
 }
 
+// MyRank godoc
+// @Summary Мое место в таблице лидеров
+// @Description Возвращает место текущего пользователя в таблице лидеров за период и соседей выше и ниже него. Пользователи с одинаковой суммой бонусов делят место. Если у пользователя нет бонусов за период, user = null.
+// @Tags Users
+// @Produce json
+// @Param period query string false "Период: day, week, month, all (по умолчанию all)"
+// @Param neighbors query int false "Кол-во соседей выше и ниже (по умолчанию 2, максимум 10)"
+// @Success 200 {object} api.LeaderboardPositionResponse "Успешно"
+// @Failure 403 {object} api.ErrorResponse "Unauthorized"
+// @Failure 400 {object} api.ErrorResponse "Ошибка клиента"
+// @Failure 500 {object} api.ErrorResponse "Ошибка на сервере"
+// @Router /users/me/rank [get]
+// @security BearerAuth
+func (h *Handler) MyRank(w http.ResponseWriter, r *http.Request) {
+	const op = "http_handlers.MyRank"
+
+	period := r.URL.Query().Get("period")
+	if period == "" {
+		period = models.PeriodAll
+	}
+
+	neighbors := uint64(defaultRankNeighbors)
+	if value := r.URL.Query().Get("neighbors"); value != "" {
+		var err error
+		neighbors, err = strconv.ParseUint(value, 10, 64)
+		if err != nil || neighbors > maxRankNeighbors {
+			Responder(w, http.StatusBadRequest, api.ErrorResponse{Status: false, Message: ErrInvalidNeighbors.Error()})
+			return
+		}
+	}
+
+	position, err := h.userService.GetLeaderboardPosition(r.Context(), userFromContext(r.Context()).ID, period, uint(neighbors))
+	if err != nil {
+		if errors.Is(err, services.ErrInvalidPeriod) {
+			Responder(w, http.StatusBadRequest, api.ErrorResponse{Status: false, Message: ErrInvalidPeriod.Error()})
+			return
+		}
+		log.Printf("%s: %v", op, err)
+		Responder(w, http.StatusInternalServerError, api.ErrorResponse{Status: false, Message: ErrInternalServer.Error()})
+		return
+	}
+
+	message := "У вас пока нет бонусов за этот период"
+	if position.User != nil {
+		message = fmt.Sprintf("Ваше место в таблице лидеров: %d", position.User.Rank)
+	}
+
+	Responder(w, http.StatusOK, api.LeaderboardPositionResponse{
+		Status:   true,
+		Message:  message,
+		Period:   period,
+		Position: position,
+	})
+}
+
 // TaskComplete godoc
 // @Summary Выполнить задачу
-// @Description Возвращает информацию о выполненной задаче
+// @Description Возвращает информацию о выполненной задаче. Пользователь может выполнять задачи только от своего имени, администратор - от имени любого пользователя.
 // @Tags Tasks
 // Accept json
 // @Produce json
 // @Param userID path string true "ID пользователя"
 // @Param taskID path string true "ID задачи"
 // @Success 200 {object} api.TaskCompletedResponse "Успешно"
-// @Failure 403 {object} api.ErrorResponse "Unauthorized"
+// @Failure 403 {object} api.ErrorResponse "Unauthorized или email не подтвержден (REQUIRE_VERIFIED)"
 // @Failure 400 {object} api.ErrorResponse "Ошибка клиента"
 // @Failure 500 {object} api.ErrorResponse "Ошибка на сервере"
 // @Router /users/{userID}/tasks/{taskID}/complete [post]
 // @security BearerAuth
+// @security ApiKeyAuth
 func (h *Handler) TaskComplete(w http.ResponseWriter, r *http.Request) {
 	const op = "http_handlers.TaskComplete"
 
-	userIDStr := chi.URLParam(r, "userID")
-	userID, err := strconv.ParseUint(userIDStr, 10, 64)
-	if err != nil || userID <= 0 {
-		log.Printf("%s %s %v", op, r.URL, err)
-		Responder(w, http.StatusBadRequest, api.ErrorResponse{Status: false, Message: ErrInvalidID.Error()})
+	userID, ok := authorizedUserID(w, r, op)
+	if !ok {
 		return
 	}
 
+	h.completeTask(w, r, userID)
+}
+
+// TaskCompleteMe godoc
+// @Summary Выполнить задачу текущим пользователем
+// @Description Возвращает информацию о выполненной задаче
+// @Tags Tasks
+// @Produce json
+// @Param taskID path string true "ID задачи"
+// @Success 200 {object} api.TaskCompletedResponse "Успешно"
+// @Failure 403 {object} api.ErrorResponse "Unauthorized или email не подтвержден (REQUIRE_VERIFIED)"
+// @Failure 400 {object} api.ErrorResponse "Ошибка клиента"
+// @Failure 500 {object} api.ErrorResponse "Ошибка на сервере"
+// @Router /users/me/tasks/{taskID}/complete [post]
+// @security BearerAuth
+func (h *Handler) TaskCompleteMe(w http.ResponseWriter, r *http.Request) {
+	h.completeTask(w, r, userFromContext(r.Context()).ID)
+}
+
+// completeTask выполняет задачу из параметра пути taskID от имени пользователя userID.
+func (h *Handler) completeTask(w http.ResponseWriter, r *http.Request, userID uint) {
+	const op = "http_handlers.completeTask"
+
 	taskIdStr := chi.URLParam(r, "taskID")
 	taskID, err := strconv.ParseUint(taskIdStr, 10, 64)
 	if err != nil || taskID <= 0 {
@@ -159,7 +311,7 @@ func (h *Handler) TaskComplete(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	task, err := h.userService.TaskComplete(r.Context(), uint(taskID), uint(userID))
+	task, err := h.userService.TaskComplete(r.Context(), uint(taskID), userID)
 	if err != nil {
 		switch {
 		case errors.Is(err, services.ErrUserNotFound):
@@ -171,6 +323,15 @@ func (h *Handler) TaskComplete(w http.ResponseWriter, r *http.Request) {
 		case errors.Is(err, services.ErrTaskAlreadyCompleted):
 			Responder(w, http.StatusConflict, api.ErrorResponse{Status: false, Message: ErrTaskAlreadyCompleted.Error()})
 			return
+		case errors.Is(err, services.ErrTaskCompletionLimit):
+			Responder(w, http.StatusConflict, api.ErrorResponse{Status: false, Message: ErrTaskCompletionLimit.Error()})
+			return
+		case errors.Is(err, services.ErrTaskArchived):
+			Responder(w, http.StatusConflict, api.ErrorResponse{Status: false, Message: ErrTaskArchived.Error()})
+			return
+		case errors.Is(err, services.ErrEmailNotVerified):
+			Responder(w, http.StatusForbidden, api.ErrorResponse{Status: false, Message: ErrEmailNotVerified.Error()})
+			return
 		default:
 			log.Printf("%s %s %v", op, r.URL, err)
 			Responder(w, http.StatusInternalServerError, api.ErrorResponse{Status: false, Message: ErrInternalServer.Error()})
@@ -189,7 +350,7 @@ func (h *Handler) TaskComplete(w http.ResponseWriter, r *http.Request) {
 
 // StatusUser godoc
 // @Summary Получить информацию о пользователе по ID
-// @Description Возвращает информацию о пользователе в случае успешной операции
+// @Description Возвращает информацию о пользователе в случае успешной операции. Пользователь может запросить только свой статус, администратор - любой.
 // @Tags Users
 // Accept json
 // @Produce json
@@ -200,18 +361,16 @@ func (h *Handler) TaskComplete(w http.ResponseWriter, r *http.Request) {
 // @Failure 500 {object} api.ErrorResponse "Ошибка на сервере"
 // @Router /users/{userID}/status [get]
 // @security BearerAuth
+// @security ApiKeyAuth
 func (h *Handler) StatusUser(w http.ResponseWriter, r *http.Request) {
 	const op = "http_handlers.StatusUser"
 
-	idStr := chi.URLParam(r, "userID")
-	id, err := strconv.ParseUint(idStr, 10, 64)
-	if err != nil || id <= 0 {
-		log.Printf("%s %s %v", op, r.URL, err)
-		Responder(w, http.StatusBadRequest, api.ErrorResponse{Status: false, Message: ErrInvalidID.Error()})
+	id, ok := authorizedUserID(w, r, op)
+	if !ok {
 		return
 	}
 
-	user, err := h.userService.StatusUser(r.Context(), uint(id))
+	user, err := h.userService.StatusUser(r.Context(), id)
 	if err != nil {
 		if errors.Is(err, services.ErrUserNotFound) {
 			Responder(w, http.StatusNotFound, api.ErrorResponse{Status: false, Message: ErrUserNotFound.Error()})
@@ -231,23 +390,43 @@ func (h *Handler) StatusUser(w http.ResponseWriter, r *http.Request) {
 	Responder(w, http.StatusOK, resp)
 }
 
+// StatusMe godoc
+// @Summary Получить информацию о текущем пользователе
+// @Description Возвращает информацию о пользователе, которому выдан токен
+// @Tags Users
+// @Produce json
+// @Success 200 {object} api.StatusUserResponse "Успешно"
+// @Failure 403 {object} api.ErrorResponse "Unauthorized"
+// @Failure 500 {object} api.ErrorResponse "Ошибка на сервере"
+// @Router /users/me/status [get]
+// @security BearerAuth
+func (h *Handler) StatusMe(w http.ResponseWriter, r *http.Request) {
+	Responder(w, http.StatusOK, api.StatusUserResponse{
+		Status:  true,
+		Message: "OK",
+		User:    userFromContext(r.Context()),
+	})
+}
+
 // Register godoc
 // @Summary Регистрация пользователя
-// @Description Создает нового пользователя, возвращает информацию о новом пользователе.
+// @Description Создает нового пользователя, возвращает информацию о новом пользователе. Ошибки формата логина, пароля и email возвращаются списком Errors по полям.
 // @Tags auth
 // @Accept json
 // @Produce json
-// @Param referID query string true "ID реферала, если нет укажите 0"
-// @Param request body api.AuthRequest true "Логин и пароль"
+// @Param ref query string false "Код приглашения"
+// @Param referID query string false "ID пригласившего (устаревший способ) или код приглашения"
+// @Param request body api.RegisterRequest true "Логин, пароль и необязательный email"
 // @Success 200 {object} api.StatusUserResponse "Успешная регистрация"
 // @Failure 403 {object} api.ErrorResponse "Unauthorized"
-// @Failure 400 {object} api.ErrorResponse "Ошибка клиента"
+// @Failure 400 {object} api.ValidationErrorResponse "Ошибка клиента"
+// @Failure 409 {object} api.ErrorResponse "Логин или email уже заняты"
 // @Failure 500 {object} api.ErrorResponse "Ошибка на сервере"
 // @Router /auth/register [post]
 func (h *Handler) Register(w http.ResponseWriter, r *http.Request) {
 	const op = "http_handlers.Register"
 
-	var request api.AuthRequest
+	var request api.RegisterRequest
 
 	if err := json.NewDecoder(r.Body).Decode(&request); err != nil {
 		log.Printf("%s: ошибка при декодировании запроса: %v", op, err)
@@ -264,25 +443,36 @@ func (h *Handler) Register(w http.ResponseWriter, r *http.Request) {
 	var referID uint64
 	var err error
 
+	// Пригласивший задается кодом ?ref=, числовой ?referID= поддерживается для совместимости.
+	// В referID также принимается код приглашения.
+	referralCode := r.URL.Query().Get("ref")
 	referIdStr := r.URL.Query().Get("referID")
-	if referIdStr != "" {
+	if referralCode == "" && referIdStr != "" {
 		referID, err = strconv.ParseUint(referIdStr, 10, 64)
 		if err != nil {
-			Responder(w, http.StatusBadRequest, api.ErrorResponse{Status: false, Message: ErrInvalidReferID.Error()})
-			return
+			referID, referralCode = 0, referIdStr
 		}
 	}
 
 	// Передаем данные для регистрации в сервис
-	regUser, err := h.userService.RegisterUser(r.Context(), request.Login, request.Password, uint(referID))
+	regUser, err := h.userService.RegisterUser(r.Context(), request.Login, request.Password, request.Email, uint(referID), referralCode)
 	if err != nil {
+		if respondValidationError(w, err) {
+			return
+		}
 		switch {
+		case errors.Is(err, services.ErrReferralCodeInvalid):
+			Responder(w, http.StatusBadRequest, api.ErrorResponse{Status: false, Message: ErrReferralCodeInvalid.Error()})
+			return
 		case errors.Is(err, services.ErrReferUserNotFound):
 			Responder(w, http.StatusBadRequest, api.ErrorResponse{Status: false, Message: ErrReferUserNotFound.Error()})
 			return
 		case errors.Is(err, services.ErrUserAlreadyExist):
 			Responder(w, http.StatusConflict, api.ErrorResponse{Status: false, Message: ErrUserAlreadyExist.Error()})
 			return
+		case errors.Is(err, services.ErrEmailAlreadyUsed):
+			Responder(w, http.StatusConflict, api.ErrorResponse{Status: false, Message: ErrEmailAlreadyUsed.Error()})
+			return
 		default:
 			log.Printf("%s: ошибка при регистрации пользователя: %v", op, err)
 			Responder(w, http.StatusInternalServerError, api.ErrorResponse{Status: false, Message: ErrInternalServer.Error()})
@@ -302,13 +492,15 @@ func (h *Handler) Register(w http.ResponseWriter, r *http.Request) {
 
 // Login godoc
 // @Summary Аутентификация пользователя
-// @Description Возвращает JWT токен для доступа к защищенным маршрутам.
+// @Description Возвращает JWT токен для доступа к защищенным маршрутам. Если у пользователя включена 2FA, возвращается TwoFactorRequired и ChallengeToken, который обменивается на токены в /auth/2fa/verify.
 // @Tags auth
 // @Produce json
 // @Param request body api.AuthRequest true "Логин и пароль"
 // @Success 200 {object} api.LoginResponse "Успешная аутентификация"
-// @Failure 403 {object} api.ErrorResponse "Unauthorized"
+// @Failure 401 {object} api.ErrorResponse "Неверный логин или пароль"
+// @Failure 403 {object} api.ErrorResponse "Пользователь заблокирован"
 // @Failure 400 {object} api.ErrorResponse "Ошибка клиента"
+// @Failure 429 {object} api.ErrorResponse "Вход временно заблокирован, см. заголовок Retry-After"
 // @Failure 500 {object} api.ErrorResponse "Ошибка на сервере"
 // @Router /auth/login [post]
 func (h *Handler) Login(w http.ResponseWriter, r *http.Request) {
@@ -328,15 +520,19 @@ func (h *Handler) Login(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	err := h.userService.Login(r.Context(), request.Login, request.Password)
+	user, err := h.userService.Login(r.Context(), request.Login, request.Password, h.clientIP(r))
 	if err != nil {
+		var locked *services.LoginLockedError
 		switch {
-		case errors.Is(err, services.ErrUserNotFound):
-			Responder(w, http.StatusNotFound, api.ErrorResponse{Status: false, Message: ErrUserNotFound.Error()})
+		case errors.As(err, &locked):
+			respondLoginLocked(w, locked)
 			return
-		case errors.Is(err, services.ErrIncorrectPassword):
+		case errors.Is(err, services.ErrInvalidCredentials):
 			Responder(w, http.StatusUnauthorized, api.ErrorResponse{Status: false, Message: ErrIncorrectPassword.Error()})
 			return
+		case errors.Is(err, services.ErrUserBanned):
+			Responder(w, http.StatusForbidden, api.ErrorResponse{Status: false, Message: ErrUserBanned.Error()})
+			return
 		default:
 			log.Printf("%s: ошибка при авторизации: %v", op, err)
 			Responder(w, http.StatusInternalServerError, api.ErrorResponse{Status: false, Message: ErrInternalServer.Error()})
@@ -344,19 +540,209 @@ func (h *Handler) Login(w http.ResponseWriter, r *http.Request) {
 		}
 	}
 
-	tokenStr, err := auth.GenerateToken(request.Login)
+	// При включенной 2FA вместо токенов выдается токен второго шага
+	if user.TOTPEnabled {
+		challenge, err := h.tokens.GenerateChallengeToken(user.ID)
+		if err != nil {
+			log.Printf("%s: ошибка при создании токена второго шага: %v", op, err)
+			Responder(w, http.StatusInternalServerError, api.ErrorResponse{Status: false, Message: ErrInternalServer.Error()})
+			return
+		}
+
+		Responder(w, http.StatusOK, api.LoginResponse{
+			Status:            true,
+			Message:           "Введите код двухфакторной аутентификации в /auth/2fa/verify",
+			TwoFactorRequired: true,
+			ChallengeToken:    challenge,
+		})
+		return
+	}
+
+	h.respondLogin(w, r, op, user)
+}
+
+// respondLogin выдает пользователю access и refresh токены после успешного входа.
+func (h *Handler) respondLogin(w http.ResponseWriter, r *http.Request, op string, user *models.User) {
+	tokenStr, err := h.tokens.GenerateToken(user.ID, user.Login, user.Role, user.TokenVersion)
 	if err != nil {
 		log.Printf("%s: ошибка при создании токена jwt: %v", op, err)
 		Responder(w, http.StatusInternalServerError, api.ErrorResponse{Status: false, Message: ErrInternalServer.Error()})
 		return
 	}
 
+	refreshToken, err := h.userService.IssueRefreshToken(r.Context(), user.ID)
+	if err != nil {
+		log.Printf("%s: ошибка при создании refresh токена: %v", op, err)
+		Responder(w, http.StatusInternalServerError, api.ErrorResponse{Status: false, Message: ErrInternalServer.Error()})
+		return
+	}
+
 	// Успешный ответ
 	resp := api.LoginResponse{
-		Status:  true,
-		Message: "Пользователь успешно авторизирован",
-		JWToken: tokenStr,
+		Status:       true,
+		Message:      "Пользователь успешно авторизирован",
+		JWToken:      tokenStr,
+		RefreshToken: refreshToken,
 	}
 
 	Responder(w, http.StatusCreated, resp)
 }
+
+// Refresh godoc
+// @Summary Обновление токенов
+// @Description Обменивает refresh токен на новую пару access и refresh токенов. Старый refresh токен становится недействительным, его повторное использование отзывает все токены, полученные от того же входа.
+// @Tags auth
+// @Accept json
+// @Produce json
+// @Param request body api.RefreshRequest true "Refresh токен"
+// @Success 200 {object} api.LoginResponse "Успешно"
+// @Failure 400 {object} api.ErrorResponse "Ошибка клиента"
+// @Failure 401 {object} api.ErrorResponse "Refresh токен недействителен"
+// @Failure 500 {object} api.ErrorResponse "Ошибка на сервере"
+// @Router /auth/refresh [post]
+func (h *Handler) Refresh(w http.ResponseWriter, r *http.Request) {
+	const op = "http_handlers.Refresh"
+
+	var request api.RefreshRequest
+	if err := json.NewDecoder(r.Body).Decode(&request); err != nil {
+		Responder(w, http.StatusBadRequest, api.ErrorResponse{Status: false, Message: ErrInvalidJSON.Error()})
+		return
+	}
+
+	user, refreshToken, err := h.userService.RefreshTokens(r.Context(), request.RefreshToken)
+	if err != nil {
+		switch {
+		case errors.Is(err, services.ErrRefreshTokenReused):
+			Responder(w, http.StatusUnauthorized, api.ErrorResponse{Status: false, Message: ErrRefreshTokenReused.Error()})
+			return
+		case errors.Is(err, services.ErrInvalidRefreshToken):
+			Responder(w, http.StatusUnauthorized, api.ErrorResponse{Status: false, Message: ErrInvalidRefreshToken.Error()})
+			return
+		case errors.Is(err, services.ErrUserBanned):
+			Responder(w, http.StatusForbidden, api.ErrorResponse{Status: false, Message: ErrUserBanned.Error()})
+			return
+		default:
+			log.Printf("%s: ошибка при обновлении токенов: %v", op, err)
+			Responder(w, http.StatusInternalServerError, api.ErrorResponse{Status: false, Message: ErrInternalServer.Error()})
+			return
+		}
+	}
+
+	tokenStr, err := h.tokens.GenerateToken(user.ID, user.Login, user.Role, user.TokenVersion)
+	if err != nil {
+		log.Printf("%s: ошибка при создании токена jwt: %v", op, err)
+		Responder(w, http.StatusInternalServerError, api.ErrorResponse{Status: false, Message: ErrInternalServer.Error()})
+		return
+	}
+
+	Responder(w, http.StatusOK, api.LoginResponse{
+		Status:       true,
+		Message:      "Токены обновлены",
+		JWToken:      tokenStr,
+		RefreshToken: refreshToken,
+	})
+}
+
+// clientIP возвращает IP адрес клиента. Если соединение пришло от доверенного прокси, адрес берется
+// из X-Forwarded-For (последний адрес справа, не принадлежащий доверенным прокси) или X-Real-IP,
+// иначе - из адреса соединения. Без доверенных прокси заголовки игнорируются: их подделывает клиент.
+func (h *Handler) clientIP(r *http.Request) string {
+	host, _, err := net.SplitHostPort(r.RemoteAddr)
+	if err != nil {
+		host = r.RemoteAddr
+	}
+
+	remote, err := netip.ParseAddr(host)
+	if err != nil || !h.trustedProxy(remote) {
+		return host
+	}
+
+	// Каждый прокси дописывает адрес своего клиента в конец, поэтому левые адреса мог подставить клиент
+	var forwarded []string
+	for _, value := range r.Header.Values("X-Forwarded-For") {
+		forwarded = append(forwarded, strings.Split(value, ",")...)
+	}
+	for i := len(forwarded) - 1; i >= 0; i-- {
+		addr, err := netip.ParseAddr(strings.TrimSpace(forwarded[i]))
+		if err != nil {
+			break
+		}
+		if !h.trustedProxy(addr) || i == 0 {
+			return addr.Unmap().String()
+		}
+	}
+
+	if addr, err := netip.ParseAddr(strings.TrimSpace(r.Header.Get("X-Real-IP"))); err == nil {
+		return addr.Unmap().String()
+	}
+
+	return host
+}
+
+// trustedProxy проверяет, что addr - адрес доверенного прокси.
+func (h *Handler) trustedProxy(addr netip.Addr) bool {
+	addr = addr.Unmap()
+	for _, prefix := range h.trustedProxies {
+		if prefix.Contains(addr) {
+			return true
+		}
+	}
+	return false
+}
+
+// authorizedUserID извлекает userID из параметра пути и проверяет, что он совпадает с текущим
+// пользователем либо текущий пользователь - администратор. В случае ошибки отправляет ответ клиенту.
+func authorizedUserID(w http.ResponseWriter, r *http.Request, op string) (uint, bool) {
+	userID, err := parseIDParam(r, "userID")
+	if err != nil {
+		log.Printf("%s %s %v", op, r.URL, err)
+		Responder(w, http.StatusBadRequest, api.ErrorResponse{Status: false, Message: ErrInvalidID.Error()})
+		return 0, false
+	}
+
+	caller := userFromContext(r.Context())
+	if caller.ID != userID && caller.Role != models.RoleAdmin {
+		Responder(w, http.StatusForbidden, api.ErrorResponse{Status: false, Message: ErrForbidden.Error()})
+		return 0, false
+	}
+
+	return userID, true
+}
+
+// parseIDParam извлекает положительный числовой идентификатор из параметра пути.
+func parseIDParam(r *http.Request, name string) (uint, error) {
+	id, err := strconv.ParseUint(chi.URLParam(r, name), 10, 64)
+	if err != nil {
+		return 0, err
+	}
+	if id == 0 {
+		return 0, fmt.Errorf("параметр %s должен быть больше 0", name)
+	}
+	return uint(id), nil
+}
+
+// parsePagination извлекает limit и offset из query параметров запроса.
+func parsePagination(r *http.Request) (uint, uint, error) {
+	limit := uint64(defaultPageLimit)
+	offset := uint64(0)
+	var err error
+
+	if limitStr := r.URL.Query().Get("limit"); limitStr != "" {
+		limit, err = strconv.ParseUint(limitStr, 10, 64)
+		if err != nil {
+			return 0, 0, err
+		}
+		if limit == 0 || limit > maxPageLimit {
+			return 0, 0, fmt.Errorf("limit должен быть от 1 до %d", maxPageLimit)
+		}
+	}
+
+	if offsetStr := r.URL.Query().Get("offset"); offsetStr != "" {
+		offset, err = strconv.ParseUint(offsetStr, 10, 64)
+		if err != nil {
+			return 0, 0, err
+		}
+	}
+
+	return uint(limit), uint(offset), nil
+}
@@ -0,0 +1,65 @@
+package http_handlers
+
+import (
+	"net/http/httptest"
+	"net/netip"
+	"testing"
+)
+
+func TestClientIP(t *testing.T) {
+	h := &Handler{trustedProxies: []netip.Prefix{
+		netip.MustParsePrefix("10.0.0.0/8"),
+		netip.MustParsePrefix("2001:db8::1/128"),
+	}}
+
+	tests := []struct {
+		name       string
+		remoteAddr string
+		forwarded  []string
+		realIP     string
+		want       string
+	}{
+		{name: "direct", remoteAddr: "203.0.113.5:4000", want: "203.0.113.5"},
+		{name: "untrusted forwarded for", remoteAddr: "203.0.113.5:4000", forwarded: []string{"198.51.100.7"}, want: "203.0.113.5"},
+		{name: "untrusted real ip", remoteAddr: "203.0.113.5:4000", realIP: "198.51.100.7", want: "203.0.113.5"},
+		{name: "trusted proxy", remoteAddr: "10.0.0.2:4000", forwarded: []string{"198.51.100.7"}, want: "198.51.100.7"},
+		{name: "spoofed leftmost", remoteAddr: "10.0.0.2:4000", forwarded: []string{"1.1.1.1, 198.51.100.7"}, want: "198.51.100.7"},
+		{name: "proxy chain", remoteAddr: "10.0.0.2:4000", forwarded: []string{"198.51.100.7, 10.0.0.3"}, want: "198.51.100.7"},
+		{name: "multiple headers", remoteAddr: "10.0.0.2:4000", forwarded: []string{"1.1.1.1", "198.51.100.7"}, want: "198.51.100.7"},
+		{name: "all trusted", remoteAddr: "10.0.0.2:4000", forwarded: []string{"10.0.0.4, 10.0.0.3"}, want: "10.0.0.4"},
+		{name: "trusted real ip", remoteAddr: "10.0.0.2:4000", realIP: "198.51.100.7", want: "198.51.100.7"},
+		{name: "trusted malformed", remoteAddr: "10.0.0.2:4000", forwarded: []string{"garbage"}, want: "10.0.0.2"},
+		{name: "trusted without headers", remoteAddr: "10.0.0.2:4000", want: "10.0.0.2"},
+		{name: "trusted ipv6", remoteAddr: "[2001:db8::1]:4000", forwarded: []string{"2001:db8::7"}, want: "2001:db8::7"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r := httptest.NewRequest("POST", "/login", nil)
+			r.RemoteAddr = tt.remoteAddr
+			for _, value := range tt.forwarded {
+				r.Header.Add("X-Forwarded-For", value)
+			}
+			if tt.realIP != "" {
+				r.Header.Set("X-Real-IP", tt.realIP)
+			}
+
+			if got := h.clientIP(r); got != tt.want {
+				t.Errorf("clientIP = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestClientIPWithoutTrustedProxies(t *testing.T) {
+	h := &Handler{}
+
+	r := httptest.NewRequest("POST", "/login", nil)
+	r.RemoteAddr = "127.0.0.1:4000"
+	r.Header.Set("X-Forwarded-For", "198.51.100.7")
+	r.Header.Set("X-Real-IP", "198.51.100.8")
+
+	if got := h.clientIP(r); got != "127.0.0.1" {
+		t.Errorf("clientIP = %q, want %q", got, "127.0.0.1")
+	}
+}
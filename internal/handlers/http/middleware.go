@@ -0,0 +1,220 @@
+package http_handlers
+
+import (
+	"context"
+	"errors"
+	"github.com/RVodassa/TaskReward/internal/api"
+	"github.com/RVodassa/TaskReward/internal/domain/models"
+	"github.com/RVodassa/TaskReward/internal/services"
+	"github.com/go-chi/jwtauth/v5"
+	"github.com/lestrrat-go/jwx/v2/jwt"
+	"log"
+	"net/http"
+	"strconv"
+)
+
+type contextKey string
+
+const (
+	userContextKey   contextKey = "user"
+	apiKeyContextKey contextKey = "api_key"
+)
+
+// apiKeyHeader заголовок, в котором сервисы передают API ключ вместо Bearer токена
+const apiKeyHeader = "X-API-Key"
+
+// Verifier извлекает access токен из заголовка Authorization или cookie jwt, проверяет его ключом
+// с kid из заголовка токена и кладет результат в контекст запроса для jwtauth.Authenticator.
+func (h *Handler) Verifier(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		tokenString := jwtauth.TokenFromHeader(r)
+		if tokenString == "" {
+			tokenString = jwtauth.TokenFromCookie(r)
+		}
+
+		var token jwt.Token
+		err := jwtauth.ErrNoTokenFound
+		if tokenString != "" {
+			token, err = h.tokens.Verify(tokenString)
+		}
+
+		ctx := jwtauth.NewContext(r.Context(), token, err)
+		next.ServeHTTP(w, r.WithContext(ctx))
+	})
+}
+
+// UserCtx загружает пользователя из claim sub JWT токена и кладет его в контекст запроса.
+// Токен отклоняется, если он отозван через logout, его версия (claim ver) устарела после
+// logout-all или смены пароля, либо пользователь заблокирован. Используется после jwtauth.Authenticator.
+func (h *Handler) UserCtx(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		const op = "http_handlers.UserCtx"
+
+		token, _, err := jwtauth.FromContext(r.Context())
+		if err != nil || token == nil {
+			Responder(w, http.StatusUnauthorized, api.ErrorResponse{Status: false, Message: ErrUnauthorized.Error()})
+			return
+		}
+
+		userID, err := strconv.ParseUint(token.Subject(), 10, 64)
+		if err != nil || userID == 0 {
+			Responder(w, http.StatusUnauthorized, api.ErrorResponse{Status: false, Message: ErrUnauthorized.Error()})
+			return
+		}
+
+		user, err := h.userService.StatusUser(r.Context(), uint(userID))
+		if err != nil {
+			if errors.Is(err, services.ErrUserNotFound) {
+				Responder(w, http.StatusUnauthorized, api.ErrorResponse{Status: false, Message: ErrUnauthorized.Error()})
+				return
+			}
+			log.Printf("%s: %v", op, err)
+			Responder(w, http.StatusInternalServerError, api.ErrorResponse{Status: false, Message: ErrInternalServer.Error()})
+			return
+		}
+
+		if user.BannedAt != nil {
+			Responder(w, http.StatusForbidden, api.ErrorResponse{Status: false, Message: ErrUserBanned.Error()})
+			return
+		}
+
+		version, ok := tokenVersion(token)
+		if !ok || version != user.TokenVersion || token.JwtID() == "" {
+			Responder(w, http.StatusUnauthorized, api.ErrorResponse{Status: false, Message: ErrUnauthorized.Error()})
+			return
+		}
+
+		revoked, err := h.userService.IsAccessTokenRevoked(r.Context(), token.JwtID())
+		if err != nil {
+			log.Printf("%s: %v", op, err)
+			Responder(w, http.StatusInternalServerError, api.ErrorResponse{Status: false, Message: ErrInternalServer.Error()})
+			return
+		}
+		if revoked {
+			Responder(w, http.StatusUnauthorized, api.ErrorResponse{Status: false, Message: ErrUnauthorized.Error()})
+			return
+		}
+
+		ctx := context.WithValue(r.Context(), userContextKey, user)
+		next.ServeHTTP(w, r.WithContext(ctx))
+	})
+}
+
+// tokenVersion возвращает claim ver токена. Числа из JSON декодируются как float64.
+func tokenVersion(token jwt.Token) (uint, bool) {
+	claim, ok := token.Get("ver")
+	if !ok {
+		return 0, false
+	}
+
+	version, ok := claim.(float64)
+	if !ok || version < 0 {
+		return 0, false
+	}
+
+	return uint(version), true
+}
+
+// userFromContext возвращает текущего пользователя, загруженного UserCtx.
+func userFromContext(ctx context.Context) *models.User {
+	user, _ := ctx.Value(userContextKey).(*models.User)
+	if user == nil {
+		return &models.User{}
+	}
+	return user
+}
+
+// APIKeyCtx принимает API ключ из заголовка X-API-Key как альтернативу access токену. Если заголовок
+// передан, в контекст кладутся ключ и создавший его администратор, иначе запрос проверяется
+// как обычно: Verifier, jwtauth.Authenticator и UserCtx. Права ключа проверяет RequireAccess.
+func (h *Handler) APIKeyCtx(next http.Handler) http.Handler {
+	jwtChain := h.Verifier(jwtauth.Authenticator(nil)(h.UserCtx(next)))
+
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		const op = "http_handlers.APIKeyCtx"
+
+		rawKey := r.Header.Get(apiKeyHeader)
+		if rawKey == "" {
+			jwtChain.ServeHTTP(w, r)
+			return
+		}
+
+		key, user, err := h.userService.AuthenticateAPIKey(r.Context(), rawKey)
+		if err != nil {
+			if errors.Is(err, services.ErrInvalidAPIKey) {
+				Responder(w, http.StatusUnauthorized, api.ErrorResponse{Status: false, Message: ErrInvalidAPIKey.Error()})
+				return
+			}
+			log.Printf("%s: %v", op, err)
+			Responder(w, http.StatusInternalServerError, api.ErrorResponse{Status: false, Message: ErrInternalServer.Error()})
+			return
+		}
+
+		ctx := context.WithValue(r.Context(), userContextKey, user)
+		ctx = context.WithValue(ctx, apiKeyContextKey, key)
+		next.ServeHTTP(w, r.WithContext(ctx))
+	})
+}
+
+// apiKeyFromContext возвращает API ключ запроса или nil, если запрос выполнен с access токеном.
+func apiKeyFromContext(ctx context.Context) *models.APIKey {
+	key, _ := ctx.Value(apiKeyContextKey).(*models.APIKey)
+	return key
+}
+
+// RequireAccess пропускает запросы с API ключом, которому выдано право scope, и пользователей,
+// роль которых входит в список roles (пустой список - любой пользователь). Используется после APIKeyCtx.
+func RequireAccess(scope string, roles ...string) func(http.Handler) http.Handler {
+	allowed := make(map[string]struct{}, len(roles))
+	for _, role := range roles {
+		allowed[role] = struct{}{}
+	}
+
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if key := apiKeyFromContext(r.Context()); key != nil {
+				if !key.HasScope(scope) {
+					Responder(w, http.StatusForbidden, api.ErrorResponse{Status: false, Message: ErrAPIKeyScope.Error()})
+					return
+				}
+				next.ServeHTTP(w, r)
+				return
+			}
+
+			if len(allowed) > 0 {
+				if _, ok := allowed[userFromContext(r.Context()).Role]; !ok {
+					Responder(w, http.StatusForbidden, api.ErrorResponse{Status: false, Message: ErrForbidden.Error()})
+					return
+				}
+			}
+
+			next.ServeHTTP(w, r)
+		})
+	}
+}
+
+// RequireRole пропускает только пользователей, роль которых входит в список roles.
+// Используется после UserCtx, поэтому изменение роли применяется сразу.
+// Запросы с API ключом отклоняются: ключ действует только на маршрутах с RequireAccess.
+func RequireRole(roles ...string) func(http.Handler) http.Handler {
+	allowed := make(map[string]struct{}, len(roles))
+	for _, role := range roles {
+		allowed[role] = struct{}{}
+	}
+
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if apiKeyFromContext(r.Context()) != nil {
+				Responder(w, http.StatusForbidden, api.ErrorResponse{Status: false, Message: ErrAPIKeyScope.Error()})
+				return
+			}
+
+			if _, ok := allowed[userFromContext(r.Context()).Role]; !ok {
+				Responder(w, http.StatusForbidden, api.ErrorResponse{Status: false, Message: ErrForbidden.Error()})
+				return
+			}
+
+			next.ServeHTTP(w, r)
+		})
+	}
+}
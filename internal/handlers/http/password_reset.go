@@ -0,0 +1,135 @@
+package http_handlers
+
+import (
+	"encoding/json"
+	"errors"
+	"github.com/RVodassa/TaskReward/internal/api"
+	"github.com/RVodassa/TaskReward/internal/services"
+	"log"
+	"net/http"
+)
+
+var (
+	ErrInvalidEmail      = errors.New("ошибка: некорректный email")
+	ErrEmailAlreadyUsed  = errors.New("ошибка: email уже используется другим пользователем")
+	ErrInvalidResetToken = errors.New("ошибка: токен сброса пароля недействителен или истек, запросите новый")
+	ErrResetRequired     = errors.New("ошибка: токен и новый пароль обязательны")
+)
+
+// UpdateMyEmail godoc
+// @Summary Изменить email
+// @Description Устанавливает email текущего пользователя, пустой email удаляет его. Email нужен для восстановления пароля.
+// @Tags Users
+// @Accept json
+// @Produce json
+// @Param request body api.UpdateEmailRequest true "Новый email"
+// @Success 200 {object} api.StatusUserResponse "Успешно"
+// @Failure 403 {object} api.ErrorResponse "Unauthorized"
+// @Failure 400 {object} api.ErrorResponse "Ошибка клиента"
+// @Failure 409 {object} api.ErrorResponse "Email уже занят"
+// @Failure 500 {object} api.ErrorResponse "Ошибка на сервере"
+// @Router /users/me/email [put]
+// @security BearerAuth
+func (h *Handler) UpdateMyEmail(w http.ResponseWriter, r *http.Request) {
+	const op = "http_handlers.UpdateMyEmail"
+
+	var request api.UpdateEmailRequest
+	if err := json.NewDecoder(r.Body).Decode(&request); err != nil {
+		Responder(w, http.StatusBadRequest, api.ErrorResponse{Status: false, Message: ErrInvalidJSON.Error()})
+		return
+	}
+
+	user, err := h.userService.UpdateEmail(r.Context(), userFromContext(r.Context()).ID, request.Email)
+	if err != nil {
+		switch {
+		case errors.Is(err, services.ErrInvalidEmail):
+			Responder(w, http.StatusBadRequest, api.ErrorResponse{Status: false, Message: ErrInvalidEmail.Error()})
+		case errors.Is(err, services.ErrEmailAlreadyUsed):
+			Responder(w, http.StatusConflict, api.ErrorResponse{Status: false, Message: ErrEmailAlreadyUsed.Error()})
+		case errors.Is(err, services.ErrUserNotFound):
+			Responder(w, http.StatusNotFound, api.ErrorResponse{Status: false, Message: ErrUserNotFound.Error()})
+		default:
+			log.Printf("%s: %v", op, err)
+			Responder(w, http.StatusInternalServerError, api.ErrorResponse{Status: false, Message: ErrInternalServer.Error()})
+		}
+		return
+	}
+
+	Responder(w, http.StatusOK, api.StatusUserResponse{Status: true, Message: "Email изменен", User: user})
+}
+
+// ForgotPassword godoc
+// @Summary Запросить восстановление пароля
+// @Description Отправляет на email письмо с одноразовым токеном сброса пароля. Ответ одинаков независимо от того, зарегистрирован ли email.
+// @Tags auth
+// @Accept json
+// @Produce json
+// @Param request body api.ForgotPasswordRequest true "Email пользователя"
+// @Success 202 {object} api.MessageResponse "Запрос принят"
+// @Failure 400 {object} api.ErrorResponse "Ошибка клиента"
+// @Failure 500 {object} api.ErrorResponse "Ошибка на сервере"
+// @Router /auth/password/forgot [post]
+func (h *Handler) ForgotPassword(w http.ResponseWriter, r *http.Request) {
+	const op = "http_handlers.ForgotPassword"
+
+	var request api.ForgotPasswordRequest
+	if err := json.NewDecoder(r.Body).Decode(&request); err != nil {
+		Responder(w, http.StatusBadRequest, api.ErrorResponse{Status: false, Message: ErrInvalidJSON.Error()})
+		return
+	}
+
+	if err := h.userService.RequestPasswordReset(r.Context(), request.Email); err != nil {
+		if errors.Is(err, services.ErrInvalidEmail) {
+			Responder(w, http.StatusBadRequest, api.ErrorResponse{Status: false, Message: ErrInvalidEmail.Error()})
+			return
+		}
+		log.Printf("%s: %v", op, err)
+		Responder(w, http.StatusInternalServerError, api.ErrorResponse{Status: false, Message: ErrInternalServer.Error()})
+		return
+	}
+
+	Responder(w, http.StatusAccepted, api.MessageResponse{
+		Status:  true,
+		Message: "Если email зарегистрирован, на него отправлено письмо для восстановления пароля",
+	})
+}
+
+// ResetPassword godoc
+// @Summary Сбросить пароль
+// @Description Устанавливает новый пароль по токену из письма. Токен одноразовый, все сессии пользователя завершаются.
+// @Tags auth
+// @Accept json
+// @Produce json
+// @Param request body api.ResetPasswordRequest true "Токен из письма и новый пароль"
+// @Success 200 {object} api.MessageResponse "Пароль изменен"
+// @Failure 400 {object} api.ValidationErrorResponse "Недействительный токен или пароль не соответствует требованиям"
+// @Failure 500 {object} api.ErrorResponse "Ошибка на сервере"
+// @Router /auth/password/reset [post]
+func (h *Handler) ResetPassword(w http.ResponseWriter, r *http.Request) {
+	const op = "http_handlers.ResetPassword"
+
+	var request api.ResetPasswordRequest
+	if err := json.NewDecoder(r.Body).Decode(&request); err != nil {
+		Responder(w, http.StatusBadRequest, api.ErrorResponse{Status: false, Message: ErrInvalidJSON.Error()})
+		return
+	}
+	if request.Token == "" || request.NewPassword == "" {
+		Responder(w, http.StatusBadRequest, api.ErrorResponse{Status: false, Message: ErrResetRequired.Error()})
+		return
+	}
+
+	if err := h.userService.ResetPassword(r.Context(), request.Token, request.NewPassword); err != nil {
+		if respondValidationError(w, err) {
+			return
+		}
+		if errors.Is(err, services.ErrInvalidResetToken) {
+			Responder(w, http.StatusBadRequest, api.ErrorResponse{Status: false, Message: ErrInvalidResetToken.Error()})
+			return
+		}
+		log.Printf("%s: %v", op, err)
+		Responder(w, http.StatusInternalServerError, api.ErrorResponse{Status: false, Message: ErrInternalServer.Error()})
+		return
+	}
+
+	Responder(w, http.StatusOK, api.MessageResponse{Status: true, Message: "Пароль изменен, выполните вход с новым паролем"})
+}
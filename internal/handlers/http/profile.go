@@ -0,0 +1,49 @@
+package http_handlers
+
+import (
+	"encoding/json"
+	"errors"
+	"github.com/RVodassa/TaskReward/internal/api"
+	"github.com/RVodassa/TaskReward/internal/services"
+	"log"
+	"net/http"
+)
+
+// UpdateMyProfile godoc
+// @Summary Изменить профиль
+// @Description Устанавливает отображаемое имя (до 50 символов) и ссылку на аватар, которые показываются в таблицах лидеров, и видимость в них: public - имя и аватар видны всем (по умолчанию), anonymous - место видно без имени, ID и аватара, hidden - пользователь не участвует в рейтингах и итогах сезонов. Пустые значения удаляют имя и аватар.
+// @Tags Users
+// @Accept json
+// @Produce json
+// @Param request body api.UpdateProfileRequest true "Профиль"
+// @Success 200 {object} api.StatusUserResponse "Успешно"
+// @Failure 403 {object} api.ErrorResponse "Unauthorized"
+// @Failure 400 {object} api.ValidationErrorResponse "Ошибки полей"
+// @Failure 500 {object} api.ErrorResponse "Ошибка на сервере"
+// @Router /users/me/profile [put]
+// @security BearerAuth
+func (h *Handler) UpdateMyProfile(w http.ResponseWriter, r *http.Request) {
+	const op = "http_handlers.UpdateMyProfile"
+
+	var request api.UpdateProfileRequest
+	if err := json.NewDecoder(r.Body).Decode(&request); err != nil {
+		Responder(w, http.StatusBadRequest, api.ErrorResponse{Status: false, Message: ErrInvalidJSON.Error()})
+		return
+	}
+
+	user, err := h.userService.UpdateProfile(r.Context(), userFromContext(r.Context()).ID, request.DisplayName, request.AvatarURL, request.LeaderboardVisibility)
+	if err != nil {
+		if respondValidationError(w, err) {
+			return
+		}
+		if errors.Is(err, services.ErrUserNotFound) {
+			Responder(w, http.StatusNotFound, api.ErrorResponse{Status: false, Message: ErrUserNotFound.Error()})
+			return
+		}
+		log.Printf("%s: %v", op, err)
+		Responder(w, http.StatusInternalServerError, api.ErrorResponse{Status: false, Message: ErrInternalServer.Error()})
+		return
+	}
+
+	Responder(w, http.StatusOK, api.StatusUserResponse{Status: true, Message: "Профиль изменен", User: user})
+}
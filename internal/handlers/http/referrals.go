@@ -0,0 +1,311 @@
+package http_handlers
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"github.com/RVodassa/TaskReward/internal/api"
+	"github.com/RVodassa/TaskReward/internal/services"
+	"log"
+	"net/http"
+	"strconv"
+)
+
+var (
+	ErrInvalidReferralDepth = errors.New("ошибка: некорректная глубина дерева приглашенных")
+	ErrInvalidReferralCode  = errors.New("ошибка: срок действия кода приглашения должен быть в будущем")
+	ErrInvalidCodeID        = errors.New("ошибка: некорректный code_id")
+	ErrReferralCodeInvalid  = errors.New("ошибка: код приглашения не найден, отключен, просрочен или исчерпан")
+	ErrReferralCodeNotFound = errors.New("ошибка: код приглашения не найден")
+)
+
+// GetMyReferrals godoc
+// @Summary Приглашенные текущим пользователем
+// @Description Возвращает пользователей, зарегистрированных по приглашению текущего пользователя
+// @Tags Referrals
+// @Produce json
+// @Param limit query int false "Кол-во записей (по умолчанию 50, максимум 100)"
+// @Param offset query int false "Смещение"
+// @Success 200 {object} api.ReferralsResponse "Успешно"
+// @Failure 403 {object} api.ErrorResponse "Unauthorized"
+// @Failure 400 {object} api.ErrorResponse "Ошибка клиента"
+// @Failure 500 {object} api.ErrorResponse "Ошибка на сервере"
+// @Router /users/me/referrals [get]
+// @security BearerAuth
+func (h *Handler) GetMyReferrals(w http.ResponseWriter, r *http.Request) {
+	h.respondReferrals(w, r, userFromContext(r.Context()).ID, 1)
+}
+
+// GetUserReferrals godoc
+// @Summary Приглашенные пользователем
+// @Description Возвращает пользователей, зарегистрированных по приглашению пользователя. Пользователь может запросить только своих приглашенных, администратор - любых.
+// @Tags Referrals
+// @Produce json
+// @Param userID path string true "ID пользователя"
+// @Param limit query int false "Кол-во записей (по умолчанию 50, максимум 100)"
+// @Param offset query int false "Смещение"
+// @Success 200 {object} api.ReferralsResponse "Успешно"
+// @Failure 403 {object} api.ErrorResponse "Unauthorized"
+// @Failure 400 {object} api.ErrorResponse "Ошибка клиента"
+// @Failure 500 {object} api.ErrorResponse "Ошибка на сервере"
+// @Router /users/{userID}/referrals [get]
+// @security BearerAuth
+func (h *Handler) GetUserReferrals(w http.ResponseWriter, r *http.Request) {
+	const op = "http_handlers.GetUserReferrals"
+
+	userID, ok := authorizedUserID(w, r, op)
+	if !ok {
+		return
+	}
+
+	h.respondReferrals(w, r, userID, 1)
+}
+
+// GetMyReferralTree godoc
+// @Summary Дерево приглашенных текущего пользователя
+// @Description Возвращает всех приглашенных текущего пользователя до указанной глубины, отсортированных по уровню
+// @Tags Referrals
+// @Produce json
+// @Param depth query int false "Глубина дерева, по умолчанию - максимальная из настроек"
+// @Param limit query int false "Кол-во записей (по умолчанию 50, максимум 100)"
+// @Param offset query int false "Смещение"
+// @Success 200 {object} api.ReferralsResponse "Успешно"
+// @Failure 403 {object} api.ErrorResponse "Unauthorized"
+// @Failure 400 {object} api.ErrorResponse "Ошибка клиента"
+// @Failure 500 {object} api.ErrorResponse "Ошибка на сервере"
+// @Router /users/me/referrals/tree [get]
+// @security BearerAuth
+func (h *Handler) GetMyReferralTree(w http.ResponseWriter, r *http.Request) {
+	h.respondReferralTree(w, r, userFromContext(r.Context()).ID)
+}
+
+// GetUserReferralTree godoc
+// @Summary Дерево приглашенных пользователя
+// @Description Возвращает всех приглашенных пользователя до указанной глубины. Пользователь может запросить только свое дерево, администратор - любое.
+// @Tags Referrals
+// @Produce json
+// @Param userID path string true "ID пользователя"
+// @Param depth query int false "Глубина дерева, по умолчанию - максимальная из настроек"
+// @Param limit query int false "Кол-во записей (по умолчанию 50, максимум 100)"
+// @Param offset query int false "Смещение"
+// @Success 200 {object} api.ReferralsResponse "Успешно"
+// @Failure 403 {object} api.ErrorResponse "Unauthorized"
+// @Failure 400 {object} api.ErrorResponse "Ошибка клиента"
+// @Failure 500 {object} api.ErrorResponse "Ошибка на сервере"
+// @Router /users/{userID}/referrals/tree [get]
+// @security BearerAuth
+func (h *Handler) GetUserReferralTree(w http.ResponseWriter, r *http.Request) {
+	const op = "http_handlers.GetUserReferralTree"
+
+	userID, ok := authorizedUserID(w, r, op)
+	if !ok {
+		return
+	}
+
+	h.respondReferralTree(w, r, userID)
+}
+
+// GetMyReferralStats godoc
+// @Summary Статистика приглашений текущего пользователя
+// @Description Возвращает кол-во приглашенных, активных приглашенных и заработок по реферальной программе
+// @Tags Referrals
+// @Produce json
+// @Success 200 {object} api.ReferralStatsResponse "Успешно"
+// @Failure 403 {object} api.ErrorResponse "Unauthorized"
+// @Failure 500 {object} api.ErrorResponse "Ошибка на сервере"
+// @Router /users/me/referrals/stats [get]
+// @security BearerAuth
+func (h *Handler) GetMyReferralStats(w http.ResponseWriter, r *http.Request) {
+	h.respondReferralStats(w, r, userFromContext(r.Context()).ID)
+}
+
+// GetUserReferralStats godoc
+// @Summary Статистика приглашений пользователя
+// @Description Возвращает статистику приглашений пользователя. Пользователь может запросить только свою статистику, администратор - любую.
+// @Tags Referrals
+// @Produce json
+// @Param userID path string true "ID пользователя"
+// @Success 200 {object} api.ReferralStatsResponse "Успешно"
+// @Failure 403 {object} api.ErrorResponse "Unauthorized"
+// @Failure 400 {object} api.ErrorResponse "Ошибка клиента"
+// @Failure 500 {object} api.ErrorResponse "Ошибка на сервере"
+// @Router /users/{userID}/referrals/stats [get]
+// @security BearerAuth
+func (h *Handler) GetUserReferralStats(w http.ResponseWriter, r *http.Request) {
+	const op = "http_handlers.GetUserReferralStats"
+
+	userID, ok := authorizedUserID(w, r, op)
+	if !ok {
+		return
+	}
+
+	h.respondReferralStats(w, r, userID)
+}
+
+// CreateMyReferralCode godoc
+// @Summary Создать код приглашения
+// @Description Создает код приглашения текущего пользователя. Зарегистрировавшиеся по коду становятся приглашенными владельца кода.
+// @Tags Referrals
+// @Accept json
+// @Produce json
+// @Param request body api.CreateReferralCodeRequest true "Ограничение кол-ва регистраций (0 - без ограничений) и срок действия"
+// @Success 201 {object} api.ReferralCodeResponse "Код создан"
+// @Failure 403 {object} api.ErrorResponse "Unauthorized"
+// @Failure 400 {object} api.ErrorResponse "Ошибка клиента"
+// @Failure 500 {object} api.ErrorResponse "Ошибка на сервере"
+// @Router /users/me/referral-codes [post]
+// @security BearerAuth
+func (h *Handler) CreateMyReferralCode(w http.ResponseWriter, r *http.Request) {
+	const op = "http_handlers.CreateMyReferralCode"
+
+	var request api.CreateReferralCodeRequest
+	if err := json.NewDecoder(r.Body).Decode(&request); err != nil {
+		Responder(w, http.StatusBadRequest, api.ErrorResponse{Status: false, Message: ErrInvalidJSON.Error()})
+		return
+	}
+
+	code, err := h.userService.CreateReferralCode(r.Context(), userFromContext(r.Context()).ID, request.MaxUses, request.ExpiresAt)
+	if err != nil {
+		if errors.Is(err, services.ErrInvalidReferralCode) {
+			Responder(w, http.StatusBadRequest, api.ErrorResponse{Status: false, Message: ErrInvalidReferralCode.Error()})
+			return
+		}
+		log.Printf("%s: %v", op, err)
+		Responder(w, http.StatusInternalServerError, api.ErrorResponse{Status: false, Message: ErrInternalServer.Error()})
+		return
+	}
+
+	Responder(w, http.StatusCreated, api.ReferralCodeResponse{
+		Status:  true,
+		Message: "Код приглашения создан",
+		Code:    code,
+	})
+}
+
+// GetMyReferralCodes godoc
+// @Summary Коды приглашения текущего пользователя
+// @Description Возвращает коды приглашения текущего пользователя, ссылки-приглашения и кол-во регистраций по ним
+// @Tags Referrals
+// @Produce json
+// @Success 200 {object} api.ReferralCodesResponse "Успешно"
+// @Failure 403 {object} api.ErrorResponse "Unauthorized"
+// @Failure 500 {object} api.ErrorResponse "Ошибка на сервере"
+// @Router /users/me/referral-codes [get]
+// @security BearerAuth
+func (h *Handler) GetMyReferralCodes(w http.ResponseWriter, r *http.Request) {
+	const op = "http_handlers.GetMyReferralCodes"
+
+	codes, err := h.userService.GetReferralCodes(r.Context(), userFromContext(r.Context()).ID)
+	if err != nil {
+		log.Printf("%s: %v", op, err)
+		Responder(w, http.StatusInternalServerError, api.ErrorResponse{Status: false, Message: ErrInternalServer.Error()})
+		return
+	}
+
+	Responder(w, http.StatusOK, api.ReferralCodesResponse{
+		Status:  true,
+		Message: fmt.Sprintf("Коды приглашения. Кол-во: %d", len(codes)),
+		Codes:   codes,
+	})
+}
+
+// DeactivateMyReferralCode godoc
+// @Summary Отключить код приглашения
+// @Description Отключает код приглашения текущего пользователя, регистрации по нему больше не принимаются
+// @Tags Referrals
+// @Produce json
+// @Param codeID path string true "ID кода приглашения"
+// @Success 200 {object} api.ReferralCodeResponse "Успешно"
+// @Failure 403 {object} api.ErrorResponse "Unauthorized"
+// @Failure 400 {object} api.ErrorResponse "Ошибка клиента"
+// @Failure 404 {object} api.ErrorResponse "Код не найден"
+// @Failure 500 {object} api.ErrorResponse "Ошибка на сервере"
+// @Router /users/me/referral-codes/{codeID}/deactivate [post]
+// @security BearerAuth
+func (h *Handler) DeactivateMyReferralCode(w http.ResponseWriter, r *http.Request) {
+	const op = "http_handlers.DeactivateMyReferralCode"
+
+	codeID, err := parseIDParam(r, "codeID")
+	if err != nil {
+		Responder(w, http.StatusBadRequest, api.ErrorResponse{Status: false, Message: ErrInvalidCodeID.Error()})
+		return
+	}
+
+	code, err := h.userService.DeactivateReferralCode(r.Context(), codeID, userFromContext(r.Context()).ID)
+	if err != nil {
+		if errors.Is(err, services.ErrReferralCodeNotFound) {
+			Responder(w, http.StatusNotFound, api.ErrorResponse{Status: false, Message: ErrReferralCodeNotFound.Error()})
+			return
+		}
+		log.Printf("%s: %v", op, err)
+		Responder(w, http.StatusInternalServerError, api.ErrorResponse{Status: false, Message: ErrInternalServer.Error()})
+		return
+	}
+
+	Responder(w, http.StatusOK, api.ReferralCodeResponse{
+		Status:  true,
+		Message: "Код приглашения отключен",
+		Code:    code,
+	})
+}
+
+// respondReferralTree отправляет клиенту дерево приглашенных с глубиной из query параметра depth.
+func (h *Handler) respondReferralTree(w http.ResponseWriter, r *http.Request, userID uint) {
+	var depth uint64
+	if depthStr := r.URL.Query().Get("depth"); depthStr != "" {
+		var err error
+		depth, err = strconv.ParseUint(depthStr, 10, 64)
+		if err != nil || depth == 0 {
+			Responder(w, http.StatusBadRequest, api.ErrorResponse{Status: false, Message: ErrInvalidReferralDepth.Error()})
+			return
+		}
+	}
+
+	h.respondReferrals(w, r, userID, uint(depth))
+}
+
+// respondReferrals отправляет клиенту страницу приглашенных пользователя userID до глубины depth.
+func (h *Handler) respondReferrals(w http.ResponseWriter, r *http.Request, userID uint, depth uint) {
+	const op = "http_handlers.respondReferrals"
+
+	limit, offset, err := parsePagination(r)
+	if err != nil {
+		Responder(w, http.StatusBadRequest, api.ErrorResponse{Status: false, Message: ErrInvalidPagination.Error()})
+		return
+	}
+
+	referrals, err := h.userService.GetReferralTree(r.Context(), userID, depth, limit, offset)
+	if err != nil {
+		if errors.Is(err, services.ErrInvalidReferralDepth) {
+			Responder(w, http.StatusBadRequest, api.ErrorResponse{Status: false, Message: ErrInvalidReferralDepth.Error()})
+			return
+		}
+		log.Printf("%s: %v", op, err)
+		Responder(w, http.StatusInternalServerError, api.ErrorResponse{Status: false, Message: ErrInternalServer.Error()})
+		return
+	}
+
+	Responder(w, http.StatusOK, api.ReferralsResponse{
+		Status:    true,
+		Message:   fmt.Sprintf("Список приглашенных. Кол-во: %d", len(referrals)),
+		Referrals: referrals,
+	})
+}
+
+// respondReferralStats отправляет клиенту статистику приглашений пользователя userID.
+func (h *Handler) respondReferralStats(w http.ResponseWriter, r *http.Request, userID uint) {
+	const op = "http_handlers.respondReferralStats"
+
+	stats, err := h.userService.GetReferralStats(r.Context(), userID)
+	if err != nil {
+		log.Printf("%s: %v", op, err)
+		Responder(w, http.StatusInternalServerError, api.ErrorResponse{Status: false, Message: ErrInternalServer.Error()})
+		return
+	}
+
+	Responder(w, http.StatusOK, api.ReferralStatsResponse{
+		Status:  true,
+		Message: "Статистика приглашений",
+		Stats:   stats,
+	})
+}
@@ -3,10 +3,15 @@ package http_handlers
 import (
 	"bytes"
 	"encoding/json"
+	"errors"
+	"github.com/RVodassa/TaskReward/internal/api"
+	"github.com/RVodassa/TaskReward/internal/services"
 	"log"
 	"net/http"
 )
 
+var ErrValidation = errors.New("ошибка: проверьте заполнение полей")
+
 // Responder отправляет JSON-ответ клиенту.
 func Responder(w http.ResponseWriter, statusCode int, response interface{}) {
 	const op = "http.Respond"
@@ -31,3 +36,19 @@ func Responder(w http.ResponseWriter, statusCode int, response interface{}) {
 		return
 	}
 }
+
+// respondValidationError отправляет 400 с ошибками полей, если err - *services.ValidationError.
+// Возвращает false, если ошибка другого вида и ответ не отправлен.
+func respondValidationError(w http.ResponseWriter, err error) bool {
+	var validation *services.ValidationError
+	if !errors.As(err, &validation) {
+		return false
+	}
+
+	Responder(w, http.StatusBadRequest, api.ValidationErrorResponse{
+		Status:  false,
+		Message: ErrValidation.Error(),
+		Errors:  validation.Fields,
+	})
+	return true
+}
@@ -0,0 +1,461 @@
+package http_handlers
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"github.com/RVodassa/TaskReward/internal/api"
+	"github.com/RVodassa/TaskReward/internal/services"
+	"log"
+	"net/http"
+)
+
+var (
+	ErrInvalidRewardID    = errors.New("ошибка: некорректный reward_id")
+	ErrInvalidOrderID     = errors.New("ошибка: некорректный order_id")
+	ErrInvalidRewardData  = errors.New("ошибка: название (до 255 символов) и стоимость (больше 0) награды обязательны, описание - до 1000 символов")
+	ErrRewardNotFound     = errors.New("ошибка: награда не найдена")
+	ErrRewardOutOfStock   = errors.New("ошибка: награда закончилась")
+	ErrRewardHasOrders    = errors.New("ошибка: на награду уже есть заказы, ее можно только деактивировать")
+	ErrOrderNotFound      = errors.New("ошибка: заказ не найден")
+	ErrOrderNotPending    = errors.New("ошибка: заказ уже обработан")
+	ErrInvalidOrderStatus = errors.New("ошибка: неизвестный статус заказа, допустимо: pending, fulfilled, cancelled")
+)
+
+// GetRewards godoc
+// @Summary Каталог наград
+// @Description Возвращает активные награды, которые можно получить в обмен на баллы
+// @Tags Rewards
+// @Produce json
+// @Param limit query int false "Кол-во записей (по умолчанию 50, максимум 100)"
+// @Param offset query int false "Смещение"
+// @Success 200 {object} api.RewardsResponse "Успешно"
+// @Failure 403 {object} api.ErrorResponse "Unauthorized"
+// @Failure 400 {object} api.ErrorResponse "Ошибка клиента"
+// @Failure 500 {object} api.ErrorResponse "Ошибка на сервере"
+// @Router /rewards [get]
+// @security BearerAuth
+func (h *Handler) GetRewards(w http.ResponseWriter, r *http.Request) {
+	const op = "http_handlers.GetRewards"
+
+	limit, offset, err := parsePagination(r)
+	if err != nil {
+		Responder(w, http.StatusBadRequest, api.ErrorResponse{Status: false, Message: ErrInvalidPagination.Error()})
+		return
+	}
+
+	rewards, err := h.userService.GetRewards(r.Context(), true, limit, offset)
+	if err != nil {
+		log.Printf("%s: %v", op, err)
+		Responder(w, http.StatusInternalServerError, api.ErrorResponse{Status: false, Message: ErrInternalServer.Error()})
+		return
+	}
+
+	Responder(w, http.StatusOK, api.RewardsResponse{
+		Status:  true,
+		Message: fmt.Sprintf("Каталог наград. Кол-во наград: %d", len(rewards)),
+		Rewards: rewards,
+	})
+}
+
+// RedeemReward godoc
+// @Summary Обменять баллы на награду
+// @Description Списывает стоимость награды с баланса текущего пользователя и создает заказ в статусе pending
+// @Tags Rewards
+// @Produce json
+// @Param rewardID path string true "ID награды"
+// @Success 201 {object} api.OrderResponse "Заказ создан"
+// @Failure 403 {object} api.ErrorResponse "Unauthorized"
+// @Failure 400 {object} api.ErrorResponse "Ошибка клиента"
+// @Failure 404 {object} api.ErrorResponse "Награда не найдена"
+// @Failure 409 {object} api.ErrorResponse "Недостаточно баллов или награда закончилась"
+// @Failure 500 {object} api.ErrorResponse "Ошибка на сервере"
+// @Router /rewards/{rewardID}/redeem [post]
+// @security BearerAuth
+func (h *Handler) RedeemReward(w http.ResponseWriter, r *http.Request) {
+	const op = "http_handlers.RedeemReward"
+
+	rewardID, err := parseIDParam(r, "rewardID")
+	if err != nil {
+		Responder(w, http.StatusBadRequest, api.ErrorResponse{Status: false, Message: ErrInvalidRewardID.Error()})
+		return
+	}
+
+	order, err := h.userService.RedeemReward(r.Context(), userFromContext(r.Context()).ID, rewardID)
+	if err != nil {
+		respondOrderError(w, r, op, err)
+		return
+	}
+
+	Responder(w, http.StatusCreated, api.OrderResponse{
+		Status:  true,
+		Message: "Заказ создан",
+		Order:   order,
+	})
+}
+
+// GetMyOrders godoc
+// @Summary Заказы текущего пользователя
+// @Description Возвращает заказы наград текущего пользователя, новые первыми
+// @Tags Rewards
+// @Produce json
+// @Param status query string false "Фильтр по статусу: pending, fulfilled, cancelled"
+// @Param limit query int false "Кол-во записей (по умолчанию 50, максимум 100)"
+// @Param offset query int false "Смещение"
+// @Success 200 {object} api.OrdersResponse "Успешно"
+// @Failure 403 {object} api.ErrorResponse "Unauthorized"
+// @Failure 400 {object} api.ErrorResponse "Ошибка клиента"
+// @Failure 500 {object} api.ErrorResponse "Ошибка на сервере"
+// @Router /users/me/orders [get]
+// @security BearerAuth
+func (h *Handler) GetMyOrders(w http.ResponseWriter, r *http.Request) {
+	h.respondOrders(w, r, userFromContext(r.Context()).ID)
+}
+
+// CancelMyOrder godoc
+// @Summary Отменить свой заказ
+// @Description Отменяет заказ в статусе pending, награда возвращается на склад, баллы - на баланс
+// @Tags Rewards
+// @Produce json
+// @Param orderID path string true "ID заказа"
+// @Success 200 {object} api.OrderResponse "Успешно"
+// @Failure 403 {object} api.ErrorResponse "Unauthorized"
+// @Failure 400 {object} api.ErrorResponse "Ошибка клиента"
+// @Failure 404 {object} api.ErrorResponse "Заказ не найден"
+// @Failure 409 {object} api.ErrorResponse "Заказ уже обработан"
+// @Failure 500 {object} api.ErrorResponse "Ошибка на сервере"
+// @Router /users/me/orders/{orderID}/cancel [post]
+// @security BearerAuth
+func (h *Handler) CancelMyOrder(w http.ResponseWriter, r *http.Request) {
+	const op = "http_handlers.CancelMyOrder"
+
+	orderID, err := parseIDParam(r, "orderID")
+	if err != nil {
+		Responder(w, http.StatusBadRequest, api.ErrorResponse{Status: false, Message: ErrInvalidOrderID.Error()})
+		return
+	}
+
+	order, err := h.userService.CancelRewardOrder(r.Context(), orderID, userFromContext(r.Context()).ID)
+	if err != nil {
+		respondOrderError(w, r, op, err)
+		return
+	}
+
+	Responder(w, http.StatusOK, api.OrderResponse{
+		Status:  true,
+		Message: "Заказ отменен, баллы возвращены",
+		Order:   order,
+	})
+}
+
+// GetAllRewards godoc
+// @Summary Каталог наград (все)
+// @Description Возвращает все награды, включая неактивные
+// @Tags Admin
+// @Produce json
+// @Param limit query int false "Кол-во записей (по умолчанию 50, максимум 100)"
+// @Param offset query int false "Смещение"
+// @Success 200 {object} api.RewardsResponse "Успешно"
+// @Failure 403 {object} api.ErrorResponse "Недостаточно прав"
+// @Failure 400 {object} api.ErrorResponse "Ошибка клиента"
+// @Failure 500 {object} api.ErrorResponse "Ошибка на сервере"
+// @Router /admin/rewards [get]
+// @security BearerAuth
+func (h *Handler) GetAllRewards(w http.ResponseWriter, r *http.Request) {
+	const op = "http_handlers.GetAllRewards"
+
+	limit, offset, err := parsePagination(r)
+	if err != nil {
+		Responder(w, http.StatusBadRequest, api.ErrorResponse{Status: false, Message: ErrInvalidPagination.Error()})
+		return
+	}
+
+	rewards, err := h.adminService.GetRewards(r.Context(), false, limit, offset)
+	if err != nil {
+		log.Printf("%s: %v", op, err)
+		Responder(w, http.StatusInternalServerError, api.ErrorResponse{Status: false, Message: ErrInternalServer.Error()})
+		return
+	}
+
+	Responder(w, http.StatusOK, api.RewardsResponse{
+		Status:  true,
+		Message: fmt.Sprintf("Каталог наград. Кол-во наград: %d", len(rewards)),
+		Rewards: rewards,
+	})
+}
+
+// CreateReward godoc
+// @Summary Добавить награду
+// @Description Добавляет награду в каталог, по умолчанию награда активна
+// @Tags Admin
+// @Accept json
+// @Produce json
+// @Param request body api.CreateRewardRequest true "Награда"
+// @Success 201 {object} api.RewardResponse "Награда создана"
+// @Failure 403 {object} api.ErrorResponse "Недостаточно прав"
+// @Failure 400 {object} api.ErrorResponse "Ошибка клиента"
+// @Failure 500 {object} api.ErrorResponse "Ошибка на сервере"
+// @Router /admin/rewards [post]
+// @security BearerAuth
+func (h *Handler) CreateReward(w http.ResponseWriter, r *http.Request) {
+	const op = "http_handlers.CreateReward"
+
+	var request api.CreateRewardRequest
+	if err := json.NewDecoder(r.Body).Decode(&request); err != nil {
+		log.Printf("%s: ошибка при декодировании запроса: %v", op, err)
+		Responder(w, http.StatusBadRequest, api.ErrorResponse{Status: false, Message: ErrInvalidJSON.Error()})
+		return
+	}
+
+	active := true
+	if request.Active != nil {
+		active = *request.Active
+	}
+
+	reward, err := h.adminService.AddReward(r.Context(), request.Name, request.Description, request.Cost, request.Stock, active)
+	if err != nil {
+		if errors.Is(err, services.ErrInvalidRewardData) {
+			Responder(w, http.StatusBadRequest, api.ErrorResponse{Status: false, Message: ErrInvalidRewardData.Error()})
+			return
+		}
+		log.Printf("%s: %v", op, err)
+		Responder(w, http.StatusInternalServerError, api.ErrorResponse{Status: false, Message: ErrInternalServer.Error()})
+		return
+	}
+
+	Responder(w, http.StatusCreated, api.RewardResponse{
+		Status:  true,
+		Message: "Награда добавлена",
+		Reward:  reward,
+	})
+}
+
+// UpdateReward godoc
+// @Summary Изменить награду
+// @Description Изменяет награду. Не переданные поля остаются без изменений.
+// @Tags Admin
+// @Accept json
+// @Produce json
+// @Param rewardID path string true "ID награды"
+// @Param request body api.UpdateRewardRequest true "Изменяемые поля"
+// @Success 200 {object} api.RewardResponse "Успешно"
+// @Failure 403 {object} api.ErrorResponse "Недостаточно прав"
+// @Failure 400 {object} api.ErrorResponse "Ошибка клиента"
+// @Failure 404 {object} api.ErrorResponse "Награда не найдена"
+// @Failure 500 {object} api.ErrorResponse "Ошибка на сервере"
+// @Router /admin/rewards/{rewardID} [patch]
+// @security BearerAuth
+func (h *Handler) UpdateReward(w http.ResponseWriter, r *http.Request) {
+	const op = "http_handlers.UpdateReward"
+
+	rewardID, err := parseIDParam(r, "rewardID")
+	if err != nil {
+		Responder(w, http.StatusBadRequest, api.ErrorResponse{Status: false, Message: ErrInvalidRewardID.Error()})
+		return
+	}
+
+	var request api.UpdateRewardRequest
+	if err = json.NewDecoder(r.Body).Decode(&request); err != nil {
+		log.Printf("%s: ошибка при декодировании запроса: %v", op, err)
+		Responder(w, http.StatusBadRequest, api.ErrorResponse{Status: false, Message: ErrInvalidJSON.Error()})
+		return
+	}
+
+	reward, err := h.adminService.UpdateReward(r.Context(), rewardID, request.Name, request.Description, request.Cost, request.Stock, request.Active)
+	if err != nil {
+		switch {
+		case errors.Is(err, services.ErrRewardNotFound):
+			Responder(w, http.StatusNotFound, api.ErrorResponse{Status: false, Message: ErrRewardNotFound.Error()})
+		case errors.Is(err, services.ErrInvalidRewardData):
+			Responder(w, http.StatusBadRequest, api.ErrorResponse{Status: false, Message: ErrInvalidRewardData.Error()})
+		default:
+			log.Printf("%s: %v", op, err)
+			Responder(w, http.StatusInternalServerError, api.ErrorResponse{Status: false, Message: ErrInternalServer.Error()})
+		}
+		return
+	}
+
+	Responder(w, http.StatusOK, api.RewardResponse{
+		Status:  true,
+		Message: "Награда обновлена",
+		Reward:  reward,
+	})
+}
+
+// DeleteReward godoc
+// @Summary Удалить награду
+// @Description Удаляет награду, на которую еще не было заказов
+// @Tags Admin
+// @Produce json
+// @Param rewardID path string true "ID награды"
+// @Success 200 {object} api.MessageResponse "Успешно"
+// @Failure 403 {object} api.ErrorResponse "Недостаточно прав"
+// @Failure 400 {object} api.ErrorResponse "Ошибка клиента"
+// @Failure 404 {object} api.ErrorResponse "Награда не найдена"
+// @Failure 409 {object} api.ErrorResponse "На награду уже есть заказы"
+// @Failure 500 {object} api.ErrorResponse "Ошибка на сервере"
+// @Router /admin/rewards/{rewardID} [delete]
+// @security BearerAuth
+func (h *Handler) DeleteReward(w http.ResponseWriter, r *http.Request) {
+	const op = "http_handlers.DeleteReward"
+
+	rewardID, err := parseIDParam(r, "rewardID")
+	if err != nil {
+		Responder(w, http.StatusBadRequest, api.ErrorResponse{Status: false, Message: ErrInvalidRewardID.Error()})
+		return
+	}
+
+	if err = h.adminService.DeleteReward(r.Context(), rewardID); err != nil {
+		switch {
+		case errors.Is(err, services.ErrRewardNotFound):
+			Responder(w, http.StatusNotFound, api.ErrorResponse{Status: false, Message: ErrRewardNotFound.Error()})
+		case errors.Is(err, services.ErrRewardHasOrders):
+			Responder(w, http.StatusConflict, api.ErrorResponse{Status: false, Message: ErrRewardHasOrders.Error()})
+		default:
+			log.Printf("%s: %v", op, err)
+			Responder(w, http.StatusInternalServerError, api.ErrorResponse{Status: false, Message: ErrInternalServer.Error()})
+		}
+		return
+	}
+
+	Responder(w, http.StatusOK, api.MessageResponse{Status: true, Message: "Награда удалена"})
+}
+
+// GetAllOrders godoc
+// @Summary Заказы наград всех пользователей
+// @Description Возвращает заказы наград, новые первыми
+// @Tags Admin
+// @Produce json
+// @Param status query string false "Фильтр по статусу: pending, fulfilled, cancelled"
+// @Param limit query int false "Кол-во записей (по умолчанию 50, максимум 100)"
+// @Param offset query int false "Смещение"
+// @Success 200 {object} api.OrdersResponse "Успешно"
+// @Failure 403 {object} api.ErrorResponse "Недостаточно прав"
+// @Failure 400 {object} api.ErrorResponse "Ошибка клиента"
+// @Failure 500 {object} api.ErrorResponse "Ошибка на сервере"
+// @Router /admin/orders [get]
+// @security BearerAuth
+func (h *Handler) GetAllOrders(w http.ResponseWriter, r *http.Request) {
+	h.respondOrders(w, r, 0)
+}
+
+// FulfillOrder godoc
+// @Summary Выдать заказ
+// @Description Переводит заказ из статуса pending в fulfilled
+// @Tags Admin
+// @Produce json
+// @Param orderID path string true "ID заказа"
+// @Success 200 {object} api.OrderResponse "Успешно"
+// @Failure 403 {object} api.ErrorResponse "Недостаточно прав"
+// @Failure 400 {object} api.ErrorResponse "Ошибка клиента"
+// @Failure 404 {object} api.ErrorResponse "Заказ не найден"
+// @Failure 409 {object} api.ErrorResponse "Заказ уже обработан"
+// @Failure 500 {object} api.ErrorResponse "Ошибка на сервере"
+// @Router /admin/orders/{orderID}/fulfill [post]
+// @security BearerAuth
+func (h *Handler) FulfillOrder(w http.ResponseWriter, r *http.Request) {
+	const op = "http_handlers.FulfillOrder"
+
+	orderID, err := parseIDParam(r, "orderID")
+	if err != nil {
+		Responder(w, http.StatusBadRequest, api.ErrorResponse{Status: false, Message: ErrInvalidOrderID.Error()})
+		return
+	}
+
+	order, err := h.adminService.FulfillRewardOrder(r.Context(), orderID)
+	if err != nil {
+		respondOrderError(w, r, op, err)
+		return
+	}
+
+	Responder(w, http.StatusOK, api.OrderResponse{
+		Status:  true,
+		Message: "Заказ выдан",
+		Order:   order,
+	})
+}
+
+// CancelOrder godoc
+// @Summary Отменить заказ
+// @Description Отменяет заказ в статусе pending, награда возвращается на склад, баллы - пользователю
+// @Tags Admin
+// @Produce json
+// @Param orderID path string true "ID заказа"
+// @Success 200 {object} api.OrderResponse "Успешно"
+// @Failure 403 {object} api.ErrorResponse "Недостаточно прав"
+// @Failure 400 {object} api.ErrorResponse "Ошибка клиента"
+// @Failure 404 {object} api.ErrorResponse "Заказ не найден"
+// @Failure 409 {object} api.ErrorResponse "Заказ уже обработан"
+// @Failure 500 {object} api.ErrorResponse "Ошибка на сервере"
+// @Router /admin/orders/{orderID}/cancel [post]
+// @security BearerAuth
+func (h *Handler) CancelOrder(w http.ResponseWriter, r *http.Request) {
+	const op = "http_handlers.CancelOrder"
+
+	orderID, err := parseIDParam(r, "orderID")
+	if err != nil {
+		Responder(w, http.StatusBadRequest, api.ErrorResponse{Status: false, Message: ErrInvalidOrderID.Error()})
+		return
+	}
+
+	order, err := h.adminService.CancelRewardOrder(r.Context(), orderID, 0)
+	if err != nil {
+		respondOrderError(w, r, op, err)
+		return
+	}
+
+	Responder(w, http.StatusOK, api.OrderResponse{
+		Status:  true,
+		Message: "Заказ отменен, баллы возвращены",
+		Order:   order,
+	})
+}
+
+// respondOrders отправляет клиенту страницу заказов пользователя userID (0 - всех пользователей).
+func (h *Handler) respondOrders(w http.ResponseWriter, r *http.Request, userID uint) {
+	const op = "http_handlers.respondOrders"
+
+	limit, offset, err := parsePagination(r)
+	if err != nil {
+		Responder(w, http.StatusBadRequest, api.ErrorResponse{Status: false, Message: ErrInvalidPagination.Error()})
+		return
+	}
+
+	orders, err := h.userService.GetRewardOrders(r.Context(), userID, r.URL.Query().Get("status"), limit, offset)
+	if err != nil {
+		if errors.Is(err, services.ErrInvalidOrderStatus) {
+			Responder(w, http.StatusBadRequest, api.ErrorResponse{Status: false, Message: ErrInvalidOrderStatus.Error()})
+			return
+		}
+		log.Printf("%s: %v", op, err)
+		Responder(w, http.StatusInternalServerError, api.ErrorResponse{Status: false, Message: ErrInternalServer.Error()})
+		return
+	}
+
+	Responder(w, http.StatusOK, api.OrdersResponse{
+		Status:  true,
+		Message: fmt.Sprintf("Список заказов. Кол-во заказов: %d", len(orders)),
+		Orders:  orders,
+	})
+}
+
+// respondOrderError отправляет клиенту ответ, соответствующий ошибке обмена баллов или обработки заказа.
+func respondOrderError(w http.ResponseWriter, r *http.Request, op string, err error) {
+	switch {
+	case errors.Is(err, services.ErrRewardNotFound):
+		Responder(w, http.StatusNotFound, api.ErrorResponse{Status: false, Message: ErrRewardNotFound.Error()})
+	case errors.Is(err, services.ErrOrderNotFound):
+		Responder(w, http.StatusNotFound, api.ErrorResponse{Status: false, Message: ErrOrderNotFound.Error()})
+	case errors.Is(err, services.ErrUserNotFound):
+		Responder(w, http.StatusNotFound, api.ErrorResponse{Status: false, Message: ErrUserNotFound.Error()})
+	case errors.Is(err, services.ErrRewardOutOfStock):
+		Responder(w, http.StatusConflict, api.ErrorResponse{Status: false, Message: ErrRewardOutOfStock.Error()})
+	case errors.Is(err, services.ErrInsufficientFunds):
+		Responder(w, http.StatusConflict, api.ErrorResponse{Status: false, Message: ErrInsufficientFunds.Error()})
+	case errors.Is(err, services.ErrOrderNotPending):
+		Responder(w, http.StatusConflict, api.ErrorResponse{Status: false, Message: ErrOrderNotPending.Error()})
+	default:
+		log.Printf("%s %s %v", op, r.URL, err)
+		Responder(w, http.StatusInternalServerError, api.ErrorResponse{Status: false, Message: ErrInternalServer.Error()})
+	}
+}
@@ -1,38 +1,130 @@
 package http_handlers
 
 import (
-	"github.com/RVodassa/TaskReward/internal/services/auth"
+	"github.com/RVodassa/TaskReward/internal/domain/models"
 	"github.com/go-chi/chi/v5"
 	"github.com/go-chi/jwtauth/v5"
 	httpSwagger "github.com/swaggo/http-swagger"
-	"log"
-	"os"
 )
 
 func NewRouter(controller *Handler) *chi.Mux {
-	const op = "http_handlers.NewRouter"
-
-	jwtAuth, err := auth.InitJWTAuth([]byte(os.Getenv("JWT_SECRET")))
-	if err != nil {
-		log.Printf("%s %s", op, err)
-	}
-
 	r := chi.NewRouter()
 
+	// Открытые ключи проверки access токенов для других сервисов
+	r.Get("/.well-known/jwks.json", controller.JWKS)
+
 	// Публичные маршруты (без авторизации)
 	r.Route("/auth", func(r chi.Router) {
 		r.Post("/register", controller.Register)
 		r.Post("/login", controller.Login)
+		r.Post("/refresh", controller.Refresh)
+		r.Post("/2fa/verify", controller.VerifyTwoFactor)
+		r.Post("/password/forgot", controller.ForgotPassword)
+		r.Post("/password/reset", controller.ResetPassword)
+		r.Post("/email/verify", controller.VerifyEmail)
+		r.Group(func(r chi.Router) {
+			r.Use(controller.Verifier)
+			r.Use(jwtauth.Authenticator(nil)) // проверяет результат Verifier из контекста, JWTAuth не нужен
+			r.Use(controller.UserCtx)
+			r.Post("/logout", controller.Logout)
+			r.Post("/logout-all", controller.LogoutAll)
+		})
 	})
-	r.Group(func(r chi.Router) {
-		r.Use(jwtauth.Verifier(jwtAuth))       // Извлекает токен из запроса
-		r.Use(jwtauth.Authenticator(jwtAuth))  // Проверяет токен
-		r.Route("/users", func(r chi.Router) { //
-			r.Get("/{userID}/status", controller.StatusUser)
-			r.Post("/{userID}/tasks/{taskID}/complete", controller.TaskComplete)
+	r.Route("/users", func(r chi.Router) {
+		r.Group(func(r chi.Router) {
+			r.Use(controller.Verifier)        // Извлекает токен из запроса
+			r.Use(jwtauth.Authenticator(nil)) // Проверяет токен
+			r.Use(controller.UserCtx)         // Загружает пользователя из токена
+			r.Get("/me/status", controller.StatusMe)
+			r.Post("/me/password", controller.ChangePassword)
+			r.Put("/me/email", controller.UpdateMyEmail)
+			r.Put("/me/profile", controller.UpdateMyProfile)
+			r.Post("/me/email/verification", controller.ResendEmailVerification)
+			r.Post("/me/2fa/enroll", controller.EnrollTOTP)
+			r.Post("/me/2fa/confirm", controller.ConfirmTOTP)
+			r.Post("/me/2fa/disable", controller.DisableTOTP)
+			r.Post("/me/tasks/{taskID}/complete", controller.TaskCompleteMe)
+			r.Get("/me/transactions", controller.GetMyTransactions)
+			r.Get("/me/orders", controller.GetMyOrders)
+			r.Post("/me/orders/{orderID}/cancel", controller.CancelMyOrder)
+			r.Get("/me/referrals", controller.GetMyReferrals)
+			r.Get("/me/referrals/tree", controller.GetMyReferralTree)
+			r.Get("/me/referrals/stats", controller.GetMyReferralStats)
+			r.Get("/me/referral-codes", controller.GetMyReferralCodes)
+			r.Post("/me/referral-codes", controller.CreateMyReferralCode)
+			r.Post("/me/referral-codes/{codeID}/deactivate", controller.DeactivateMyReferralCode)
+			r.Get("/{userID}/referrals", controller.GetUserReferrals)
+			r.Get("/{userID}/referrals/tree", controller.GetUserReferralTree)
+			r.Get("/{userID}/referrals/stats", controller.GetUserReferralStats)
 			r.Get("/leaderboard", controller.LeaderBoard)
+			r.Get("/me/rank", controller.MyRank)
 			r.Get("/tasks/activetasks", controller.GetAllActiveTask)
 		})
+		// Маршруты, доступные также другим сервисам по API ключу с соответствующими правами
+		r.Group(func(r chi.Router) {
+			r.Use(controller.APIKeyCtx)
+			r.With(RequireAccess(models.ScopeUsersRead)).Get("/{userID}/status", controller.StatusUser)
+			r.With(RequireAccess(models.ScopeUsersRead)).Get("/{userID}/transactions", controller.GetUserTransactions)
+			r.With(RequireAccess(models.ScopeTasksComplete)).Post("/{userID}/tasks/{taskID}/complete", controller.TaskComplete)
+		})
+	})
+	r.Group(func(r chi.Router) {
+		r.Use(controller.Verifier)
+		r.Use(jwtauth.Authenticator(nil))
+		r.Use(controller.UserCtx)
+		r.Route("/rewards", func(r chi.Router) {
+			r.Get("/", controller.GetRewards)
+			r.Post("/{rewardID}/redeem", controller.RedeemReward)
+		})
+		r.Route("/seasons", func(r chi.Router) {
+			r.Get("/", controller.GetSeasons)
+			r.Get("/{seasonID}/standings", controller.GetSeasonStandings)
+		})
+	})
+
+	// Маршруты администрирования, доступ определяется ролью пользователя или правами API ключа
+	r.Group(func(r chi.Router) {
+		r.Use(controller.APIKeyCtx)
+		r.Route("/admin", func(r chi.Router) {
+			r.Route("/tasks", func(r chi.Router) {
+				r.With(RequireAccess(models.ScopeTasksRead, models.RoleAdmin, models.RoleModerator)).Get("/", controller.GetAllTasks)
+				r.With(RequireAccess(models.ScopeTasksWrite, models.RoleAdmin)).Post("/", controller.CreateTask)
+				r.With(RequireAccess(models.ScopeTasksWrite, models.RoleAdmin, models.RoleModerator)).Patch("/{taskID}", controller.UpdateTask)
+				r.With(RequireAccess(models.ScopeTasksWrite, models.RoleAdmin, models.RoleModerator)).Post("/{taskID}/archive", controller.ArchiveTask)
+				r.With(RequireAccess(models.ScopeTasksWrite, models.RoleAdmin)).Delete("/{taskID}", controller.DeleteTask)
+			})
+			r.Route("/seasons", func(r chi.Router) {
+				r.Use(RequireRole(models.RoleAdmin))
+				r.Post("/", controller.CreateSeason)
+				r.Post("/{seasonID}/close", controller.CloseSeason)
+			})
+			r.Route("/api-keys", func(r chi.Router) {
+				r.Use(RequireRole(models.RoleAdmin))
+				r.Get("/", controller.GetAPIKeys)
+				r.Post("/", controller.CreateAPIKey)
+				r.Post("/{keyID}/revoke", controller.RevokeAPIKey)
+			})
+			r.Route("/rewards", func(r chi.Router) {
+				r.Use(RequireRole(models.RoleAdmin))
+				r.Get("/", controller.GetAllRewards)
+				r.Post("/", controller.CreateReward)
+				r.Patch("/{rewardID}", controller.UpdateReward)
+				r.Delete("/{rewardID}", controller.DeleteReward)
+			})
+			r.Route("/orders", func(r chi.Router) {
+				r.Use(RequireRole(models.RoleAdmin, models.RoleModerator))
+				r.Get("/", controller.GetAllOrders)
+				r.Post("/{orderID}/fulfill", controller.FulfillOrder)
+				r.Post("/{orderID}/cancel", controller.CancelOrder)
+			})
+			r.With(RequireRole(models.RoleAdmin)).Put("/users/{userID}/role", controller.SetUserRole)
+			r.With(RequireRole(models.RoleAdmin)).Post("/users/{userID}/ban", controller.BanUser)
+			r.With(RequireRole(models.RoleAdmin)).Post("/users/{userID}/unban", controller.UnbanUser)
+			r.With(RequireRole(models.RoleAdmin)).Post("/users/{userID}/unlock", controller.UnlockUser)
+			r.With(RequireRole(models.RoleAdmin)).Post("/users/{userID}/leaderboard/exclude", controller.ExcludeFromLeaderboard)
+			r.With(RequireRole(models.RoleAdmin)).Post("/users/{userID}/leaderboard/include", controller.IncludeInLeaderboard)
+			r.With(RequireAccess(models.ScopeBalanceWrite, models.RoleAdmin)).Post("/users/{userID}/balance", controller.AdjustBalance)
+		})
 	})
 
 	// Маршрут для Swagger UI (публичный)
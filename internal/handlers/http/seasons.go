@@ -0,0 +1,195 @@
+package http_handlers
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"github.com/RVodassa/TaskReward/internal/api"
+	"github.com/RVodassa/TaskReward/internal/services"
+	"log"
+	"net/http"
+)
+
+var (
+	ErrInvalidSeasonData = errors.New("ошибка: укажите название (до 100 символов), дату окончания позже даты начала и в будущем, не больше 100 призов")
+	ErrInvalidSeasonID   = errors.New("ошибка: некорректный season_id")
+	ErrSeasonNotFound    = errors.New("ошибка: сезон не найден")
+	ErrSeasonOverlap     = errors.New("ошибка: сезон пересекается с другим сезоном")
+	ErrSeasonClosed      = errors.New("ошибка: итоги сезона уже подведены")
+	ErrSeasonNotEnded    = errors.New("ошибка: сезон еще не завершился")
+)
+
+// GetSeasons godoc
+// @Summary Список сезонов
+// @Description Возвращает сезоны, последние первыми, со статусом: upcoming - не начался, active - идет, ended - завершился, closed - итоги подведены
+// @Tags Seasons
+// @Produce json
+// @Param limit query int false "Кол-во записей (по умолчанию 50, максимум 100)"
+// @Param offset query int false "Смещение"
+// @Success 200 {object} api.SeasonsResponse "Успешно"
+// @Failure 403 {object} api.ErrorResponse "Unauthorized"
+// @Failure 400 {object} api.ErrorResponse "Ошибка клиента"
+// @Failure 500 {object} api.ErrorResponse "Ошибка на сервере"
+// @Router /seasons [get]
+// @security BearerAuth
+func (h *Handler) GetSeasons(w http.ResponseWriter, r *http.Request) {
+	const op = "http_handlers.GetSeasons"
+
+	limit, offset, err := parsePagination(r)
+	if err != nil {
+		Responder(w, http.StatusBadRequest, api.ErrorResponse{Status: false, Message: ErrInvalidPagination.Error()})
+		return
+	}
+
+	seasons, err := h.userService.GetSeasons(r.Context(), limit, offset)
+	if err != nil {
+		log.Printf("%s: %v", op, err)
+		Responder(w, http.StatusInternalServerError, api.ErrorResponse{Status: false, Message: ErrInternalServer.Error()})
+		return
+	}
+
+	Responder(w, http.StatusOK, api.SeasonsResponse{
+		Status:  true,
+		Message: fmt.Sprintf("Сезоны. Кол-во: %d", len(seasons)),
+		Seasons: seasons,
+	})
+}
+
+// GetSeasonStandings godoc
+// @Summary Таблица сезона
+// @Description Возвращает сезон и его таблицу: для закрытого сезона - сохраненные итоги с призами, для остальных - текущие баллы. Пользователи с одинаковыми баллами делят место.
+// @Tags Seasons
+// @Produce json
+// @Param seasonID path string true "ID сезона"
+// @Param limit query int false "Кол-во записей (по умолчанию 50, максимум 100)"
+// @Param offset query int false "Смещение"
+// @Success 200 {object} api.SeasonStandingsResponse "Успешно"
+// @Failure 403 {object} api.ErrorResponse "Unauthorized"
+// @Failure 400 {object} api.ErrorResponse "Ошибка клиента"
+// @Failure 404 {object} api.ErrorResponse "Сезон не найден"
+// @Failure 500 {object} api.ErrorResponse "Ошибка на сервере"
+// @Router /seasons/{seasonID}/standings [get]
+// @security BearerAuth
+func (h *Handler) GetSeasonStandings(w http.ResponseWriter, r *http.Request) {
+	const op = "http_handlers.GetSeasonStandings"
+
+	seasonID, err := parseIDParam(r, "seasonID")
+	if err != nil {
+		Responder(w, http.StatusBadRequest, api.ErrorResponse{Status: false, Message: ErrInvalidSeasonID.Error()})
+		return
+	}
+
+	limit, offset, err := parsePagination(r)
+	if err != nil {
+		Responder(w, http.StatusBadRequest, api.ErrorResponse{Status: false, Message: ErrInvalidPagination.Error()})
+		return
+	}
+
+	season, standings, err := h.userService.GetSeasonStandings(r.Context(), seasonID, limit, offset)
+	if err != nil {
+		if errors.Is(err, services.ErrSeasonNotFound) {
+			Responder(w, http.StatusNotFound, api.ErrorResponse{Status: false, Message: ErrSeasonNotFound.Error()})
+			return
+		}
+		log.Printf("%s: %v", op, err)
+		Responder(w, http.StatusInternalServerError, api.ErrorResponse{Status: false, Message: ErrInternalServer.Error()})
+		return
+	}
+
+	Responder(w, http.StatusOK, api.SeasonStandingsResponse{
+		Status:    true,
+		Message:   fmt.Sprintf("Таблица сезона %q. Кол-во: %d", season.Name, len(standings)),
+		Season:    season,
+		Standings: standings,
+	})
+}
+
+// CreateSeason godoc
+// @Summary Создать сезон
+// @Description Создает сезон. За задачи, выполненные с starts_at по ends_at, пользователи получают баллы сезона отдельно от баланса. prizes - призы за места, начисляемые на баланс при закрытии сезона: первый элемент - за 1 место и т.д. Сезоны не могут пересекаться.
+// @Tags Admin
+// @Accept json
+// @Produce json
+// @Param request body api.CreateSeasonRequest true "Название, даты начала и окончания, призы"
+// @Success 201 {object} api.SeasonResponse "Сезон создан"
+// @Failure 403 {object} api.ErrorResponse "Недостаточно прав"
+// @Failure 400 {object} api.ErrorResponse "Ошибка клиента"
+// @Failure 409 {object} api.ErrorResponse "Сезон пересекается с другим"
+// @Failure 500 {object} api.ErrorResponse "Ошибка на сервере"
+// @Router /admin/seasons [post]
+// @security BearerAuth
+func (h *Handler) CreateSeason(w http.ResponseWriter, r *http.Request) {
+	const op = "http_handlers.CreateSeason"
+
+	var request api.CreateSeasonRequest
+	if err := json.NewDecoder(r.Body).Decode(&request); err != nil {
+		Responder(w, http.StatusBadRequest, api.ErrorResponse{Status: false, Message: ErrInvalidJSON.Error()})
+		return
+	}
+
+	season, err := h.adminService.CreateSeason(r.Context(), request.Name, request.StartsAt, request.EndsAt, request.Prizes)
+	if err != nil {
+		switch {
+		case errors.Is(err, services.ErrInvalidSeasonData):
+			Responder(w, http.StatusBadRequest, api.ErrorResponse{Status: false, Message: ErrInvalidSeasonData.Error()})
+		case errors.Is(err, services.ErrSeasonOverlap):
+			Responder(w, http.StatusConflict, api.ErrorResponse{Status: false, Message: ErrSeasonOverlap.Error()})
+		default:
+			log.Printf("%s: %v", op, err)
+			Responder(w, http.StatusInternalServerError, api.ErrorResponse{Status: false, Message: ErrInternalServer.Error()})
+		}
+		return
+	}
+
+	Responder(w, http.StatusCreated, api.SeasonResponse{
+		Status:  true,
+		Message: "Сезон создан",
+		Season:  season,
+	})
+}
+
+// CloseSeason godoc
+// @Summary Подвести итоги сезона
+// @Description Сохраняет итоговую таблицу завершившегося сезона и начисляет призы за места на баланс. Итоги подводятся один раз.
+// @Tags Admin
+// @Produce json
+// @Param seasonID path string true "ID сезона"
+// @Success 200 {object} api.SeasonResponse "Успешно"
+// @Failure 403 {object} api.ErrorResponse "Недостаточно прав"
+// @Failure 400 {object} api.ErrorResponse "Ошибка клиента"
+// @Failure 404 {object} api.ErrorResponse "Сезон не найден"
+// @Failure 409 {object} api.ErrorResponse "Сезон еще идет или итоги уже подведены"
+// @Failure 500 {object} api.ErrorResponse "Ошибка на сервере"
+// @Router /admin/seasons/{seasonID}/close [post]
+// @security BearerAuth
+func (h *Handler) CloseSeason(w http.ResponseWriter, r *http.Request) {
+	const op = "http_handlers.CloseSeason"
+
+	seasonID, err := parseIDParam(r, "seasonID")
+	if err != nil {
+		Responder(w, http.StatusBadRequest, api.ErrorResponse{Status: false, Message: ErrInvalidSeasonID.Error()})
+		return
+	}
+
+	season, err := h.adminService.CloseSeason(r.Context(), seasonID, userFromContext(r.Context()).ID)
+	if err != nil {
+		switch {
+		case errors.Is(err, services.ErrSeasonNotFound):
+			Responder(w, http.StatusNotFound, api.ErrorResponse{Status: false, Message: ErrSeasonNotFound.Error()})
+		case errors.Is(err, services.ErrSeasonClosed):
+			Responder(w, http.StatusConflict, api.ErrorResponse{Status: false, Message: ErrSeasonClosed.Error()})
+		case errors.Is(err, services.ErrSeasonNotEnded):
+			Responder(w, http.StatusConflict, api.ErrorResponse{Status: false, Message: ErrSeasonNotEnded.Error()})
+		default:
+			log.Printf("%s: %v", op, err)
+			Responder(w, http.StatusInternalServerError, api.ErrorResponse{Status: false, Message: ErrInternalServer.Error()})
+		}
+		return
+	}
+
+	Responder(w, http.StatusOK, api.SeasonResponse{
+		Status:  true,
+		Message: "Итоги сезона подведены, призы начислены",
+		Season:  season,
+	})
+}
@@ -0,0 +1,127 @@
+package http_handlers
+
+import (
+	"encoding/json"
+	"errors"
+	"github.com/RVodassa/TaskReward/internal/api"
+	"github.com/RVodassa/TaskReward/internal/services"
+	"github.com/go-chi/jwtauth/v5"
+	"io"
+	"log"
+	"net/http"
+)
+
+var ErrPasswordsRequired = errors.New("ошибка: текущий и новый пароль обязательны")
+
+// Logout godoc
+// @Summary Выход
+// @Description Отзывает текущий access токен. Если передан refresh токен, отзываются и все refresh токены этого входа.
+// @Tags auth
+// @Accept json
+// @Produce json
+// @Param request body api.LogoutRequest false "Refresh токен текущего входа"
+// @Success 200 {object} api.MessageResponse "Успешно"
+// @Failure 401 {object} api.ErrorResponse "Unauthorized"
+// @Failure 400 {object} api.ErrorResponse "Ошибка клиента"
+// @Failure 500 {object} api.ErrorResponse "Ошибка на сервере"
+// @Router /auth/logout [post]
+// @security BearerAuth
+func (h *Handler) Logout(w http.ResponseWriter, r *http.Request) {
+	const op = "http_handlers.Logout"
+
+	var request api.LogoutRequest
+	if err := json.NewDecoder(r.Body).Decode(&request); err != nil && !errors.Is(err, io.EOF) {
+		Responder(w, http.StatusBadRequest, api.ErrorResponse{Status: false, Message: ErrInvalidJSON.Error()})
+		return
+	}
+
+	token, _, err := jwtauth.FromContext(r.Context())
+	if err != nil || token == nil {
+		Responder(w, http.StatusUnauthorized, api.ErrorResponse{Status: false, Message: ErrUnauthorized.Error()})
+		return
+	}
+
+	userID := userFromContext(r.Context()).ID
+	if err = h.userService.Logout(r.Context(), userID, token.JwtID(), token.Expiration(), request.RefreshToken); err != nil {
+		log.Printf("%s: %v", op, err)
+		Responder(w, http.StatusInternalServerError, api.ErrorResponse{Status: false, Message: ErrInternalServer.Error()})
+		return
+	}
+
+	Responder(w, http.StatusOK, api.MessageResponse{Status: true, Message: "Выход выполнен"})
+}
+
+// LogoutAll godoc
+// @Summary Выход на всех устройствах
+// @Description Отзывает все access и refresh токены текущего пользователя
+// @Tags auth
+// @Produce json
+// @Success 200 {object} api.MessageResponse "Успешно"
+// @Failure 401 {object} api.ErrorResponse "Unauthorized"
+// @Failure 500 {object} api.ErrorResponse "Ошибка на сервере"
+// @Router /auth/logout-all [post]
+// @security BearerAuth
+func (h *Handler) LogoutAll(w http.ResponseWriter, r *http.Request) {
+	const op = "http_handlers.LogoutAll"
+
+	if err := h.userService.LogoutAll(r.Context(), userFromContext(r.Context()).ID); err != nil {
+		log.Printf("%s: %v", op, err)
+		Responder(w, http.StatusInternalServerError, api.ErrorResponse{Status: false, Message: ErrInternalServer.Error()})
+		return
+	}
+
+	Responder(w, http.StatusOK, api.MessageResponse{Status: true, Message: "Выход выполнен на всех устройствах"})
+}
+
+// ChangePassword godoc
+// @Summary Смена пароля
+// @Description Меняет пароль текущего пользователя. Все выданные токены, включая текущий, становятся недействительными.
+// @Tags auth
+// @Accept json
+// @Produce json
+// @Param request body api.ChangePasswordRequest true "Текущий и новый пароль"
+// @Success 200 {object} api.MessageResponse "Успешно"
+// @Failure 401 {object} api.ErrorResponse "Неверный текущий пароль"
+// @Failure 400 {object} api.ValidationErrorResponse "Новый пароль не соответствует требованиям"
+// @Failure 500 {object} api.ErrorResponse "Ошибка на сервере"
+// @Router /users/me/password [post]
+// @security BearerAuth
+func (h *Handler) ChangePassword(w http.ResponseWriter, r *http.Request) {
+	const op = "http_handlers.ChangePassword"
+
+	var request api.ChangePasswordRequest
+	if err := json.NewDecoder(r.Body).Decode(&request); err != nil {
+		Responder(w, http.StatusBadRequest, api.ErrorResponse{Status: false, Message: ErrInvalidJSON.Error()})
+		return
+	}
+
+	err := h.userService.ChangePassword(r.Context(), userFromContext(r.Context()).ID, request.OldPassword, request.NewPassword)
+	if err != nil {
+		if respondValidationError(w, err) {
+			return
+		}
+		switch {
+		case errors.Is(err, services.ErrCredentialsRequired):
+			Responder(w, http.StatusBadRequest, api.ErrorResponse{Status: false, Message: ErrPasswordsRequired.Error()})
+		case errors.Is(err, services.ErrIncorrectPassword):
+			Responder(w, http.StatusUnauthorized, api.ErrorResponse{Status: false, Message: ErrIncorrectPassword.Error()})
+		default:
+			log.Printf("%s: %v", op, err)
+			Responder(w, http.StatusInternalServerError, api.ErrorResponse{Status: false, Message: ErrInternalServer.Error()})
+		}
+		return
+	}
+
+	Responder(w, http.StatusOK, api.MessageResponse{Status: true, Message: "Пароль изменен, выполните вход заново"})
+}
+
+// JWKS godoc
+// @Summary Ключи проверки токенов
+// @Description Возвращает открытые ключи (JWKS), которыми другие сервисы проверяют access токены по kid из заголовка токена
+// @Tags auth
+// @Produce json
+// @Success 200 {object} object "JWKS"
+// @Router /.well-known/jwks.json [get]
+func (h *Handler) JWKS(w http.ResponseWriter, r *http.Request) {
+	Responder(w, http.StatusOK, h.tokens.PublicKeys())
+}
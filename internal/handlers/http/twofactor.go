@@ -0,0 +1,214 @@
+package http_handlers
+
+import (
+	"encoding/json"
+	"errors"
+	"github.com/RVodassa/TaskReward/internal/api"
+	"github.com/RVodassa/TaskReward/internal/services"
+	"log"
+	"math"
+	"net/http"
+	"strconv"
+	"time"
+)
+
+var (
+	ErrTOTPAlreadyEnabled  = errors.New("ошибка: двухфакторная аутентификация уже включена")
+	ErrTOTPNotEnrolled     = errors.New("ошибка: сначала получите секрет в /users/me/2fa/enroll")
+	ErrTOTPNotEnabled      = errors.New("ошибка: двухфакторная аутентификация не включена")
+	ErrInvalidTOTPCode     = errors.New("ошибка: неверный или уже использованный код")
+	ErrInvalidChallenge    = errors.New("ошибка: токен второго шага недействителен или истек, выполните вход заново")
+	ErrTOTPCodeRequired    = errors.New("ошибка: код обязателен")
+	ErrTOTPDisableRequired = errors.New("ошибка: пароль и код обязательны")
+)
+
+// EnrollTOTP godoc
+// @Summary Подключить двухфакторную аутентификацию
+// @Description Создает секрет TOTP и URI для приложения-аутентификатора. 2FA включается после подтверждения кодом в /users/me/2fa/confirm.
+// @Tags 2FA
+// @Produce json
+// @Success 200 {object} api.TOTPEnrollmentResponse "Успешно"
+// @Failure 403 {object} api.ErrorResponse "Unauthorized"
+// @Failure 409 {object} api.ErrorResponse "2FA уже включена"
+// @Failure 500 {object} api.ErrorResponse "Ошибка на сервере"
+// @Router /users/me/2fa/enroll [post]
+// @security BearerAuth
+func (h *Handler) EnrollTOTP(w http.ResponseWriter, r *http.Request) {
+	const op = "http_handlers.EnrollTOTP"
+
+	enrollment, err := h.userService.EnrollTOTP(r.Context(), userFromContext(r.Context()).ID)
+	if err != nil {
+		if errors.Is(err, services.ErrTOTPAlreadyEnabled) {
+			Responder(w, http.StatusConflict, api.ErrorResponse{Status: false, Message: ErrTOTPAlreadyEnabled.Error()})
+			return
+		}
+		log.Printf("%s: %v", op, err)
+		Responder(w, http.StatusInternalServerError, api.ErrorResponse{Status: false, Message: ErrInternalServer.Error()})
+		return
+	}
+
+	Responder(w, http.StatusOK, api.TOTPEnrollmentResponse{
+		Status:     true,
+		Message:    "Добавьте секрет в приложение-аутентификатор и подтвердите кодом",
+		Enrollment: enrollment,
+	})
+}
+
+// ConfirmTOTP godoc
+// @Summary Подтвердить двухфакторную аутентификацию
+// @Description Включает 2FA после проверки кода из приложения. Возвращает одноразовые резервные коды, они показываются только один раз.
+// @Tags 2FA
+// @Accept json
+// @Produce json
+// @Param request body api.TOTPCodeRequest true "Код из приложения"
+// @Success 200 {object} api.BackupCodesResponse "Успешно"
+// @Failure 403 {object} api.ErrorResponse "Unauthorized"
+// @Failure 400 {object} api.ErrorResponse "Ошибка клиента"
+// @Failure 409 {object} api.ErrorResponse "2FA уже включена"
+// @Failure 500 {object} api.ErrorResponse "Ошибка на сервере"
+// @Router /users/me/2fa/confirm [post]
+// @security BearerAuth
+func (h *Handler) ConfirmTOTP(w http.ResponseWriter, r *http.Request) {
+	const op = "http_handlers.ConfirmTOTP"
+
+	var request api.TOTPCodeRequest
+	if err := json.NewDecoder(r.Body).Decode(&request); err != nil {
+		Responder(w, http.StatusBadRequest, api.ErrorResponse{Status: false, Message: ErrInvalidJSON.Error()})
+		return
+	}
+	if request.Code == "" {
+		Responder(w, http.StatusBadRequest, api.ErrorResponse{Status: false, Message: ErrTOTPCodeRequired.Error()})
+		return
+	}
+
+	codes, err := h.userService.ConfirmTOTP(r.Context(), userFromContext(r.Context()).ID, request.Code)
+	if err != nil {
+		switch {
+		case errors.Is(err, services.ErrTOTPAlreadyEnabled):
+			Responder(w, http.StatusConflict, api.ErrorResponse{Status: false, Message: ErrTOTPAlreadyEnabled.Error()})
+		case errors.Is(err, services.ErrTOTPNotEnrolled):
+			Responder(w, http.StatusBadRequest, api.ErrorResponse{Status: false, Message: ErrTOTPNotEnrolled.Error()})
+		case errors.Is(err, services.ErrInvalidTOTPCode):
+			Responder(w, http.StatusBadRequest, api.ErrorResponse{Status: false, Message: ErrInvalidTOTPCode.Error()})
+		default:
+			log.Printf("%s: %v", op, err)
+			Responder(w, http.StatusInternalServerError, api.ErrorResponse{Status: false, Message: ErrInternalServer.Error()})
+		}
+		return
+	}
+
+	Responder(w, http.StatusOK, api.BackupCodesResponse{
+		Status:      true,
+		Message:     "Двухфакторная аутентификация включена. Сохраните резервные коды, каждый можно использовать один раз",
+		BackupCodes: codes,
+	})
+}
+
+// DisableTOTP godoc
+// @Summary Отключить двухфакторную аутентификацию
+// @Description Отключает 2FA. Требуется текущий пароль и код из приложения либо резервный код.
+// @Tags 2FA
+// @Accept json
+// @Produce json
+// @Param request body api.DisableTOTPRequest true "Пароль и код"
+// @Success 200 {object} api.MessageResponse "Успешно"
+// @Failure 403 {object} api.ErrorResponse "Unauthorized"
+// @Failure 401 {object} api.ErrorResponse "Неверный пароль"
+// @Failure 400 {object} api.ErrorResponse "Ошибка клиента"
+// @Failure 500 {object} api.ErrorResponse "Ошибка на сервере"
+// @Router /users/me/2fa/disable [post]
+// @security BearerAuth
+func (h *Handler) DisableTOTP(w http.ResponseWriter, r *http.Request) {
+	const op = "http_handlers.DisableTOTP"
+
+	var request api.DisableTOTPRequest
+	if err := json.NewDecoder(r.Body).Decode(&request); err != nil {
+		Responder(w, http.StatusBadRequest, api.ErrorResponse{Status: false, Message: ErrInvalidJSON.Error()})
+		return
+	}
+	if request.Password == "" || request.Code == "" {
+		Responder(w, http.StatusBadRequest, api.ErrorResponse{Status: false, Message: ErrTOTPDisableRequired.Error()})
+		return
+	}
+
+	err := h.userService.DisableTOTP(r.Context(), userFromContext(r.Context()).ID, request.Password, request.Code)
+	if err != nil {
+		switch {
+		case errors.Is(err, services.ErrIncorrectPassword):
+			Responder(w, http.StatusUnauthorized, api.ErrorResponse{Status: false, Message: ErrIncorrectPassword.Error()})
+		case errors.Is(err, services.ErrTOTPNotEnabled):
+			Responder(w, http.StatusBadRequest, api.ErrorResponse{Status: false, Message: ErrTOTPNotEnabled.Error()})
+		case errors.Is(err, services.ErrInvalidTOTPCode):
+			Responder(w, http.StatusBadRequest, api.ErrorResponse{Status: false, Message: ErrInvalidTOTPCode.Error()})
+		default:
+			log.Printf("%s: %v", op, err)
+			Responder(w, http.StatusInternalServerError, api.ErrorResponse{Status: false, Message: ErrInternalServer.Error()})
+		}
+		return
+	}
+
+	Responder(w, http.StatusOK, api.MessageResponse{Status: true, Message: "Двухфакторная аутентификация отключена"})
+}
+
+// VerifyTwoFactor godoc
+// @Summary Второй шаг входа
+// @Description Обменивает токен второго шага из /auth/login и код из приложения (или резервный код) на access и refresh токены.
+// @Tags auth
+// @Accept json
+// @Produce json
+// @Param request body api.TwoFactorLoginRequest true "Токен второго шага и код"
+// @Success 201 {object} api.LoginResponse "Успешная аутентификация"
+// @Failure 401 {object} api.ErrorResponse "Неверный код или токен"
+// @Failure 403 {object} api.ErrorResponse "Пользователь заблокирован"
+// @Failure 400 {object} api.ErrorResponse "Ошибка клиента"
+// @Failure 429 {object} api.ErrorResponse "Вход временно заблокирован, см. заголовок Retry-After"
+// @Failure 500 {object} api.ErrorResponse "Ошибка на сервере"
+// @Router /auth/2fa/verify [post]
+func (h *Handler) VerifyTwoFactor(w http.ResponseWriter, r *http.Request) {
+	const op = "http_handlers.VerifyTwoFactor"
+
+	var request api.TwoFactorLoginRequest
+	if err := json.NewDecoder(r.Body).Decode(&request); err != nil {
+		Responder(w, http.StatusBadRequest, api.ErrorResponse{Status: false, Message: ErrInvalidJSON.Error()})
+		return
+	}
+	if request.Code == "" {
+		Responder(w, http.StatusBadRequest, api.ErrorResponse{Status: false, Message: ErrTOTPCodeRequired.Error()})
+		return
+	}
+
+	userID, challengeID, err := h.tokens.VerifyChallengeToken(request.ChallengeToken)
+	if err != nil {
+		Responder(w, http.StatusUnauthorized, api.ErrorResponse{Status: false, Message: ErrInvalidChallenge.Error()})
+		return
+	}
+
+	user, err := h.userService.VerifyTwoFactor(r.Context(), userID, challengeID, request.Code, h.clientIP(r))
+	if err != nil {
+		var locked *services.LoginLockedError
+		switch {
+		case errors.As(err, &locked):
+			respondLoginLocked(w, locked)
+		case errors.Is(err, services.ErrInvalidTOTPCode):
+			Responder(w, http.StatusUnauthorized, api.ErrorResponse{Status: false, Message: ErrInvalidTOTPCode.Error()})
+		case errors.Is(err, services.ErrInvalidCredentials), errors.Is(err, services.ErrTOTPNotEnabled),
+			errors.Is(err, services.ErrChallengeExhausted):
+			Responder(w, http.StatusUnauthorized, api.ErrorResponse{Status: false, Message: ErrInvalidChallenge.Error()})
+		case errors.Is(err, services.ErrUserBanned):
+			Responder(w, http.StatusForbidden, api.ErrorResponse{Status: false, Message: ErrUserBanned.Error()})
+		default:
+			log.Printf("%s: %v", op, err)
+			Responder(w, http.StatusInternalServerError, api.ErrorResponse{Status: false, Message: ErrInternalServer.Error()})
+		}
+		return
+	}
+
+	h.respondLogin(w, r, op, user)
+}
+
+// respondLoginLocked отвечает 429 с заголовком Retry-After до окончания блокировки входа.
+func respondLoginLocked(w http.ResponseWriter, locked *services.LoginLockedError) {
+	retryAfter := int(math.Ceil(time.Until(locked.Until).Seconds()))
+	w.Header().Set("Retry-After", strconv.Itoa(max(retryAfter, 1)))
+	Responder(w, http.StatusTooManyRequests, api.ErrorResponse{Status: false, Message: ErrLoginLocked.Error()})
+}
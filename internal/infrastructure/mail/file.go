@@ -0,0 +1,53 @@
+package mail
+
+import (
+	"context"
+	"fmt"
+	"github.com/RVodassa/TaskReward/internal/domain/models"
+	"log"
+	"os"
+	"sync"
+	"time"
+)
+
+// FileMailer не отправляет письма, а дописывает их в файл-outbox или, если файл не задан, в журнал.
+// Предназначен для локальной разработки.
+type FileMailer struct {
+	from string
+	path string
+	mu   sync.Mutex
+}
+
+func NewFileMailer(from, path string) *FileMailer {
+	return &FileMailer{
+		from: from,
+		path: path,
+	}
+}
+
+// Send записывает письмо в outbox.
+func (m *FileMailer) Send(_ context.Context, email *models.Email) error {
+	const op = "mail.FileMailer.Send"
+
+	if m.path == "" {
+		log.Printf("%s: письмо для %s: %s\n%s", op, email.To, email.Subject, email.Body)
+		return nil
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	file, err := os.OpenFile(m.path, os.O_CREATE|os.O_APPEND|os.O_WRONLY, 0o600)
+	if err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+	defer file.Close()
+
+	_, err = fmt.Fprintf(file, "---- %s\nFrom: %s\nTo: %s\nSubject: %s\n\n%s\n\n",
+		time.Now().UTC().Format(time.RFC3339), m.from, email.To, email.Subject, email.Body)
+	if err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	return nil
+}
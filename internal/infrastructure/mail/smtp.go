@@ -0,0 +1,90 @@
+package mail
+
+import (
+	"context"
+	"crypto/tls"
+	"fmt"
+	"github.com/RVodassa/TaskReward/internal/domain/models"
+	"net"
+	"net/smtp"
+	"strconv"
+	"time"
+)
+
+const smtpTimeout = 30 * time.Second
+
+// SMTPMailer отправляет письма через SMTP сервер. Если сервер поддерживает STARTTLS, соединение
+// шифруется; аутентификация PLAIN выполняется, когда задан username.
+type SMTPMailer struct {
+	from     string
+	addr     string
+	host     string
+	username string
+	password string
+}
+
+func NewSMTPMailer(from, host string, port uint, username, password string) *SMTPMailer {
+	return &SMTPMailer{
+		from:     from,
+		addr:     net.JoinHostPort(host, strconv.FormatUint(uint64(port), 10)),
+		host:     host,
+		username: username,
+		password: password,
+	}
+}
+
+// Send отправляет письмо. Время отправки ограничено дедлайном ctx, но не более smtpTimeout.
+func (m *SMTPMailer) Send(ctx context.Context, email *models.Email) error {
+	const op = "mail.SMTPMailer.Send"
+
+	ctx, cancel := context.WithTimeout(ctx, smtpTimeout)
+	defer cancel()
+
+	var dialer net.Dialer
+	conn, err := dialer.DialContext(ctx, "tcp", m.addr)
+	if err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+	if deadline, ok := ctx.Deadline(); ok {
+		_ = conn.SetDeadline(deadline)
+	}
+
+	client, err := smtp.NewClient(conn, m.host)
+	if err != nil {
+		_ = conn.Close()
+		return fmt.Errorf("%s: %w", op, err)
+	}
+	defer client.Close()
+
+	if ok, _ := client.Extension("STARTTLS"); ok {
+		if err = client.StartTLS(&tls.Config{ServerName: m.host}); err != nil {
+			return fmt.Errorf("%s: %w", op, err)
+		}
+	}
+
+	if m.username != "" {
+		if err = client.Auth(smtp.PlainAuth("", m.username, m.password, m.host)); err != nil {
+			return fmt.Errorf("%s: %w", op, err)
+		}
+	}
+
+	if err = client.Mail(m.from); err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+	if err = client.Rcpt(email.To); err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	writer, err := client.Data()
+	if err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+	if _, err = writer.Write(buildMessage(m.from, email)); err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+	if err = writer.Close(); err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	return client.Quit()
+}
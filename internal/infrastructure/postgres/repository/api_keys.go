@@ -0,0 +1,182 @@
+package repository
+
+import (
+	"context"
+	"github.com/Masterminds/squirrel"
+	"github.com/RVodassa/TaskReward/internal/domain/models"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgconn"
+	"github.com/pkg/errors"
+	"strings"
+	"time"
+)
+
+var (
+	ErrAPIKeyNotFound = errors.New("ошибка: API ключ не найден")
+	ErrAPIKeyExists   = errors.New("ошибка: API ключ с таким префиксом уже существует")
+)
+
+// apiKeyColumns колонки api_keys в порядке scanAPIKey
+var apiKeyColumns = []string{"id", "name", "prefix", "secret_hash", "scopes", "created_by", "created_at", "expires_at", "last_used_at", "revoked_at"}
+
+// AddAPIKey сохраняет новый API ключ.
+func (r *Repo) AddAPIKey(ctx context.Context, key *models.APIKey) error {
+	const op = "repository.AddAPIKey"
+
+	query, args, err := r.builder.
+		Insert("api_keys").
+		Columns("name", "prefix", "secret_hash", "scopes", "created_by", "created_at", "expires_at").
+		Values(key.Name, key.Prefix, key.SecretHash, key.Scopes, key.CreatedBy, key.CreatedAt, key.ExpiresAt).
+		Suffix(`RETURNING "id"`).
+		ToSql()
+	if err != nil {
+		return errors.Wrap(err, op)
+	}
+
+	if err = r.db.QueryRow(ctx, query, args...).Scan(&key.ID); err != nil {
+		var pgErr *pgconn.PgError
+		if errors.As(err, &pgErr) {
+			switch pgErr.Code {
+			case "23505":
+				return ErrAPIKeyExists
+			case "23503":
+				return ErrUserNotFound
+			}
+		}
+		return errors.Wrap(err, op)
+	}
+
+	return nil
+}
+
+// GetAPIKeys возвращает страницу API ключей, новые первыми.
+func (r *Repo) GetAPIKeys(ctx context.Context, limit, offset uint) ([]*models.APIKey, error) {
+	const op = "repository.GetAPIKeys"
+
+	query, args, err := r.builder.
+		Select(apiKeyColumns...).
+		From("api_keys").
+		OrderBy("id DESC").
+		Limit(uint64(limit)).
+		Offset(uint64(offset)).
+		ToSql()
+	if err != nil {
+		return nil, errors.Wrap(err, op)
+	}
+
+	rows, err := r.db.Query(ctx, query, args...)
+	if err != nil {
+		return nil, errors.Wrap(err, op)
+	}
+	defer rows.Close()
+
+	keys := make([]*models.APIKey, 0)
+	for rows.Next() {
+		key, err := scanAPIKey(rows)
+		if err != nil {
+			return nil, errors.Wrap(err, op)
+		}
+		keys = append(keys, key)
+	}
+
+	if err = rows.Err(); err != nil {
+		return nil, errors.Wrap(err, op)
+	}
+
+	return keys, nil
+}
+
+// GetAPIKeyByPrefix возвращает API ключ по открытой части prefix.
+func (r *Repo) GetAPIKeyByPrefix(ctx context.Context, prefix string) (*models.APIKey, error) {
+	const op = "repository.GetAPIKeyByPrefix"
+
+	query, args, err := r.builder.
+		Select(apiKeyColumns...).
+		From("api_keys").
+		Where(squirrel.Eq{"prefix": prefix}).
+		ToSql()
+	if err != nil {
+		return nil, errors.Wrap(err, op)
+	}
+
+	key, err := scanAPIKey(r.db.QueryRow(ctx, query, args...))
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, ErrAPIKeyNotFound
+		}
+		return nil, errors.Wrap(err, op)
+	}
+
+	return key, nil
+}
+
+// RevokeAPIKey отзывает API ключ keyID. Повторный отзыв не меняет время отзыва.
+func (r *Repo) RevokeAPIKey(ctx context.Context, keyID uint) (*models.APIKey, error) {
+	const op = "repository.RevokeAPIKey"
+
+	query, args, err := r.builder.
+		Update("api_keys").
+		Set("revoked_at", squirrel.Expr("COALESCE(revoked_at, ?)", time.Now().UTC())).
+		Where(squirrel.Eq{"id": keyID}).
+		Suffix("RETURNING " + strings.Join(apiKeyColumns, ", ")).
+		ToSql()
+	if err != nil {
+		return nil, errors.Wrap(err, op)
+	}
+
+	key, err := scanAPIKey(r.db.QueryRow(ctx, query, args...))
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, ErrAPIKeyNotFound
+		}
+		return nil, errors.Wrap(err, op)
+	}
+
+	return key, nil
+}
+
+// TouchAPIKey отмечает использование API ключа. Время обновляется не чаще раза в минуту,
+// чтобы частые запросы не создавали лишних записей.
+func (r *Repo) TouchAPIKey(ctx context.Context, keyID uint, usedAt time.Time) error {
+	const op = "repository.TouchAPIKey"
+
+	query, args, err := r.builder.
+		Update("api_keys").
+		Set("last_used_at", usedAt).
+		Where(squirrel.Eq{"id": keyID}).
+		Where(squirrel.Or{
+			squirrel.Eq{"last_used_at": nil},
+			squirrel.Lt{"last_used_at": usedAt.Add(-time.Minute)},
+		}).
+		ToSql()
+	if err != nil {
+		return errors.Wrap(err, op)
+	}
+
+	if _, err = r.db.Exec(ctx, query, args...); err != nil {
+		return errors.Wrap(err, op)
+	}
+
+	return nil
+}
+
+// scanAPIKey читает API ключ в порядке apiKeyColumns.
+func scanAPIKey(row pgx.Row) (*models.APIKey, error) {
+	key := &models.APIKey{}
+	err := row.Scan(
+		&key.ID,
+		&key.Name,
+		&key.Prefix,
+		&key.SecretHash,
+		&key.Scopes,
+		&key.CreatedBy,
+		&key.CreatedAt,
+		&key.ExpiresAt,
+		&key.LastUsedAt,
+		&key.RevokedAt,
+	)
+	if err != nil {
+		return nil, err
+	}
+	return key, nil
+}
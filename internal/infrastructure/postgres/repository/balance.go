@@ -0,0 +1,198 @@
+package repository
+
+import (
+	"context"
+	"github.com/Masterminds/squirrel"
+	"github.com/RVodassa/TaskReward/internal/domain/models"
+	"github.com/jackc/pgx/v5"
+	"github.com/pkg/errors"
+	"time"
+)
+
+// ChangeBalance изменяет баланс пользователя на txn.Amount и записывает операцию в журнал
+// balance_transactions в рамках переданной транзакции. Баланс не может стать отрицательным.
+// После выполнения txn содержит ID, баланс после операции и время записи.
+func (r *Repo) ChangeBalance(ctx context.Context, tx pgx.Tx, txn *models.BalanceTransaction) error {
+	const op = "repository.ChangeBalance"
+
+	if txn.Amount == 0 {
+		return errors.New("invalid amount: amount cannot be zero")
+	}
+
+	query, args, err := r.builder.
+		Update("users").
+		Set("balance", squirrel.Expr("balance + ?", txn.Amount)).
+		Where(squirrel.Eq{"id": txn.UserID}).
+		Where(squirrel.Expr("balance + ? >= 0", txn.Amount)).
+		Suffix(`RETURNING "balance"`).
+		ToSql()
+	if err != nil {
+		return errors.Wrap(err, op)
+	}
+
+	err = tx.QueryRow(ctx, query, args...).Scan(&txn.BalanceAfter)
+	if err != nil {
+		if !errors.Is(err, pgx.ErrNoRows) {
+			return errors.Wrap(err, op)
+		}
+
+		// Баланс не изменен: либо пользователя нет, либо недостаточно средств
+		exists, err := r.userExists(ctx, tx, txn.UserID)
+		if err != nil {
+			return errors.Wrap(err, op)
+		}
+		if !exists {
+			return ErrUserNotFound
+		}
+		return ErrInsufficientFunds
+	}
+
+	now := time.Now().UTC()
+	txn.CreatedAt = &now
+
+	query, args, err = r.builder.
+		Insert("balance_transactions").
+		Columns("user_id", "amount", "balance_after", "type", "reference_id", "admin_id", "comment", "created_at").
+		Values(txn.UserID, txn.Amount, txn.BalanceAfter, txn.Type, nullableID(txn.ReferenceID), nullableID(txn.AdminID), txn.Comment, txn.CreatedAt).
+		Suffix(`RETURNING "id"`).
+		ToSql()
+	if err != nil {
+		return errors.Wrap(err, op)
+	}
+
+	if err = tx.QueryRow(ctx, query, args...).Scan(&txn.ID); err != nil {
+		return errors.Wrap(err, op)
+	}
+
+	return nil
+}
+
+// AdjustBalance выполняет ручную корректировку баланса администратором.
+func (r *Repo) AdjustBalance(ctx context.Context, txn *models.BalanceTransaction) error {
+	const op = "repository.AdjustBalance"
+
+	tx, err := r.db.Begin(ctx)
+	if err != nil {
+		return errors.Wrap(err, op)
+	}
+	defer func() {
+		_ = tx.Rollback(ctx)
+	}()
+
+	txn.Type = models.TransactionAdminAdjustment
+	if err = r.ChangeBalance(ctx, tx, txn); err != nil {
+		if errors.Is(err, ErrUserNotFound) || errors.Is(err, ErrInsufficientFunds) {
+			return err
+		}
+		return errors.Wrap(err, op)
+	}
+
+	if err = tx.Commit(ctx); err != nil {
+		return errors.Wrap(err, op)
+	}
+
+	return nil
+}
+
+// GetBalanceTransactions возвращает страницу истории операций пользователя (новые первыми)
+// и общее кол-во операций.
+func (r *Repo) GetBalanceTransactions(ctx context.Context, userID uint, limit, offset uint) ([]*models.BalanceTransaction, uint, error) {
+	const op = "repository.GetBalanceTransactions"
+
+	query, args, err := r.builder.
+		Select("COUNT(*)").
+		From("balance_transactions").
+		Where(squirrel.Eq{"user_id": userID}).
+		ToSql()
+	if err != nil {
+		return nil, 0, errors.Wrap(err, op)
+	}
+
+	var total uint
+	if err = r.db.QueryRow(ctx, query, args...).Scan(&total); err != nil {
+		return nil, 0, errors.Wrap(err, op)
+	}
+
+	query, args, err = r.builder.
+		Select("id", "user_id", "amount", "balance_after", "type", "COALESCE(reference_id, 0)", "COALESCE(admin_id, 0)", "comment", "created_at").
+		From("balance_transactions").
+		Where(squirrel.Eq{"user_id": userID}).
+		OrderBy("id DESC").
+		Limit(uint64(limit)).
+		Offset(uint64(offset)).
+		ToSql()
+	if err != nil {
+		return nil, 0, errors.Wrap(err, op)
+	}
+
+	rows, err := r.db.Query(ctx, query, args...)
+	if err != nil {
+		return nil, 0, errors.Wrap(err, op)
+	}
+	defer rows.Close()
+
+	transactions := make([]*models.BalanceTransaction, 0)
+	for rows.Next() {
+		txn := &models.BalanceTransaction{}
+		err = rows.Scan(
+			&txn.ID,
+			&txn.UserID,
+			&txn.Amount,
+			&txn.BalanceAfter,
+			&txn.Type,
+			&txn.ReferenceID,
+			&txn.AdminID,
+			&txn.Comment,
+			&txn.CreatedAt,
+		)
+		if err != nil {
+			return nil, 0, errors.Wrap(err, op)
+		}
+		transactions = append(transactions, txn)
+	}
+
+	if err = rows.Err(); err != nil {
+		return nil, 0, errors.Wrap(err, op)
+	}
+
+	return transactions, total, nil
+}
+
+// userExists проверяет существование пользователя в рамках транзакции.
+func (r *Repo) userExists(ctx context.Context, tx pgx.Tx, userID uint) (bool, error) {
+	const op = "repository.userExists"
+
+	query, args, err := r.builder.
+		Select("1").
+		Prefix("SELECT EXISTS (").
+		From("users").
+		Where(squirrel.Eq{"id": userID}).
+		Suffix(")").
+		ToSql()
+	if err != nil {
+		return false, errors.Wrap(err, op)
+	}
+
+	var exists bool
+	if err = tx.QueryRow(ctx, query, args...).Scan(&exists); err != nil {
+		return false, errors.Wrap(err, op)
+	}
+
+	return exists, nil
+}
+
+// nullableID возвращает nil для нулевого идентификатора, чтобы записать NULL в необязательную ссылку.
+func nullableID(id uint) interface{} {
+	if id == 0 {
+		return nil
+	}
+	return id
+}
+
+// nullableString возвращает nil для пустой строки, чтобы записать NULL в необязательное поле.
+func nullableString(value string) interface{} {
+	if value == "" {
+		return nil
+	}
+	return value
+}
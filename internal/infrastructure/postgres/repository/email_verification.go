@@ -0,0 +1,143 @@
+package repository
+
+import (
+	"context"
+	"github.com/Masterminds/squirrel"
+	"github.com/RVodassa/TaskReward/internal/domain/models"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgconn"
+	"github.com/pkg/errors"
+	"time"
+)
+
+var (
+	ErrEmailVerificationTokenInvalid = errors.New("ошибка: токен подтверждения email недействителен")
+)
+
+// AddEmailVerificationToken сохраняет токен подтверждения email. Ранее выданные и еще не
+// использованные токены пользователя становятся недействительными.
+func (r *Repo) AddEmailVerificationToken(ctx context.Context, token *models.EmailVerificationToken) error {
+	const op = "repository.AddEmailVerificationToken"
+
+	tx, err := r.db.Begin(ctx)
+	if err != nil {
+		return errors.Wrap(err, op)
+	}
+	defer func() {
+		_ = tx.Rollback(ctx)
+	}()
+
+	if err = r.expireEmailVerificationTokens(ctx, tx, token.UserID, token.CreatedAt); err != nil {
+		return errors.Wrap(err, op)
+	}
+
+	query, args, err := r.builder.
+		Insert("email_verification_tokens").
+		Columns("user_id", "email", "token_hash", "expires_at", "created_at").
+		Values(token.UserID, token.Email, token.TokenHash, token.ExpiresAt, token.CreatedAt).
+		Suffix(`RETURNING "id"`).
+		ToSql()
+	if err != nil {
+		return errors.Wrap(err, op)
+	}
+
+	if err = tx.QueryRow(ctx, query, args...).Scan(&token.ID); err != nil {
+		var pgErr *pgconn.PgError
+		if errors.As(err, &pgErr) && pgErr.Code == "23503" {
+			return ErrUserNotFound
+		}
+		return errors.Wrap(err, op)
+	}
+
+	if err = tx.Commit(ctx); err != nil {
+		return errors.Wrap(err, op)
+	}
+
+	return nil
+}
+
+// VerifyEmail по хэшу токена tokenHash отмечает email пользователя подтвержденным, если с момента
+// выдачи токена email не менялся. Токен становится использованным. Возвращает ID пользователя.
+func (r *Repo) VerifyEmail(ctx context.Context, tokenHash string) (uint, error) {
+	const op = "repository.VerifyEmail"
+
+	tx, err := r.db.Begin(ctx)
+	if err != nil {
+		return 0, errors.Wrap(err, op)
+	}
+	defer func() {
+		_ = tx.Rollback(ctx)
+	}()
+
+	query, args, err := r.builder.
+		Select("id", "user_id", "email", "expires_at", "used_at").
+		From("email_verification_tokens").
+		Where(squirrel.Eq{"token_hash": tokenHash}).
+		Suffix("FOR UPDATE").
+		ToSql()
+	if err != nil {
+		return 0, errors.Wrap(err, op)
+	}
+
+	var token models.EmailVerificationToken
+	err = tx.QueryRow(ctx, query, args...).Scan(&token.ID, &token.UserID, &token.Email, &token.ExpiresAt, &token.UsedAt)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return 0, ErrEmailVerificationTokenInvalid
+		}
+		return 0, errors.Wrap(err, op)
+	}
+
+	now := time.Now().UTC()
+	if token.UsedAt != nil || !token.ExpiresAt.After(now) {
+		return 0, ErrEmailVerificationTokenInvalid
+	}
+
+	// Подтверждается только адрес, на который был отправлен токен
+	query, args, err = r.builder.
+		Update("users").
+		Set("email_verified_at", squirrel.Expr("COALESCE(email_verified_at, ?)", now)).
+		Where(squirrel.Eq{"id": token.UserID, "email": token.Email}).
+		ToSql()
+	if err != nil {
+		return 0, errors.Wrap(err, op)
+	}
+
+	result, err := tx.Exec(ctx, query, args...)
+	if err != nil {
+		return 0, errors.Wrap(err, op)
+	}
+	if result.RowsAffected() == 0 {
+		return 0, ErrEmailVerificationTokenInvalid
+	}
+
+	if err = r.expireEmailVerificationTokens(ctx, tx, token.UserID, now); err != nil {
+		return 0, errors.Wrap(err, op)
+	}
+
+	if err = tx.Commit(ctx); err != nil {
+		return 0, errors.Wrap(err, op)
+	}
+
+	return token.UserID, nil
+}
+
+// expireEmailVerificationTokens отмечает использованными все неиспользованные токены подтверждения email пользователя.
+func (r *Repo) expireEmailVerificationTokens(ctx context.Context, tx pgx.Tx, userID uint, now time.Time) error {
+	const op = "repository.expireEmailVerificationTokens"
+
+	query, args, err := r.builder.
+		Update("email_verification_tokens").
+		Set("used_at", now).
+		Where(squirrel.Eq{"user_id": userID, "used_at": nil}).
+		ToSql()
+	if err != nil {
+		return errors.Wrap(err, op)
+	}
+
+	if _, err = tx.Exec(ctx, query, args...); err != nil {
+		return errors.Wrap(err, op)
+	}
+
+	return nil
+}
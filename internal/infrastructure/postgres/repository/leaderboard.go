@@ -0,0 +1,341 @@
+package repository
+
+import (
+	"context"
+	"github.com/RVodassa/TaskReward/internal/domain/models"
+	"github.com/jackc/pgx/v5"
+	"github.com/pkg/errors"
+	"strconv"
+	"time"
+)
+
+// leaderboardChannel канал LISTEN/NOTIFY с событиями таблиц лидеров
+const leaderboardChannel = "leaderboard"
+
+// notifyLeaderboardScoreQuery отправляет событие о начислении бонуса $2 пользователю $1 за задачу,
+// выполненную в $3. Уведомление доставляется только после фиксации транзакции.
+const notifyLeaderboardScoreQuery = `
+SELECT pg_notify('` + leaderboardChannel + `', json_build_object(
+    'type', '` + models.LeaderboardEventScore + `',
+    'user_id', $1::bigint,
+    'bonus', $2::bigint,
+    'completed_at', $3::timestamptz,
+    'xid', pg_current_xact_id()::text
+)::text)`
+
+// notifyLeaderboardUserQuery отправляет событие об изменении данных пользователя $1 в таблицах лидеров
+const notifyLeaderboardUserQuery = `
+SELECT pg_notify('` + leaderboardChannel + `', json_build_object(
+    'type', '` + models.LeaderboardEventUser + `',
+    'user_id', $1::bigint
+)::text)`
+
+// leaderboardSnapshotQuery возвращает суммы бонусов всех пользователей с выполненными задачами начиная
+// с $1 (день), $2 (неделя), $3 (месяц) и за все время. Скрывшие себя, исключенные и неподтвержденные
+// пользователи тоже возвращаются: кэш учитывает их бонусы и включает в рейтинг при изменении настроек.
+const leaderboardSnapshotQuery = `
+SELECT c.user_id,
+       COALESCE(SUM(c.bonus_awarded) FILTER (WHERE c.completed_at >= $1), 0),
+       COALESCE(SUM(c.bonus_awarded) FILTER (WHERE c.completed_at >= $2), 0),
+       COALESCE(SUM(c.bonus_awarded) FILTER (WHERE c.completed_at >= $3), 0),
+       SUM(c.bonus_awarded),
+       u.email_verified_at IS NOT NULL,
+       u.leaderboard_excluded_at IS NOT NULL,
+       COALESCE(u.display_name, ''),
+       COALESCE(u.avatar_url, ''),
+       u.leaderboard_visibility
+FROM task_completions c
+JOIN users u ON u.id = c.user_id
+GROUP BY c.user_id, u.id`
+
+// leaderboardSnapshotBoundsQuery возвращает границы текущего снимка транзакций
+const leaderboardSnapshotBoundsQuery = `
+SELECT pg_snapshot_xmin(s)::text, pg_snapshot_xmax(s)::text, ARRAY(SELECT pg_snapshot_xip(s)::text)
+FROM pg_current_snapshot() s`
+
+// rankedUserCondition оставляет пользователей, участвующих в рейтингах: не скрывших себя
+// и не исключенных администратором. Псевдоним таблицы users - u.
+const rankedUserCondition = "u.leaderboard_visibility <> 'hidden' AND u.leaderboard_excluded_at IS NULL"
+
+// leaderboardProfileColumns публичные данные пользователя u в порядке полей models.LeaderboardProfile
+var leaderboardProfileColumns = []string{
+	"COALESCE(u.display_name, '') AS display_name",
+	"COALESCE(u.avatar_url, '') AS avatar_url",
+	"u.leaderboard_visibility AS visibility",
+}
+
+// leaderboardPeriods периоды таблиц лидеров с началами дня $1, недели $2 и месяца $3 в UTC.
+// Период all хранится с началом '-infinity'.
+const leaderboardPeriods = `(VALUES ('` + models.PeriodDay + `', $1::timestamp),
+        ('` + models.PeriodWeek + `', $2::timestamp),
+        ('` + models.PeriodMonth + `', $3::timestamp),
+        ('` + models.PeriodAll + `', '-infinity'::timestamp)) p(period, period_start)`
+
+// leaderboardScoreQuery добавляет пользователю $4 бонус $5 в суммы за периоды, которым принадлежит
+// выполнение задачи.
+const leaderboardScoreQuery = `
+INSERT INTO leaderboard_scores (period, period_start, user_id, score)
+SELECT p.period, p.period_start, $4::integer, $5::bigint
+FROM ` + leaderboardPeriods + `
+ON CONFLICT (period, period_start, user_id) DO UPDATE SET score = leaderboard_scores.score + EXCLUDED.score`
+
+// leaderboardRebuildQuery считает суммы бонусов всех пользователей за текущие периоды по task_completions.
+const leaderboardRebuildQuery = `
+INSERT INTO leaderboard_scores (period, period_start, user_id, score)
+SELECT p.period, p.period_start, c.user_id, SUM(c.bonus_awarded)
+FROM ` + leaderboardPeriods + `
+JOIN task_completions c ON c.completed_at >= p.period_start
+GROUP BY p.period, p.period_start, c.user_id`
+
+// leaderboardScoresCondition оставляет суммы leaderboard_scores s за период $2 с началом $3
+// (NULL - за все время) пользователей u, участвующих в рейтингах, $4 - только с подтвержденным email.
+const leaderboardScoresCondition = `s.period = $2 AND s.period_start = COALESCE($3::timestamp, '-infinity')
+      AND (NOT $4 OR u.email_verified_at IS NOT NULL)
+      AND ` + rankedUserCondition
+
+// leaderboardNeighborsQuery возвращает место пользователя $1 и до $5 соседей выше и ниже по сумме бонусов
+// за период (условия - leaderboardScoresCondition). Соседи читаются по индексу на сумме через LIMIT,
+// место - по кол-ву уникальных сумм выше суммы пользователя, места соседей отсчитываются от него
+// по уникальным суммам в выборке: она идет по таблице без разрывов.
+const leaderboardNeighborsQuery = `
+WITH me AS (
+    SELECT s.user_id, s.score
+    FROM leaderboard_scores s
+    JOIN users u ON u.id = s.user_id
+    WHERE s.user_id = $1 AND ` + leaderboardScoresCondition + `
+),
+neighbors AS (
+    SELECT * FROM (
+        SELECT s.user_id, s.score
+        FROM me, leaderboard_scores s
+        JOIN users u ON u.id = s.user_id
+        WHERE ` + leaderboardScoresCondition + `
+          AND s.score >= me.score AND (s.score > me.score OR s.user_id < me.user_id)
+        ORDER BY s.score, s.user_id DESC
+        LIMIT $5
+    ) above
+    UNION ALL
+    SELECT * FROM me
+    UNION ALL
+    SELECT * FROM (
+        SELECT s.user_id, s.score
+        FROM me, leaderboard_scores s
+        JOIN users u ON u.id = s.user_id
+        WHERE ` + leaderboardScoresCondition + `
+          AND s.score <= me.score AND (s.score < me.score OR s.user_id > me.user_id)
+        ORDER BY s.score DESC, s.user_id
+        LIMIT $5
+    ) below
+),
+ranks AS (
+    SELECT user_id, score, DENSE_RANK() OVER (ORDER BY score DESC) AS rank
+    FROM neighbors
+),
+higher AS (
+    SELECT COUNT(DISTINCT s.score) AS scores
+    FROM me, leaderboard_scores s
+    JOIN users u ON u.id = s.user_id
+    WHERE ` + leaderboardScoresCondition + ` AND s.score > me.score
+)
+SELECT h.scores + 1 + r.rank - m.rank, r.user_id, r.score,
+       COALESCE(u.display_name, ''), COALESCE(u.avatar_url, ''), u.leaderboard_visibility
+FROM ranks r
+JOIN ranks m ON m.user_id = $1
+CROSS JOIN higher h
+JOIN users u ON u.id = r.user_id
+ORDER BY r.score DESC, r.user_id`
+
+// GetLeaderboardNeighbors возвращает место пользователя userID в таблице лидеров period с началом since
+// (nil - за все время) вместе с neighbors соседями выше и ниже, в порядке таблицы.
+// Пустой список - у пользователя нет бонусов за период или он не участвует в рейтингах.
+func (r *Repo) GetLeaderboardNeighbors(ctx context.Context, userID uint, period string, since *time.Time, neighbors uint, verifiedOnly bool) ([]*models.LeaderboardEntry, error) {
+	const op = "repository.GetLeaderboardNeighbors"
+
+	rows, err := r.db.Query(ctx, leaderboardNeighborsQuery, userID, period, since, verifiedOnly, neighbors)
+	if err != nil {
+		return nil, errors.Wrap(err, op)
+	}
+	defer rows.Close()
+
+	entries := make([]*models.LeaderboardEntry, 0)
+	for rows.Next() {
+		var entry models.LeaderboardEntry
+		err = rows.Scan(&entry.Rank, &entry.UserID, &entry.Score, &entry.DisplayName, &entry.AvatarURL, &entry.Visibility)
+		if err != nil {
+			return nil, errors.Wrap(err, op)
+		}
+		entries = append(entries, &entry)
+	}
+
+	if err = rows.Err(); err != nil {
+		return nil, errors.Wrap(err, op)
+	}
+
+	return entries, nil
+}
+
+// RebuildLeaderboardScores пересчитывает суммы бонусов за текущие на момент now периоды по выполненным
+// задачам и удаляет суммы прошедших периодов. Вызывается при запуске сервиса: заполняет таблицу после
+// миграции и исправляет границы периодов после смены LEADERBOARD_TIMEZONE.
+func (r *Repo) RebuildLeaderboardScores(ctx context.Context, now time.Time) error {
+	const op = "repository.RebuildLeaderboardScores"
+
+	tx, err := r.db.Begin(ctx)
+	if err != nil {
+		return errors.Wrap(err, op)
+	}
+	defer func() {
+		_ = tx.Rollback(ctx)
+	}()
+
+	// Блокировка ждет транзакции, уже начислившие суммы, и не дает начислять новые до конца пересчета:
+	// выполнения, не вошедшие в пересчет, добавятся к суммам после него
+	if _, err = tx.Exec(ctx, "LOCK TABLE leaderboard_scores IN SHARE ROW EXCLUSIVE MODE"); err != nil {
+		return errors.Wrap(err, op)
+	}
+	if _, err = tx.Exec(ctx, "DELETE FROM leaderboard_scores"); err != nil {
+		return errors.Wrap(err, op)
+	}
+
+	day, week, month := r.leaderboardPeriodStarts(now)
+	if _, err = tx.Exec(ctx, leaderboardRebuildQuery, day, week, month); err != nil {
+		return errors.Wrap(err, op)
+	}
+
+	if err = tx.Commit(ctx); err != nil {
+		return errors.Wrap(err, op)
+	}
+	return nil
+}
+
+// addLeaderboardScore добавляет бонус за задачу, выполненную в момент at, в суммы за день, неделю и месяц,
+// которым принадлежит at, и за все время.
+func (r *Repo) addLeaderboardScore(ctx context.Context, tx pgx.Tx, userID uint, bonus uint, at time.Time) error {
+	const op = "repository.addLeaderboardScore"
+
+	day, week, month := r.leaderboardPeriodStarts(at)
+	if _, err := tx.Exec(ctx, leaderboardScoreQuery, day, week, month, userID, bonus); err != nil {
+		return errors.Wrap(err, op)
+	}
+	return nil
+}
+
+// leaderboardPeriodStarts возвращает начала дня, недели и месяца, которым принадлежит at, в UTC.
+func (r *Repo) leaderboardPeriodStarts(at time.Time) (day, week, month time.Time) {
+	dayStart, _ := models.PeriodStart(models.PeriodDay, at, r.location)
+	weekStart, _ := models.PeriodStart(models.PeriodWeek, at, r.location)
+	monthStart, _ := models.PeriodStart(models.PeriodMonth, at, r.location)
+	return *dayStart, *weekStart, *monthStart
+}
+
+// notifyLeaderboardScore сообщает экземплярам сервиса о начислении бонуса за задачу в транзакции tx.
+func (r *Repo) notifyLeaderboardScore(ctx context.Context, tx pgx.Tx, userID uint, bonus uint, completedAt time.Time) error {
+	const op = "repository.notifyLeaderboardScore"
+
+	if _, err := tx.Exec(ctx, notifyLeaderboardScoreQuery, userID, bonus, completedAt); err != nil {
+		return errors.Wrap(err, op)
+	}
+	return nil
+}
+
+// NotifyLeaderboardUser сообщает экземплярам сервиса об изменении профиля, видимости, исключения
+// или подтверждения email пользователя.
+func (r *Repo) NotifyLeaderboardUser(ctx context.Context, userID uint) error {
+	const op = "repository.NotifyLeaderboardUser"
+
+	if _, err := r.db.Exec(ctx, notifyLeaderboardUserQuery, userID); err != nil {
+		return errors.Wrap(err, op)
+	}
+	return nil
+}
+
+// GetLeaderboardSnapshot возвращает суммы бонусов всех пользователей за периоды, начинающиеся с day,
+// week и month, и за все время вместе с границами снимка транзакций, в котором они посчитаны.
+func (r *Repo) GetLeaderboardSnapshot(ctx context.Context, day, week, month time.Time) (*models.LeaderboardSnapshot, error) {
+	const op = "repository.GetLeaderboardSnapshot"
+
+	// Границы снимка и суммы должны относиться к одному снимку
+	tx, err := r.db.BeginTx(ctx, pgx.TxOptions{IsoLevel: pgx.RepeatableRead, AccessMode: pgx.ReadOnly})
+	if err != nil {
+		return nil, errors.Wrap(err, op)
+	}
+	defer func() {
+		_ = tx.Rollback(ctx)
+	}()
+
+	var xmin, xmax string
+	var xip []string
+	if err = tx.QueryRow(ctx, leaderboardSnapshotBoundsQuery).Scan(&xmin, &xmax, &xip); err != nil {
+		return nil, errors.Wrap(err, op)
+	}
+
+	snapshot := &models.LeaderboardSnapshot{
+		Scores: make([]*models.LeaderboardScore, 0),
+		XIP:    make(map[uint64]struct{}, len(xip)),
+	}
+	if snapshot.Xmin, err = strconv.ParseUint(xmin, 10, 64); err != nil {
+		return nil, errors.Wrap(err, op)
+	}
+	if snapshot.Xmax, err = strconv.ParseUint(xmax, 10, 64); err != nil {
+		return nil, errors.Wrap(err, op)
+	}
+	for _, value := range xip {
+		xid, err := strconv.ParseUint(value, 10, 64)
+		if err != nil {
+			return nil, errors.Wrap(err, op)
+		}
+		snapshot.XIP[xid] = struct{}{}
+	}
+
+	rows, err := tx.Query(ctx, leaderboardSnapshotQuery, day, week, month)
+	if err != nil {
+		return nil, errors.Wrap(err, op)
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var score models.LeaderboardScore
+		err = rows.Scan(&score.UserID, &score.Day, &score.Week, &score.Month, &score.All, &score.Verified,
+			&score.Excluded, &score.DisplayName, &score.AvatarURL, &score.Visibility)
+		if err != nil {
+			return nil, errors.Wrap(err, op)
+		}
+		snapshot.Scores = append(snapshot.Scores, &score)
+	}
+
+	if err = rows.Err(); err != nil {
+		return nil, errors.Wrap(err, op)
+	}
+
+	return snapshot, nil
+}
+
+// ListenLeaderboard подписывается на события таблиц лидеров на отдельном соединении, вызывает listening
+// после подписки и notify для каждого события. Работает до отмены ctx или ошибки соединения,
+// события, отправленные без подписки, теряются.
+func (r *Repo) ListenLeaderboard(ctx context.Context, listening func(), notify func(payload string)) error {
+	const op = "repository.ListenLeaderboard"
+
+	pooled, err := r.db.Acquire(ctx)
+	if err != nil {
+		return errors.Wrap(err, op)
+	}
+	// Соединение с подпиской не возвращается в пул
+	conn := pooled.Hijack()
+	defer func() {
+		_ = conn.Close(context.Background())
+	}()
+
+	if _, err = conn.Exec(ctx, "LISTEN "+leaderboardChannel); err != nil {
+		return errors.Wrap(err, op)
+	}
+	listening()
+
+	for {
+		notification, err := conn.WaitForNotification(ctx)
+		if err != nil {
+			return errors.Wrap(err, op)
+		}
+		notify(notification.Payload)
+	}
+}
@@ -0,0 +1,220 @@
+package repository
+
+import (
+	"context"
+	"fmt"
+	"github.com/RVodassa/TaskReward/internal/domain/models"
+	"github.com/jackc/pgx/v5/pgxpool"
+	"testing"
+	"time"
+)
+
+const (
+	// benchmarkUsers кол-во пользователей в бенчмарке, совпадает с бенчмарками кэша таблиц лидеров
+	benchmarkUsers = 200_000
+	// benchmarkTasks кол-во задач, каждую пользователь выполнил с вероятностью 30%
+	benchmarkTasks = 20
+	// benchmarkPrefix префикс логинов пользователей бенчмарка
+	benchmarkPrefix = "bench_leaderboard_"
+)
+
+// seedLeaderboardBenchmark создает пользователей и выполнения задач за последние 60 дней, если их еще нет.
+// Данные сохраняются между запусками.
+func seedLeaderboardBenchmark(b *testing.B, r *Repo, db *pgxpool.Pool) {
+	b.Helper()
+	ctx := context.Background()
+
+	var users int
+	err := db.QueryRow(ctx, "SELECT COUNT(*) FROM users WHERE login LIKE $1 || '%'", benchmarkPrefix).Scan(&users)
+	if err != nil {
+		b.Fatalf("count users: %v", err)
+	}
+	if users >= benchmarkUsers {
+		return
+	}
+
+	_, err = db.Exec(ctx, `
+		INSERT INTO users (login, password_hash)
+		SELECT $1 || i, 'hash' FROM generate_series(1, $2::int) i
+		ON CONFLICT (login) DO NOTHING`, benchmarkPrefix, benchmarkUsers)
+	if err != nil {
+		b.Fatalf("create users: %v", err)
+	}
+
+	taskIDs := make([]int64, 0, benchmarkTasks)
+	for i := 0; i < benchmarkTasks; i++ {
+		task := createTestTask(b, r, 10, 0)
+		taskIDs = append(taskIDs, int64(task.ID))
+	}
+
+	_, err = db.Exec(ctx, `
+		INSERT INTO task_completions (task_id, user_id, completed_at, bonus_awarded)
+		SELECT t.id, u.id, NOW() - random() * INTERVAL '60 days', 1 + (random() * 100)::int
+		FROM users u CROSS JOIN unnest($2::bigint[]) t(id)
+		WHERE u.login LIKE $1 || '%' AND random() < 0.3`, benchmarkPrefix, taskIDs)
+	if err != nil {
+		b.Fatalf("create completions: %v", err)
+	}
+
+	// Выполнения добавлены в обход TaskComplete, суммы за периоды пересчитываются по ним
+	if err = r.RebuildLeaderboardScores(ctx, time.Now()); err != nil {
+		b.Fatalf("RebuildLeaderboardScores: %v", err)
+	}
+
+	if _, err = db.Exec(ctx, "ANALYZE task_completions, leaderboard_scores"); err != nil {
+		b.Fatalf("analyze: %v", err)
+	}
+}
+
+// BenchmarkRepoGetListTopUsers запрос таблицы лидеров без кэша, для сравнения с BenchmarkCacheTop.
+func BenchmarkRepoGetListTopUsers(b *testing.B) {
+	r, db := newTestRepo(b)
+	seedLeaderboardBenchmark(b, r, db)
+
+	day := time.Now().UTC().Truncate(24 * time.Hour)
+	periods := []struct {
+		name  string
+		since *time.Time
+	}{
+		{name: models.PeriodDay, since: &day},
+		{name: models.PeriodAll},
+	}
+
+	b.ResetTimer()
+	for _, period := range periods {
+		b.Run(period.name, func(b *testing.B) {
+			for i := 0; i < b.N; i++ {
+				if _, err := r.GetListTopUsers(context.Background(), period.since, 10, false); err != nil {
+					b.Fatalf("GetListTopUsers: %v", err)
+				}
+			}
+		})
+	}
+}
+
+// BenchmarkRepoGetLeaderboardNeighbors место пользователя и соседи без кэша, для сравнения с BenchmarkCacheNeighbors.
+func BenchmarkRepoGetLeaderboardNeighbors(b *testing.B) {
+	r, db := newTestRepo(b)
+	seedLeaderboardBenchmark(b, r, db)
+
+	var userID uint
+	err := db.QueryRow(context.Background(), "SELECT id FROM users WHERE login = $1", benchmarkPrefix+"100000").Scan(&userID)
+	if err != nil {
+		b.Fatalf("benchmark user: %v", err)
+	}
+
+	day, _ := models.PeriodStart(models.PeriodDay, time.Now(), time.UTC)
+	periods := []struct {
+		name  string
+		since *time.Time
+	}{
+		{name: models.PeriodDay, since: day},
+		{name: models.PeriodAll},
+	}
+
+	b.ResetTimer()
+	for _, period := range periods {
+		b.Run(period.name, func(b *testing.B) {
+			for i := 0; i < b.N; i++ {
+				if _, err := r.GetLeaderboardNeighbors(context.Background(), userID, period.name, period.since, 5, false); err != nil {
+					b.Fatalf("GetLeaderboardNeighbors: %v", err)
+				}
+			}
+		})
+	}
+}
+
+// leaderboardNeighborsReference считает место пользователя $1 и до $3 соседей выше и ниже агрегированием
+// task_completions с начала $2 (NULL - за все время): результат GetLeaderboardNeighbors должен совпадать с ним.
+const leaderboardNeighborsReference = `
+WITH ranked AS (
+    SELECT user_id, score,
+           DENSE_RANK() OVER (ORDER BY score DESC) AS rank,
+           ROW_NUMBER() OVER (ORDER BY score DESC, user_id) AS position
+    FROM (
+        SELECT c.user_id, SUM(c.bonus_awarded) AS score
+        FROM task_completions c
+        JOIN users u ON u.id = c.user_id
+        WHERE ($2::timestamp IS NULL OR c.completed_at >= $2) AND ` + rankedUserCondition + `
+        GROUP BY c.user_id
+    ) s
+)
+SELECT r.rank, r.user_id, r.score
+FROM ranked r, ranked me
+WHERE me.user_id = $1 AND r.position BETWEEN me.position - $3 AND me.position + $3
+ORDER BY r.position`
+
+func TestGetLeaderboardNeighbors(t *testing.T) {
+	r, db := newTestRepo(t)
+	ctx := context.Background()
+
+	// Суммы: 50, 30, 30 (общее место), 20, 10, скрывший себя пользователь с 25
+	userIDs := createTestUsers(t, db, 6)
+	bonuses := []uint{50, 30, 30, 20, 10, 25}
+	for i, userID := range userIDs {
+		task := createTestTask(t, r, bonuses[i], 0)
+		if _, err := r.TaskComplete(ctx, task.ID, userID); err != nil {
+			t.Fatalf("TaskComplete: %v", err)
+		}
+	}
+	hidden := userIDs[5]
+	if err := r.UpdateProfile(ctx, hidden, "", "", models.VisibilityHidden); err != nil {
+		t.Fatalf("UpdateProfile: %v", err)
+	}
+
+	// Выполнения других тестов и бенчмарков могли быть добавлены в обход TaskComplete
+	if err := r.RebuildLeaderboardScores(ctx, time.Now()); err != nil {
+		t.Fatalf("RebuildLeaderboardScores: %v", err)
+	}
+
+	day, _ := models.PeriodStart(models.PeriodDay, time.Now(), time.UTC)
+	periods := []struct {
+		name  string
+		since *time.Time
+	}{
+		{name: models.PeriodDay, since: day},
+		{name: models.PeriodAll},
+	}
+
+	for _, period := range periods {
+		for _, userID := range userIDs {
+			t.Run(fmt.Sprintf("%s/%d", period.name, userID), func(t *testing.T) {
+				entries, err := r.GetLeaderboardNeighbors(ctx, userID, period.name, period.since, 2, false)
+				if err != nil {
+					t.Fatalf("GetLeaderboardNeighbors: %v", err)
+				}
+
+				rows, err := db.Query(ctx, leaderboardNeighborsReference, userID, period.since, 2)
+				if err != nil {
+					t.Fatalf("reference: %v", err)
+				}
+				defer rows.Close()
+
+				want := make([]models.LeaderboardEntry, 0)
+				for rows.Next() {
+					var entry models.LeaderboardEntry
+					if err = rows.Scan(&entry.Rank, &entry.UserID, &entry.Score); err != nil {
+						t.Fatalf("reference: %v", err)
+					}
+					want = append(want, entry)
+				}
+				if err = rows.Err(); err != nil {
+					t.Fatalf("reference: %v", err)
+				}
+
+				if userID == hidden && len(entries) != 0 {
+					t.Errorf("hidden user: got %d entries, want none", len(entries))
+				}
+				if len(entries) != len(want) {
+					t.Fatalf("got %d entries, want %d", len(entries), len(want))
+				}
+				for i, entry := range entries {
+					if entry.Rank != want[i].Rank || entry.UserID != want[i].UserID || entry.Score != want[i].Score {
+						t.Errorf("entry %d = {rank %d, user %d, score %d}, want {rank %d, user %d, score %d}", i,
+							entry.Rank, entry.UserID, entry.Score, want[i].Rank, want[i].UserID, want[i].Score)
+					}
+				}
+			})
+		}
+	}
+}
@@ -0,0 +1,104 @@
+package repository
+
+import (
+	"context"
+	"github.com/Masterminds/squirrel"
+	"github.com/RVodassa/TaskReward/internal/domain/models"
+	"github.com/pkg/errors"
+	"time"
+)
+
+// GetLoginLockedUntil возвращает время окончания самой долгой действующей блокировки входа
+// по логину login или IP адресу ip. nil - вход не заблокирован.
+func (r *Repo) GetLoginLockedUntil(ctx context.Context, login, ip string) (*time.Time, error) {
+	const op = "repository.GetLoginLockedUntil"
+
+	query, args, err := r.builder.
+		Select("MAX(locked_until)").
+		From("login_failures").
+		Where(squirrel.Or{
+			squirrel.Eq{"kind": models.LoginFailureByLogin, "subject": login},
+			squirrel.Eq{"kind": models.LoginFailureByIP, "subject": ip},
+		}).
+		Where(squirrel.Gt{"locked_until": time.Now().UTC()}).
+		ToSql()
+	if err != nil {
+		return nil, errors.Wrap(err, op)
+	}
+
+	var lockedUntil *time.Time
+	if err = r.db.QueryRow(ctx, query, args...).Scan(&lockedUntil); err != nil {
+		return nil, errors.Wrap(err, op)
+	}
+
+	return lockedUntil, nil
+}
+
+// RecordLoginFailure увеличивает счетчик неудачных попыток входа и возвращает его новое значение.
+// Если последняя неудача и блокировка были раньше windowStart, счетчик начинается заново.
+func (r *Repo) RecordLoginFailure(ctx context.Context, kind, subject string, windowStart time.Time) (uint, error) {
+	const op = "repository.RecordLoginFailure"
+
+	query, args, err := r.builder.
+		Insert("login_failures").
+		Columns("kind", "subject", "failures", "last_failure_at").
+		Values(kind, subject, 1, time.Now().UTC()).
+		Suffix(`ON CONFLICT (kind, subject) DO UPDATE SET
+			failures = CASE
+				WHEN GREATEST(login_failures.last_failure_at, COALESCE(login_failures.locked_until, login_failures.last_failure_at)) < ?
+				THEN 1
+				ELSE login_failures.failures + 1
+			END,
+			last_failure_at = EXCLUDED.last_failure_at
+		RETURNING "failures"`, windowStart).
+		ToSql()
+	if err != nil {
+		return 0, errors.Wrap(err, op)
+	}
+
+	var failures uint
+	if err = r.db.QueryRow(ctx, query, args...).Scan(&failures); err != nil {
+		return 0, errors.Wrap(err, op)
+	}
+
+	return failures, nil
+}
+
+// LockLogin блокирует вход по счетчику kind/subject до lockedUntil.
+func (r *Repo) LockLogin(ctx context.Context, kind, subject string, lockedUntil time.Time) error {
+	const op = "repository.LockLogin"
+
+	query, args, err := r.builder.
+		Update("login_failures").
+		Set("locked_until", lockedUntil).
+		Where(squirrel.Eq{"kind": kind, "subject": subject}).
+		ToSql()
+	if err != nil {
+		return errors.Wrap(err, op)
+	}
+
+	if _, err = r.db.Exec(ctx, query, args...); err != nil {
+		return errors.Wrap(err, op)
+	}
+
+	return nil
+}
+
+// ResetLoginFailures удаляет счетчик неудачных попыток входа вместе с блокировкой.
+func (r *Repo) ResetLoginFailures(ctx context.Context, kind, subject string) error {
+	const op = "repository.ResetLoginFailures"
+
+	query, args, err := r.builder.
+		Delete("login_failures").
+		Where(squirrel.Eq{"kind": kind, "subject": subject}).
+		ToSql()
+	if err != nil {
+		return errors.Wrap(err, op)
+	}
+
+	if _, err = r.db.Exec(ctx, query, args...); err != nil {
+		return errors.Wrap(err, op)
+	}
+
+	return nil
+}
@@ -0,0 +1,199 @@
+package repository
+
+import (
+	"context"
+	"github.com/Masterminds/squirrel"
+	"github.com/RVodassa/TaskReward/internal/domain/models"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgconn"
+	"github.com/pkg/errors"
+	"time"
+)
+
+var (
+	ErrPasswordResetTokenInvalid = errors.New("ошибка: токен сброса пароля недействителен")
+)
+
+// UpdateEmail меняет email пользователя, пустой email удаляет его. Подтверждение email
+// сбрасывается, если адрес изменился.
+func (r *Repo) UpdateEmail(ctx context.Context, userID uint, email string) error {
+	const op = "repository.UpdateEmail"
+
+	query, args, err := r.builder.
+		Update("users").
+		Set("email_verified_at", squirrel.Expr("CASE WHEN email IS NOT DISTINCT FROM ? THEN email_verified_at END", nullableString(email))).
+		Set("email", nullableString(email)).
+		Where(squirrel.Eq{"id": userID}).
+		ToSql()
+	if err != nil {
+		return errors.Wrap(err, op)
+	}
+
+	result, err := r.db.Exec(ctx, query, args...)
+	if err != nil {
+		var pgErr *pgconn.PgError
+		if errors.As(err, &pgErr) && pgErr.Code == "23505" && pgErr.ConstraintName == emailUniqueConstraint {
+			return ErrEmailAlreadyUsed
+		}
+		return errors.Wrap(err, op)
+	}
+	if result.RowsAffected() == 0 {
+		return ErrUserNotFound
+	}
+
+	return nil
+}
+
+// AddPasswordResetToken сохраняет токен сброса пароля. Ранее выданные и еще не использованные
+// токены пользователя становятся недействительными, действует только последний.
+func (r *Repo) AddPasswordResetToken(ctx context.Context, token *models.PasswordResetToken) error {
+	const op = "repository.AddPasswordResetToken"
+
+	tx, err := r.db.Begin(ctx)
+	if err != nil {
+		return errors.Wrap(err, op)
+	}
+	defer func() {
+		_ = tx.Rollback(ctx)
+	}()
+
+	if err = r.expirePasswordResetTokens(ctx, tx, token.UserID, token.CreatedAt); err != nil {
+		return errors.Wrap(err, op)
+	}
+
+	query, args, err := r.builder.
+		Insert("password_reset_tokens").
+		Columns("user_id", "token_hash", "expires_at", "created_at").
+		Values(token.UserID, token.TokenHash, token.ExpiresAt, token.CreatedAt).
+		Suffix(`RETURNING "id"`).
+		ToSql()
+	if err != nil {
+		return errors.Wrap(err, op)
+	}
+
+	if err = tx.QueryRow(ctx, query, args...).Scan(&token.ID); err != nil {
+		var pgErr *pgconn.PgError
+		if errors.As(err, &pgErr) && pgErr.Code == "23503" {
+			return ErrUserNotFound
+		}
+		return errors.Wrap(err, op)
+	}
+
+	if err = tx.Commit(ctx); err != nil {
+		return errors.Wrap(err, op)
+	}
+
+	return nil
+}
+
+// GetPasswordResetUserID возвращает ID пользователя, которому выдан действующий токен сброса пароля.
+func (r *Repo) GetPasswordResetUserID(ctx context.Context, tokenHash string) (uint, error) {
+	const op = "repository.GetPasswordResetUserID"
+
+	query, args, err := r.builder.
+		Select("user_id").
+		From("password_reset_tokens").
+		Where(squirrel.Eq{"token_hash": tokenHash, "used_at": nil}).
+		Where(squirrel.Gt{"expires_at": time.Now().UTC()}).
+		ToSql()
+	if err != nil {
+		return 0, errors.Wrap(err, op)
+	}
+
+	var userID uint
+	if err = r.db.QueryRow(ctx, query, args...).Scan(&userID); err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return 0, ErrPasswordResetTokenInvalid
+		}
+		return 0, errors.Wrap(err, op)
+	}
+
+	return userID, nil
+}
+
+// ResetPassword по хэшу токена tokenHash устанавливает новый хэш пароля, отмечает токен
+// использованным и отзывает все токены доступа пользователя. Возвращает ID пользователя.
+func (r *Repo) ResetPassword(ctx context.Context, tokenHash string, passwordHash string) (uint, error) {
+	const op = "repository.ResetPassword"
+
+	tx, err := r.db.Begin(ctx)
+	if err != nil {
+		return 0, errors.Wrap(err, op)
+	}
+	defer func() {
+		_ = tx.Rollback(ctx)
+	}()
+
+	query, args, err := r.builder.
+		Select("id", "user_id", "expires_at", "used_at").
+		From("password_reset_tokens").
+		Where(squirrel.Eq{"token_hash": tokenHash}).
+		Suffix("FOR UPDATE").
+		ToSql()
+	if err != nil {
+		return 0, errors.Wrap(err, op)
+	}
+
+	var token models.PasswordResetToken
+	err = tx.QueryRow(ctx, query, args...).Scan(&token.ID, &token.UserID, &token.ExpiresAt, &token.UsedAt)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return 0, ErrPasswordResetTokenInvalid
+		}
+		return 0, errors.Wrap(err, op)
+	}
+
+	now := time.Now().UTC()
+	if token.UsedAt != nil || !token.ExpiresAt.After(now) {
+		return 0, ErrPasswordResetTokenInvalid
+	}
+
+	if err = r.expirePasswordResetTokens(ctx, tx, token.UserID, now); err != nil {
+		return 0, errors.Wrap(err, op)
+	}
+
+	query, args, err = r.builder.
+		Update("users").
+		Set("password_hash", passwordHash).
+		Where(squirrel.Eq{"id": token.UserID}).
+		ToSql()
+	if err != nil {
+		return 0, errors.Wrap(err, op)
+	}
+	if _, err = tx.Exec(ctx, query, args...); err != nil {
+		return 0, errors.Wrap(err, op)
+	}
+
+	if err = r.revokeUserTokens(ctx, tx, token.UserID); err != nil {
+		if errors.Is(err, ErrUserNotFound) {
+			return 0, ErrPasswordResetTokenInvalid
+		}
+		return 0, errors.Wrap(err, op)
+	}
+
+	if err = tx.Commit(ctx); err != nil {
+		return 0, errors.Wrap(err, op)
+	}
+
+	return token.UserID, nil
+}
+
+// expirePasswordResetTokens отмечает использованными все неиспользованные токены сброса пароля пользователя.
+func (r *Repo) expirePasswordResetTokens(ctx context.Context, tx pgx.Tx, userID uint, now time.Time) error {
+	const op = "repository.expirePasswordResetTokens"
+
+	query, args, err := r.builder.
+		Update("password_reset_tokens").
+		Set("used_at", now).
+		Where(squirrel.Eq{"user_id": userID, "used_at": nil}).
+		ToSql()
+	if err != nil {
+		return errors.Wrap(err, op)
+	}
+
+	if _, err = tx.Exec(ctx, query, args...); err != nil {
+		return errors.Wrap(err, op)
+	}
+
+	return nil
+}
@@ -0,0 +1,64 @@
+package repository
+
+import (
+	"context"
+	"github.com/Masterminds/squirrel"
+	"github.com/pkg/errors"
+	"time"
+)
+
+// UpdateProfile изменяет публичный профиль пользователя: отображаемое имя, аватар и видимость
+// в таблицах лидеров. Пустые displayName и avatarURL удаляют значения.
+func (r *Repo) UpdateProfile(ctx context.Context, userID uint, displayName, avatarURL, visibility string) error {
+	const op = "repository.UpdateProfile"
+
+	query, args, err := r.builder.
+		Update("users").
+		Set("display_name", nullableString(displayName)).
+		Set("avatar_url", nullableString(avatarURL)).
+		Set("leaderboard_visibility", visibility).
+		Where(squirrel.Eq{"id": userID}).
+		ToSql()
+	if err != nil {
+		return errors.Wrap(err, op)
+	}
+
+	result, err := r.db.Exec(ctx, query, args...)
+	if err != nil {
+		return errors.Wrap(err, op)
+	}
+	if result.RowsAffected() == 0 {
+		return ErrUserNotFound
+	}
+
+	return nil
+}
+
+// SetLeaderboardExcluded исключает пользователя из таблиц лидеров и итогов сезонов или возвращает его.
+func (r *Repo) SetLeaderboardExcluded(ctx context.Context, userID uint, excluded bool) error {
+	const op = "repository.SetLeaderboardExcluded"
+
+	var excludedAt interface{}
+	if excluded {
+		excludedAt = time.Now().UTC()
+	}
+
+	query, args, err := r.builder.
+		Update("users").
+		Set("leaderboard_excluded_at", excludedAt).
+		Where(squirrel.Eq{"id": userID}).
+		ToSql()
+	if err != nil {
+		return errors.Wrap(err, op)
+	}
+
+	result, err := r.db.Exec(ctx, query, args...)
+	if err != nil {
+		return errors.Wrap(err, op)
+	}
+	if result.RowsAffected() == 0 {
+		return ErrUserNotFound
+	}
+
+	return nil
+}
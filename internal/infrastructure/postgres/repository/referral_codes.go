@@ -0,0 +1,150 @@
+package repository
+
+import (
+	"context"
+	"github.com/Masterminds/squirrel"
+	"github.com/RVodassa/TaskReward/internal/domain/models"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgconn"
+	"github.com/pkg/errors"
+	"time"
+)
+
+var (
+	ErrReferralCodeExists   = errors.New("ошибка: код приглашения уже существует")
+	ErrReferralCodeNotFound = errors.New("ошибка: код приглашения не найден")
+	ErrReferralCodeInvalid  = errors.New("ошибка: код приглашения недействителен")
+)
+
+// AddReferralCode сохраняет новый код приглашения. При совпадении кода возвращает ErrReferralCodeExists.
+func (r *Repo) AddReferralCode(ctx context.Context, code *models.ReferralCode) error {
+	const op = "repository.AddReferralCode"
+
+	query, args, err := r.builder.
+		Insert("referral_codes").
+		Columns("user_id", "code", "max_uses", "expires_at", "active", "created_at").
+		Values(code.UserID, code.Code, code.MaxUses, code.ExpiresAt, code.Active, code.CreatedAt).
+		Suffix(`RETURNING "id"`).
+		ToSql()
+	if err != nil {
+		return errors.Wrap(err, op)
+	}
+
+	if err = r.db.QueryRow(ctx, query, args...).Scan(&code.ID); err != nil {
+		var pgErr *pgconn.PgError
+		if errors.As(err, &pgErr) && pgErr.Code == "23505" {
+			return ErrReferralCodeExists
+		}
+		return errors.Wrap(err, op)
+	}
+
+	return nil
+}
+
+// GetReferralCodes возвращает коды приглашения пользователя, новые первыми.
+func (r *Repo) GetReferralCodes(ctx context.Context, userID uint) ([]*models.ReferralCode, error) {
+	const op = "repository.GetReferralCodes"
+
+	query, args, err := r.builder.
+		Select("id", "user_id", "code", "max_uses", "uses", "expires_at", "active", "created_at").
+		From("referral_codes").
+		Where(squirrel.Eq{"user_id": userID}).
+		OrderBy("id DESC").
+		ToSql()
+	if err != nil {
+		return nil, errors.Wrap(err, op)
+	}
+
+	rows, err := r.db.Query(ctx, query, args...)
+	if err != nil {
+		return nil, errors.Wrap(err, op)
+	}
+	defer rows.Close()
+
+	codes := make([]*models.ReferralCode, 0)
+	for rows.Next() {
+		code, err := scanReferralCode(rows)
+		if err != nil {
+			return nil, errors.Wrap(err, op)
+		}
+		codes = append(codes, code)
+	}
+
+	if err = rows.Err(); err != nil {
+		return nil, errors.Wrap(err, op)
+	}
+
+	return codes, nil
+}
+
+// DeactivateReferralCode отключает код приглашения codeID, принадлежащий пользователю userID.
+func (r *Repo) DeactivateReferralCode(ctx context.Context, codeID uint, userID uint) (*models.ReferralCode, error) {
+	const op = "repository.DeactivateReferralCode"
+
+	query, args, err := r.builder.
+		Update("referral_codes").
+		Set("active", false).
+		Where(squirrel.Eq{"id": codeID, "user_id": userID}).
+		Suffix(`RETURNING "id", "user_id", "code", "max_uses", "uses", "expires_at", "active", "created_at"`).
+		ToSql()
+	if err != nil {
+		return nil, errors.Wrap(err, op)
+	}
+
+	code, err := scanReferralCode(r.db.QueryRow(ctx, query, args...))
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, ErrReferralCodeNotFound
+		}
+		return nil, errors.Wrap(err, op)
+	}
+
+	return code, nil
+}
+
+// useReferralCode засчитывает регистрацию по коду в рамках транзакции и возвращает ID кода и его владельца.
+// Неактивный, просроченный или исчерпанный код возвращает ErrReferralCodeInvalid.
+func (r *Repo) useReferralCode(ctx context.Context, tx pgx.Tx, code string) (uint, uint, error) {
+	const op = "repository.useReferralCode"
+
+	query, args, err := r.builder.
+		Update("referral_codes").
+		Set("uses", squirrel.Expr("uses + 1")).
+		Where(squirrel.Eq{"code": code, "active": true}).
+		Where("(max_uses = 0 OR uses < max_uses)").
+		Where(squirrel.Or{squirrel.Eq{"expires_at": nil}, squirrel.Gt{"expires_at": time.Now().UTC()}}).
+		Suffix(`RETURNING "id", "user_id"`).
+		ToSql()
+	if err != nil {
+		return 0, 0, errors.Wrap(err, op)
+	}
+
+	var codeID, ownerID uint
+	if err = tx.QueryRow(ctx, query, args...).Scan(&codeID, &ownerID); err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return 0, 0, ErrReferralCodeInvalid
+		}
+		return 0, 0, errors.Wrap(err, op)
+	}
+
+	return codeID, ownerID, nil
+}
+
+// scanReferralCode читает код приглашения из строки результата.
+func scanReferralCode(row pgx.Row) (*models.ReferralCode, error) {
+	code := &models.ReferralCode{}
+	err := row.Scan(
+		&code.ID,
+		&code.UserID,
+		&code.Code,
+		&code.MaxUses,
+		&code.Uses,
+		&code.ExpiresAt,
+		&code.Active,
+		&code.CreatedAt,
+	)
+	if err != nil {
+		return nil, err
+	}
+	return code, nil
+}
@@ -0,0 +1,236 @@
+package repository
+
+import (
+	"context"
+	"fmt"
+	"github.com/Masterminds/squirrel"
+	"github.com/RVodassa/TaskReward/internal/domain/models"
+	"github.com/jackc/pgx/v5"
+	"github.com/pkg/errors"
+)
+
+// referralChainQuery возвращает цепочку пригласивших пользователя $1 не глубже $2 уровней.
+const referralChainQuery = `
+WITH RECURSIVE chain AS (
+    SELECT refer_id AS id, 1 AS level
+    FROM users
+    WHERE id = $1 AND refer_id <> 0
+    UNION ALL
+    SELECT u.refer_id, c.level + 1
+    FROM chain c
+    JOIN users u ON u.id = c.id
+    WHERE u.refer_id <> 0 AND c.level < $2
+)
+SELECT id, level FROM chain ORDER BY level`
+
+// payReferralRewards начисляет вознаграждения пригласившим за выполнение задачи taskID пользователем userID:
+// разовый бонус за первую задачу приглашенного и комиссию с бонуса по уровням реферальной программы.
+func (r *Repo) payReferralRewards(ctx context.Context, tx pgx.Tx, userID uint, taskID uint, bonus uint) error {
+	const op = "repository.payReferralRewards"
+
+	if r.referral.SignupBonus > 0 {
+		if err := r.paySignupBonus(ctx, tx, userID); err != nil {
+			return errors.Wrap(err, op)
+		}
+	}
+
+	if len(r.referral.CommissionPercents) == 0 {
+		return nil
+	}
+
+	rows, err := tx.Query(ctx, referralChainQuery, userID, len(r.referral.CommissionPercents))
+	if err != nil {
+		return errors.Wrap(err, op)
+	}
+	type referrer struct {
+		id    uint
+		level int
+	}
+	var chain []referrer
+	for rows.Next() {
+		var ref referrer
+		if err = rows.Scan(&ref.id, &ref.level); err != nil {
+			rows.Close()
+			return errors.Wrap(err, op)
+		}
+		chain = append(chain, ref)
+	}
+	rows.Close()
+	if err = rows.Err(); err != nil {
+		return errors.Wrap(err, op)
+	}
+
+	for _, ref := range chain {
+		amount := bonus * r.referral.CommissionPercents[ref.level-1] / 100
+		if amount == 0 {
+			continue
+		}
+
+		err = r.ChangeBalance(ctx, tx, &models.BalanceTransaction{
+			UserID:      ref.id,
+			Amount:      int64(amount),
+			Type:        models.TransactionReferralCommission,
+			ReferenceID: taskID,
+			Comment:     fmt.Sprintf("реферал %d, уровень %d", userID, ref.level),
+		})
+		if err != nil {
+			// Пригласивший мог быть удален, остальные уровни начисляются
+			if errors.Is(err, ErrUserNotFound) {
+				continue
+			}
+			return errors.Wrap(err, op)
+		}
+	}
+
+	return nil
+}
+
+// paySignupBonus начисляет пригласившему разовый бонус, если пользователь выполнил первую задачу.
+// Флаг referral_bonus_paid выставляется условным UPDATE, поэтому бонус выплачивается ровно один раз.
+func (r *Repo) paySignupBonus(ctx context.Context, tx pgx.Tx, userID uint) error {
+	const op = "repository.paySignupBonus"
+
+	query, args, err := r.builder.
+		Update("users").
+		Set("referral_bonus_paid", true).
+		Where(squirrel.Eq{"id": userID, "referral_bonus_paid": false}).
+		Where(squirrel.NotEq{"refer_id": 0}).
+		Suffix(`RETURNING "refer_id"`).
+		ToSql()
+	if err != nil {
+		return errors.Wrap(err, op)
+	}
+
+	var referID uint
+	err = tx.QueryRow(ctx, query, args...).Scan(&referID)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil
+		}
+		return errors.Wrap(err, op)
+	}
+
+	err = r.ChangeBalance(ctx, tx, &models.BalanceTransaction{
+		UserID:      referID,
+		Amount:      int64(r.referral.SignupBonus),
+		Type:        models.TransactionReferralBonus,
+		ReferenceID: userID,
+	})
+	if err != nil && !errors.Is(err, ErrUserNotFound) {
+		return errors.Wrap(err, op)
+	}
+
+	return nil
+}
+
+// referralTreeQuery возвращает приглашенных пользователя $1 не глубже $2 уровней, страница $3/$4.
+const referralTreeQuery = `
+WITH RECURSIVE tree AS (
+    SELECT id, login, refer_id, 1 AS level, created_at
+    FROM users
+    WHERE refer_id = $1
+    UNION ALL
+    SELECT u.id, u.login, u.refer_id, t.level + 1, u.created_at
+    FROM tree t
+    JOIN users u ON u.refer_id = t.id
+    WHERE t.level < $2
+)
+SELECT t.id, t.login, t.refer_id, t.level, t.created_at,
+       EXISTS (SELECT 1 FROM task_completions c WHERE c.user_id = t.id)
+FROM tree t
+ORDER BY t.level, t.id
+LIMIT $3 OFFSET $4`
+
+// referralDownlineCountQuery возвращает кол-во приглашенных пользователя $1 не глубже $2 уровней.
+const referralDownlineCountQuery = `
+WITH RECURSIVE tree AS (
+    SELECT id, 1 AS level FROM users WHERE refer_id = $1
+    UNION ALL
+    SELECT u.id, t.level + 1
+    FROM tree t
+    JOIN users u ON u.refer_id = t.id
+    WHERE t.level < $2
+)
+SELECT COUNT(*) FROM tree`
+
+// GetReferralTree возвращает приглашенных пользователя до глубины depth (1 - только прямые приглашения),
+// отсортированных по уровню.
+func (r *Repo) GetReferralTree(ctx context.Context, userID uint, depth uint, limit, offset uint) ([]*models.Referral, error) {
+	const op = "repository.GetReferralTree"
+
+	rows, err := r.db.Query(ctx, referralTreeQuery, userID, depth, limit, offset)
+	if err != nil {
+		return nil, errors.Wrap(err, op)
+	}
+	defer rows.Close()
+
+	referrals := make([]*models.Referral, 0)
+	for rows.Next() {
+		referral := &models.Referral{}
+		err = rows.Scan(
+			&referral.ID,
+			&referral.Login,
+			&referral.ReferID,
+			&referral.Level,
+			&referral.CreatedAt,
+			&referral.Active,
+		)
+		if err != nil {
+			return nil, errors.Wrap(err, op)
+		}
+		referrals = append(referrals, referral)
+	}
+
+	if err = rows.Err(); err != nil {
+		return nil, errors.Wrap(err, op)
+	}
+
+	return referrals, nil
+}
+
+// GetReferralStats возвращает статистику приглашений пользователя, downline считается до глубины depth.
+func (r *Repo) GetReferralStats(ctx context.Context, userID uint, depth uint) (*models.ReferralStats, error) {
+	const op = "repository.GetReferralStats"
+
+	stats := &models.ReferralStats{UserID: userID}
+
+	query, args, err := r.builder.
+		Select("COUNT(*)").
+		Column("COUNT(*) FILTER (WHERE EXISTS (SELECT 1 FROM task_completions c WHERE c.user_id = u.id))").
+		From("users u").
+		Where(squirrel.Eq{"u.refer_id": userID}).
+		ToSql()
+	if err != nil {
+		return nil, errors.Wrap(err, op)
+	}
+	if err = r.db.QueryRow(ctx, query, args...).Scan(&stats.Invited, &stats.ActiveInvited); err != nil {
+		return nil, errors.Wrap(err, op)
+	}
+
+	if err = r.db.QueryRow(ctx, referralDownlineCountQuery, userID, depth).Scan(&stats.Downline); err != nil {
+		return nil, errors.Wrap(err, op)
+	}
+
+	query, args, err = r.builder.
+		Select().
+		Column(squirrel.Expr("COALESCE(SUM(amount) FILTER (WHERE type = ?), 0)", models.TransactionReferralBonus)).
+		Column(squirrel.Expr("COALESCE(SUM(amount) FILTER (WHERE type = ?), 0)", models.TransactionReferralCommission)).
+		From("balance_transactions").
+		Where(squirrel.Eq{"user_id": userID}).
+		ToSql()
+	if err != nil {
+		return nil, errors.Wrap(err, op)
+	}
+	err = r.db.QueryRow(ctx, query, args...).Scan(&stats.SignupBonusEarnings, &stats.CommissionEarnings)
+	if err != nil {
+		return nil, errors.Wrap(err, op)
+	}
+	stats.TotalEarnings = stats.SignupBonusEarnings + stats.CommissionEarnings
+
+	stats.Codes, err = r.GetReferralCodes(ctx, userID)
+	if err != nil {
+		return nil, errors.Wrap(err, op)
+	}
+
+	return stats, nil
+}
@@ -18,32 +18,49 @@ var (
 	ErrUserNotFound         = errors.New("ошибка: пользователь найден")
 	ErrTaskNotFound         = errors.New("ошибка: задача не найдена")
 	ErrTaskAlreadyCompleted = errors.New("ошибка: задача уже выполнена")
+	ErrTaskCompletionLimit  = errors.New("ошибка: достигнут лимит выполнений задачи")
+	ErrTaskArchived         = errors.New("ошибка: задача в архиве")
+	ErrTaskHasCompletions   = errors.New("ошибка: задача уже выполнялась пользователями")
+	ErrInsufficientFunds    = errors.New("ошибка: недостаточно средств на балансе")
+	ErrEmailAlreadyUsed     = errors.New("ошибка: email уже используется другим пользователем")
 )
 
+// emailUniqueConstraint ограничение уникальности email пользователей.
+const emailUniqueConstraint = "users_email_key"
+
 const (
-	StatusTaskClose = "завершено"
-	StatusTaskOpen  = "не завершено"
+	StatusTaskClose    = "завершено"
+	StatusTaskOpen     = "не завершено"
+	StatusTaskArchived = "в архиве"
 )
 
 type Repo struct {
-	db      *pgxpool.Pool
-	builder squirrel.StatementBuilderType
+	db       *pgxpool.Pool
+	builder  squirrel.StatementBuilderType
+	referral models.ReferralProgram
+	location *time.Location
 }
 
-func NewRepo(db *pgxpool.Pool) *Repo {
+// NewRepo создает репозиторий. location - часовой пояс, в котором считаются границы периодов
+// таблиц лидеров.
+func NewRepo(db *pgxpool.Pool, referral models.ReferralProgram, location *time.Location) *Repo {
 	return &Repo{
-		db:      db,
-		builder: squirrel.StatementBuilder.PlaceholderFormat(squirrel.Dollar),
+		db:       db,
+		builder:  squirrel.StatementBuilder.PlaceholderFormat(squirrel.Dollar),
+		referral: referral,
+		location: location,
 	}
 }
 
-func (r *Repo) GetAllActiveTask(ctx context.Context) ([]*models.Task, error) {
+func (r *Repo) GetAllActiveTask(ctx context.Context, userID uint) ([]*models.Task, error) {
 	const op = "repository.GetAllActiveTask"
 
 	query, args, err := r.builder.
-		Select("id", "description", "bonus").
-		From("tasks").
-		Where(squirrel.Eq{"status": StatusTaskOpen}).
+		Select("t.id", "t.description", "t.bonus", "t.max_completions").
+		Column(squirrel.Expr("EXISTS (SELECT 1 FROM task_completions c WHERE c.task_id = t.id AND c.user_id = ?)", userID)).
+		From("tasks t").
+		Where(squirrel.Eq{"t.status": StatusTaskOpen}).
+		OrderBy("t.id").
 		ToSql()
 
 	if err != nil {
@@ -59,7 +76,7 @@ func (r *Repo) GetAllActiveTask(ctx context.Context) ([]*models.Task, error) {
 	tasks := make([]*models.Task, 0)
 	for rows.Next() {
 		task := &models.Task{}
-		if err = rows.Scan(&task.ID, &task.Description, &task.Bonus); err != nil {
+		if err = rows.Scan(&task.ID, &task.Description, &task.Bonus, &task.MaxCompletions, &task.Completed); err != nil {
 			return nil, errors.Wrap(err, op)
 		}
 		tasks = append(tasks, task)
@@ -71,16 +88,33 @@ func (r *Repo) GetAllActiveTask(ctx context.Context) ([]*models.Task, error) {
 	return tasks, nil
 }
 
-func (r *Repo) GetListTopUsers(ctx context.Context) ([]*models.User, error) {
+// GetListTopUsers возвращает limit лидеров по сумме бонусов за задачи, выполненные начиная с since
+// (nil - за все время). Пользователи с одинаковой суммой делят место (DENSE_RANK), verifiedOnly
+// оставляет только пользователей с подтвержденным email. Скрывшие себя и исключенные администратором
+// пользователи в таблицу не попадают.
+func (r *Repo) GetListTopUsers(ctx context.Context, since *time.Time, limit uint, verifiedOnly bool) ([]*models.LeaderboardEntry, error) {
 	const op = "repository.GetListTopUsers"
 
+	scores := squirrel.
+		Select("c.user_id", "SUM(c.bonus_awarded) AS score").
+		Columns(leaderboardProfileColumns...).
+		From("task_completions c").
+		Join("users u ON u.id = c.user_id").
+		Where(rankedUserCondition).
+		GroupBy("c.user_id", "u.id")
+	if since != nil {
+		scores = scores.Where(squirrel.GtOrEq{"c.completed_at": *since})
+	}
+	if verifiedOnly {
+		scores = scores.Where(squirrel.NotEq{"u.email_verified_at": nil})
+	}
+
 	query, args, err := r.builder.
-		Select("id", "balance").
-		From("users").
-		OrderBy("balance DESC").
-		Limit(10).
+		Select("DENSE_RANK() OVER (ORDER BY score DESC)", "user_id", "score", "display_name", "avatar_url", "visibility").
+		FromSelect(scores, "s").
+		OrderBy("score DESC", "user_id").
+		Limit(uint64(limit)).
 		ToSql()
-
 	if err != nil {
 		return nil, errors.Wrap(err, op)
 	}
@@ -91,22 +125,26 @@ func (r *Repo) GetListTopUsers(ctx context.Context) ([]*models.User, error) {
 	}
 	defer rows.Close()
 
-	var users []*models.User
+	entries := make([]*models.LeaderboardEntry, 0)
 	for rows.Next() {
-		var user models.User
-		if err = rows.Scan(&user.ID, &user.Balance); err != nil {
+		var entry models.LeaderboardEntry
+		err = rows.Scan(&entry.Rank, &entry.UserID, &entry.Score, &entry.DisplayName, &entry.AvatarURL, &entry.Visibility)
+		if err != nil {
 			return nil, errors.Wrap(err, op)
 		}
-		users = append(users, &user)
+		entries = append(entries, &entry)
 	}
 
 	if err = rows.Err(); err != nil {
 		return nil, errors.Wrap(err, op)
 	}
 
-	return users, nil
+	return entries, nil
 }
 
+// TaskComplete фиксирует выполнение задачи пользователем и начисляет бонус.
+// Каждый пользователь может выполнить задачу только один раз, при достижении
+// лимита max_completions задача закрывается для всех.
 func (r *Repo) TaskComplete(ctx context.Context, taskID uint, userID uint) (*models.Task, error) {
 	const op = "repository.TaskComplete"
 
@@ -121,60 +159,123 @@ func (r *Repo) TaskComplete(ctx context.Context, taskID uint, userID uint) (*mod
 	if err != nil {
 		return nil, errors.Wrap(err, op)
 	}
-
 	defer func() {
-		if err != nil {
-			tx.Rollback(ctx)
-		}
+		_ = tx.Rollback(ctx)
 	}()
 
-	var currentStatus string
-	query, args, err := r.builder.Select("status").From("tasks").Where(squirrel.Eq{"id": taskID}).ToSql()
+	// Блокируем строку задачи до конца транзакции: параллельные выполнения этой задачи
+	// ждут друг друга, поэтому проверка статуса и лимита не устаревает до фиксации
+	var task models.Task
+	query, args, err := r.builder.
+		Select("id", "status", "description", "bonus", "max_completions", "created_at").
+		From("tasks").
+		Where(squirrel.Eq{"id": taskID}).
+		Suffix("FOR UPDATE").
+		ToSql()
 	if err != nil {
 		return nil, errors.Wrap(err, op)
 	}
-	err = tx.QueryRow(ctx, query, args...).Scan(&currentStatus)
+	err = tx.QueryRow(ctx, query, args...).Scan(&task.ID, &task.Status, &task.Description, &task.Bonus, &task.MaxCompletions, &task.CreatedAt)
 	if err != nil {
 		if errors.Is(err, pgx.ErrNoRows) {
 			return nil, ErrTaskNotFound
 		}
 		return nil, errors.Wrap(err, op)
 	}
-	if currentStatus != StatusTaskOpen {
-		return nil, ErrTaskAlreadyCompleted
+	switch task.Status {
+	case StatusTaskOpen:
+	case StatusTaskArchived:
+		return nil, ErrTaskArchived
+	default:
+		return nil, ErrTaskCompletionLimit
 	}
 
-	// Обновляет статус задачи
-	query, args, err = r.builder.Update("tasks").
-		Set("user_id", userID).
-		Set("completed_at", time.Now().UTC()).
-		Set("status", StatusTaskClose).
-		Where(squirrel.Eq{"id": taskID}).
-		Suffix(`RETURNING "id", "user_id", "status", "description", "bonus", "completed_at", "created_at"`).
-		ToSql()
+	// Проверяем лимит выполнений
+	var completions uint
+	if task.MaxCompletions > 0 {
+		completions, err = r.countTaskCompletions(ctx, tx, taskID)
+		if err != nil {
+			return nil, errors.Wrap(err, op)
+		}
+		if completions >= task.MaxCompletions {
+			return nil, ErrTaskCompletionLimit
+		}
+	}
 
+	// Фиксируем выполнение задачи пользователем
+	query, args, err = r.builder.
+		Insert("task_completions").
+		Columns("task_id", "user_id", "completed_at", "bonus_awarded").
+		Values(taskID, userID, time.Now().UTC(), task.Bonus).
+		Suffix(`RETURNING "user_id", "completed_at"`).
+		ToSql()
 	if err != nil {
 		return nil, errors.Wrap(err, op)
 	}
 
-	var task models.Task
-	err = tx.QueryRow(ctx, query, args...).Scan(&task.ID, &task.UserID, &task.Status, &task.Description, &task.Bonus, &task.CompletedAt, &task.CreatedAt)
+	err = tx.QueryRow(ctx, query, args...).Scan(&task.UserID, &task.CompletedAt)
 	if err != nil {
-		if errors.Is(err, pgx.ErrNoRows) {
-			return nil, ErrTaskNotFound
-		}
 		var pgErr *pgconn.PgError
-		if errors.As(err, &pgErr) && pgErr.Code == "23503" {
-			return nil, ErrUserNotFound
+		if errors.As(err, &pgErr) {
+			switch pgErr.Code {
+			case "23505":
+				return nil, ErrTaskAlreadyCompleted
+			case "23503":
+				return nil, ErrUserNotFound
+			}
 		}
 		return nil, errors.Wrap(err, op)
 	}
+	task.Completed = true
 
-	err = r.IncreaseUserBalance(ctx, tx, userID, task.Bonus)
+	// Закрываем задачу, если лимит выполнений исчерпан.
+	// Условие по статусу гарантирует, что закрывается только еще открытая задача
+	if task.MaxCompletions > 0 && completions+1 >= task.MaxCompletions {
+		query, args, err = r.builder.Update("tasks").
+			Set("status", StatusTaskClose).
+			Where(squirrel.Eq{"id": taskID, "status": StatusTaskOpen}).
+			ToSql()
+		if err != nil {
+			return nil, errors.Wrap(err, op)
+		}
+		result, err := tx.Exec(ctx, query, args...)
+		if err != nil {
+			return nil, errors.Wrap(err, op)
+		}
+		if result.RowsAffected() == 0 {
+			return nil, ErrTaskCompletionLimit
+		}
+		task.Status = StatusTaskClose
+	}
+
+	err = r.ChangeBalance(ctx, tx, &models.BalanceTransaction{
+		UserID:      userID,
+		Amount:      int64(task.Bonus),
+		Type:        models.TransactionTaskBonus,
+		ReferenceID: taskID,
+	})
 	if err != nil {
 		return nil, errors.Wrap(err, op)
 	}
 
+	// Баллы сезона начисляются отдельно от баланса и не уменьшаются при обмене на награды
+	if err = r.addSeasonScore(ctx, tx, userID, task.Bonus, *task.CompletedAt); err != nil {
+		return nil, errors.Wrap(err, op)
+	}
+
+	if err = r.addLeaderboardScore(ctx, tx, userID, task.Bonus, *task.CompletedAt); err != nil {
+		return nil, errors.Wrap(err, op)
+	}
+
+	// Вознаграждения пригласившим начисляются в той же транзакции
+	if err = r.payReferralRewards(ctx, tx, userID, taskID, task.Bonus); err != nil {
+		return nil, errors.Wrap(err, op)
+	}
+
+	if err = r.notifyLeaderboardScore(ctx, tx, userID, task.Bonus, *task.CompletedAt); err != nil {
+		return nil, errors.Wrap(err, op)
+	}
+
 	if err = tx.Commit(ctx); err != nil {
 		return nil, errors.Wrap(err, op)
 	}
@@ -182,32 +283,25 @@ func (r *Repo) TaskComplete(ctx context.Context, taskID uint, userID uint) (*mod
 	return &task, nil
 }
 
-func (r *Repo) IncreaseUserBalance(ctx context.Context, tx pgx.Tx, userID uint, amount uint) error {
-	const op = "repository.IncreaseUserBalance"
-
-	if amount <= 0 {
-		return errors.New("invalid amount: amount must be positive")
-	}
+// countTaskCompletions возвращает кол-во выполнений задачи.
+func (r *Repo) countTaskCompletions(ctx context.Context, tx pgx.Tx, taskID uint) (uint, error) {
+	const op = "repository.countTaskCompletions"
 
 	query, args, err := r.builder.
-		Update("users").
-		Set("balance", squirrel.Expr("balance + ?", amount)).
-		Where("id = ?", userID).
+		Select("COUNT(*)").
+		From("task_completions").
+		Where(squirrel.Eq{"task_id": taskID}).
 		ToSql()
-
 	if err != nil {
-		return errors.Wrap(err, op)
+		return 0, errors.Wrap(err, op)
 	}
 
-	_, err = tx.Exec(ctx, query, args...)
-	if err != nil {
-		if errors.Is(err, pgx.ErrNoRows) {
-			return ErrUserNotFound
-		}
-		return errors.Wrap(err, op)
+	var count uint
+	if err = tx.QueryRow(ctx, query, args...).Scan(&count); err != nil {
+		return 0, errors.Wrap(err, op)
 	}
 
-	return nil
+	return count, nil
 }
 
 func (r *Repo) AddTask(ctx context.Context, task *models.Task) error {
@@ -215,8 +309,8 @@ func (r *Repo) AddTask(ctx context.Context, task *models.Task) error {
 
 	query, args, err := r.builder.
 		Insert("tasks").
-		Columns("description", "bonus", "created_at", "status").
-		Values(task.Description, task.Bonus, task.CreatedAt, StatusTaskOpen).
+		Columns("description", "bonus", "max_completions", "created_at", "status").
+		Values(task.Description, task.Bonus, task.MaxCompletions, task.CreatedAt, StatusTaskOpen).
 		Suffix(`RETURNING "id"`).
 		ToSql()
 
@@ -228,6 +322,7 @@ func (r *Repo) AddTask(ctx context.Context, task *models.Task) error {
 	if err != nil {
 		return errors.Wrap(err, op)
 	}
+	task.Status = StatusTaskOpen
 
 	return nil
 }
@@ -235,41 +330,55 @@ func (r *Repo) AddTask(ctx context.Context, task *models.Task) error {
 func (r *Repo) GetUserByID(ctx context.Context, id uint) (*models.User, error) {
 	const op = "repository.GetUserByID"
 
-	query, args, err := r.builder.
-		Select("login", "password_hash", "id", "refer_id", "balance", "created_at").
-		From("users").
-		Where("id = ?", id).ToSql()
+	user, err := r.getUser(ctx, squirrel.Eq{"id": id})
+	if err != nil {
+		if errors.Is(err, ErrUserNotFound) {
+			return nil, err
+		}
+		return nil, errors.Wrap(err, op)
+	}
+	return user, nil
+}
 
+func (r *Repo) GetUserByLogin(ctx context.Context, login string) (*models.User, error) {
+	const op = "repository.GetUserByLogin"
+
+	user, err := r.getUser(ctx, squirrel.Eq{"login": login})
 	if err != nil {
+		if errors.Is(err, ErrUserNotFound) {
+			return nil, err
+		}
 		return nil, errors.Wrap(err, op)
 	}
 
-	var user models.User
-	err = r.db.QueryRow(ctx, query, args...).Scan(
-		&user.Login,
-		&user.PasswordHash,
-		&user.ID,
-		&user.ReferID,
-		&user.Balance,
-		&user.CreatedAt,
-	)
+	return user, nil
+}
+
+// GetUserByEmail возвращает пользователя по email (email хранится в нижнем регистре).
+func (r *Repo) GetUserByEmail(ctx context.Context, email string) (*models.User, error) {
+	const op = "repository.GetUserByEmail"
+
+	user, err := r.getUser(ctx, squirrel.Eq{"email": email})
 	if err != nil {
-		if errors.Is(err, pgx.ErrNoRows) {
-			return nil, ErrUserNotFound
+		if errors.Is(err, ErrUserNotFound) {
+			return nil, err
 		}
 		return nil, errors.Wrap(err, op)
 	}
-	return &user, nil
+
+	return user, nil
 }
 
-func (r *Repo) GetUserByLogin(ctx context.Context, login string) (*models.User, error) {
-	const op = "repository.GetUserByLogin"
+// getUser возвращает одного пользователя по условию pred.
+func (r *Repo) getUser(ctx context.Context, pred interface{}) (*models.User, error) {
+	const op = "repository.getUser"
 
 	query, args, err := r.builder.
-		Select("login", "password_hash", "id", "refer_id", "balance", "created_at").
+		Select("login", "COALESCE(email, '')", "email_verified_at IS NOT NULL", "password_hash", "id", "refer_id", "balance", "role", "created_at", "token_version", "banned_at", "totp_enabled").
+		Columns("COALESCE(display_name, '')", "COALESCE(avatar_url, '')", "leaderboard_visibility", "leaderboard_excluded_at IS NOT NULL").
 		From("users").
-		Where("login = ?", login).ToSql()
-
+		Where(pred).
+		ToSql()
 	if err != nil {
 		return nil, errors.Wrap(err, op)
 	}
@@ -277,11 +386,21 @@ func (r *Repo) GetUserByLogin(ctx context.Context, login string) (*models.User,
 	var user models.User
 	err = r.db.QueryRow(ctx, query, args...).Scan(
 		&user.Login,
+		&user.Email,
+		&user.Verified,
 		&user.PasswordHash,
 		&user.ID,
 		&user.ReferID,
 		&user.Balance,
+		&user.Role,
 		&user.CreatedAt,
+		&user.TokenVersion,
+		&user.BannedAt,
+		&user.TOTPEnabled,
+		&user.DisplayName,
+		&user.AvatarURL,
+		&user.LeaderboardVisibility,
+		&user.LeaderboardExcluded,
 	)
 	if err != nil {
 		if errors.Is(err, pgx.ErrNoRows) {
@@ -293,7 +412,9 @@ func (r *Repo) GetUserByLogin(ctx context.Context, login string) (*models.User,
 	return &user, nil
 }
 
-func (r *Repo) RegisterUser(ctx context.Context, user *models.User) error {
+// RegisterUser создает пользователя. Если указан referralCode, владелец кода становится пригласившим
+// (user.ReferID), а регистрация засчитывается коду в той же транзакции.
+func (r *Repo) RegisterUser(ctx context.Context, user *models.User, referralCode string) error {
 	const op = "repository.RegisterUser"
 
 	// Проверка входных данных
@@ -307,9 +428,7 @@ func (r *Repo) RegisterUser(ctx context.Context, user *models.User) error {
 		return errors.Wrap(err, op)
 	}
 	defer func() {
-		if err != nil {
-			tx.Rollback(ctx)
-		}
+		_ = tx.Rollback(ctx)
 	}()
 
 	// Проверяет существование пользователя с id = refer_id
@@ -323,11 +442,27 @@ func (r *Repo) RegisterUser(ctx context.Context, user *models.User) error {
 		}
 	}
 
+	// Засчитывает использование кода приглашения
+	var codeID uint
+	if referralCode != "" {
+		codeID, user.ReferID, err = r.useReferralCode(ctx, tx, referralCode)
+		if err != nil {
+			if errors.Is(err, ErrReferralCodeInvalid) {
+				return err
+			}
+			return errors.Wrap(err, op)
+		}
+	}
+
+	if user.Role == "" {
+		user.Role = models.RoleUser
+	}
+
 	// Вставляем нового пользователя
 	query, args, err := r.builder.
 		Insert("users").
-		Columns("login", "password_hash", "refer_id", "created_at").
-		Values(user.Login, user.PasswordHash, user.ReferID, user.CreatedAt).
+		Columns("login", "email", "password_hash", "refer_id", "referral_code_id", "role", "created_at").
+		Values(user.Login, nullableString(user.Email), user.PasswordHash, user.ReferID, nullableID(codeID), user.Role, user.CreatedAt).
 		Suffix(`RETURNING "id"`).
 		ToSql()
 
@@ -339,6 +474,9 @@ func (r *Repo) RegisterUser(ctx context.Context, user *models.User) error {
 	if err != nil {
 		var pgErr *pgconn.PgError
 		if errors.As(err, &pgErr) && pgErr.Code == "23505" {
+			if pgErr.ConstraintName == emailUniqueConstraint {
+				return ErrEmailAlreadyUsed
+			}
 			return fmt.Errorf("%w: login %s already exists", ErrUserAlreadyExist, user.Login)
 		}
 		return errors.Wrap(err, op)
@@ -351,3 +489,27 @@ func (r *Repo) RegisterUser(ctx context.Context, user *models.User) error {
 
 	return nil
 }
+
+// SetUserRole изменяет роль пользователя.
+func (r *Repo) SetUserRole(ctx context.Context, userID uint, role string) error {
+	const op = "repository.SetUserRole"
+
+	query, args, err := r.builder.
+		Update("users").
+		Set("role", role).
+		Where(squirrel.Eq{"id": userID}).
+		ToSql()
+	if err != nil {
+		return errors.Wrap(err, op)
+	}
+
+	result, err := r.db.Exec(ctx, query, args...)
+	if err != nil {
+		return errors.Wrap(err, op)
+	}
+	if result.RowsAffected() == 0 {
+		return ErrUserNotFound
+	}
+
+	return nil
+}
@@ -0,0 +1,212 @@
+package repository
+
+import (
+	"context"
+	"fmt"
+	"github.com/RVodassa/TaskReward/internal/domain/models"
+	"github.com/golang-migrate/migrate/v4"
+	_ "github.com/golang-migrate/migrate/v4/database/postgres"
+	_ "github.com/golang-migrate/migrate/v4/source/file"
+	"github.com/jackc/pgx/v5/pgxpool"
+	"github.com/pkg/errors"
+	"os"
+	"sync"
+	"testing"
+	"time"
+)
+
+// testDatabaseURL переменная окружения с адресом тестовой базы данных. Тесты и бенчмарки
+// с базой данных пропускаются, если она не задана.
+const testDatabaseURL = "TEST_DATABASE_URL"
+
+// newTestRepo подключается к тестовой базе данных и применяет миграции.
+func newTestRepo(tb testing.TB) (*Repo, *pgxpool.Pool) {
+	tb.Helper()
+
+	url := os.Getenv(testDatabaseURL)
+	if url == "" {
+		tb.Skipf("%s не задана", testDatabaseURL)
+	}
+
+	m, err := migrate.New("file://../../../../migrations", url)
+	if err != nil {
+		tb.Fatalf("migrate.New: %v", err)
+	}
+	if err = m.Up(); err != nil && !errors.Is(err, migrate.ErrNoChange) {
+		tb.Fatalf("migrate.Up: %v", err)
+	}
+	_, _ = m.Close()
+
+	db, err := pgxpool.New(context.Background(), url)
+	if err != nil {
+		tb.Fatalf("pgxpool.New: %v", err)
+	}
+	tb.Cleanup(db.Close)
+
+	return NewRepo(db, models.ReferralProgram{}, time.UTC), db
+}
+
+// createTestUsers создает count пользователей с уникальными логинами и возвращает их ID.
+func createTestUsers(tb testing.TB, db *pgxpool.Pool, count int) []uint {
+	tb.Helper()
+
+	prefix := fmt.Sprintf("test_%d", time.Now().UnixNano())
+	rows, err := db.Query(context.Background(), `
+		INSERT INTO users (login, password_hash)
+		SELECT $1 || '_' || i, 'hash' FROM generate_series(1, $2::int) i
+		RETURNING id`, prefix, count)
+	if err != nil {
+		tb.Fatalf("create users: %v", err)
+	}
+	defer rows.Close()
+
+	ids := make([]uint, 0, count)
+	for rows.Next() {
+		var id uint
+		if err = rows.Scan(&id); err != nil {
+			tb.Fatalf("create users: %v", err)
+		}
+		ids = append(ids, id)
+	}
+	if err = rows.Err(); err != nil {
+		tb.Fatalf("create users: %v", err)
+	}
+
+	return ids
+}
+
+// createTestTask создает открытую задачу с бонусом bonus и лимитом выполнений maxCompletions.
+func createTestTask(tb testing.TB, r *Repo, bonus, maxCompletions uint) *models.Task {
+	tb.Helper()
+
+	task := models.NewTask("concurrency test", bonus)
+	task.MaxCompletions = maxCompletions
+	if err := r.AddTask(context.Background(), task); err != nil {
+		tb.Fatalf("AddTask: %v", err)
+	}
+	return task
+}
+
+// completeConcurrently выполняет задачу taskID параллельно для каждого пользователя из userIDs
+// и возвращает кол-во успешных выполнений. Ожидаемые отказы не считаются ошибками.
+func completeConcurrently(t *testing.T, r *Repo, taskID uint, userIDs []uint) int {
+	t.Helper()
+
+	var (
+		wg        sync.WaitGroup
+		mu        sync.Mutex
+		succeeded int
+	)
+	start := make(chan struct{})
+	for _, userID := range userIDs {
+		wg.Add(1)
+		go func(userID uint) {
+			defer wg.Done()
+			<-start
+
+			_, err := r.TaskComplete(context.Background(), taskID, userID)
+			switch {
+			case err == nil:
+				mu.Lock()
+				succeeded++
+				mu.Unlock()
+			case errors.Is(err, ErrTaskAlreadyCompleted), errors.Is(err, ErrTaskCompletionLimit):
+			default:
+				t.Errorf("TaskComplete(%d, %d): %v", taskID, userID, err)
+			}
+		}(userID)
+	}
+	close(start)
+	wg.Wait()
+
+	return succeeded
+}
+
+// taskCompletionState возвращает кол-во выполнений задачи пользователем, кол-во начислений бонуса
+// за задачу в истории баланса и баланс пользователя.
+func taskCompletionState(t *testing.T, db *pgxpool.Pool, taskID, userID uint) (completions, transactions, balance int) {
+	t.Helper()
+
+	err := db.QueryRow(context.Background(), `
+		SELECT (SELECT COUNT(*) FROM task_completions WHERE task_id = $1 AND user_id = $2),
+		       (SELECT COUNT(*) FROM balance_transactions WHERE reference_id = $1 AND user_id = $2 AND type = $3),
+		       (SELECT balance FROM users WHERE id = $2)`,
+		taskID, userID, models.TransactionTaskBonus).Scan(&completions, &transactions, &balance)
+	if err != nil {
+		t.Fatalf("task completion state: %v", err)
+	}
+	return completions, transactions, balance
+}
+
+func TestTaskCompleteConcurrentSameUser(t *testing.T) {
+	r, db := newTestRepo(t)
+
+	const (
+		workers = 20
+		bonus   = 30
+	)
+	userID := createTestUsers(t, db, 1)[0]
+	task := createTestTask(t, r, bonus, 0)
+
+	userIDs := make([]uint, workers)
+	for i := range userIDs {
+		userIDs[i] = userID
+	}
+
+	if succeeded := completeConcurrently(t, r, task.ID, userIDs); succeeded != 1 {
+		t.Errorf("succeeded = %d, want 1", succeeded)
+	}
+
+	completions, transactions, balance := taskCompletionState(t, db, task.ID, userID)
+	if completions != 1 {
+		t.Errorf("task_completions = %d, want 1", completions)
+	}
+	if transactions != 1 {
+		t.Errorf("balance_transactions = %d, want 1", transactions)
+	}
+	if balance != bonus {
+		t.Errorf("balance = %d, want %d", balance, bonus)
+	}
+}
+
+func TestTaskCompleteConcurrentCompletionLimit(t *testing.T) {
+	r, db := newTestRepo(t)
+
+	const (
+		users          = 30
+		maxCompletions = 5
+		bonus          = 10
+	)
+	userIDs := createTestUsers(t, db, users)
+	task := createTestTask(t, r, bonus, maxCompletions)
+
+	if succeeded := completeConcurrently(t, r, task.ID, userIDs); succeeded != maxCompletions {
+		t.Errorf("succeeded = %d, want %d", succeeded, maxCompletions)
+	}
+
+	var total int
+	for _, userID := range userIDs {
+		completions, transactions, balance := taskCompletionState(t, db, task.ID, userID)
+		if completions > 1 {
+			t.Errorf("user %d: task_completions = %d, want at most 1", userID, completions)
+		}
+		if transactions != completions {
+			t.Errorf("user %d: balance_transactions = %d, want %d", userID, transactions, completions)
+		}
+		if balance != completions*bonus {
+			t.Errorf("user %d: balance = %d, want %d", userID, balance, completions*bonus)
+		}
+		total += completions
+	}
+	if total != maxCompletions {
+		t.Errorf("total task_completions = %d, want %d", total, maxCompletions)
+	}
+
+	var status string
+	if err := db.QueryRow(context.Background(), "SELECT status FROM tasks WHERE id = $1", task.ID).Scan(&status); err != nil {
+		t.Fatalf("task status: %v", err)
+	}
+	if status != StatusTaskClose {
+		t.Errorf("status = %q, want %q", status, StatusTaskClose)
+	}
+}
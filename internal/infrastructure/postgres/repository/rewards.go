@@ -0,0 +1,461 @@
+package repository
+
+import (
+	"context"
+	"github.com/Masterminds/squirrel"
+	"github.com/RVodassa/TaskReward/internal/domain/models"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgconn"
+	"github.com/pkg/errors"
+	"time"
+)
+
+var (
+	ErrRewardNotFound   = errors.New("ошибка: награда не найдена")
+	ErrRewardOutOfStock = errors.New("ошибка: награда закончилась")
+	ErrRewardHasOrders  = errors.New("ошибка: на награду уже есть заказы")
+	ErrOrderNotFound    = errors.New("ошибка: заказ не найден")
+	ErrOrderNotPending  = errors.New("ошибка: заказ уже обработан")
+)
+
+var (
+	rewardColumns      = []string{"id", "name", "description", "cost", "stock", "active", "created_at"}
+	rewardOrderColumns = []string{"o.id", "o.user_id", "o.reward_id", "w.name", "o.cost", "o.status", "o.created_at", "o.updated_at"}
+)
+
+const rewardOrderJoinTable = "reward_orders o JOIN rewards w ON w.id = o.reward_id"
+
+// AddReward добавляет награду в каталог.
+func (r *Repo) AddReward(ctx context.Context, reward *models.Reward) error {
+	const op = "repository.AddReward"
+
+	query, args, err := r.builder.
+		Insert("rewards").
+		Columns("name", "description", "cost", "stock", "active", "created_at").
+		Values(reward.Name, reward.Description, reward.Cost, reward.Stock, reward.Active, reward.CreatedAt).
+		Suffix(`RETURNING "id"`).
+		ToSql()
+	if err != nil {
+		return errors.Wrap(err, op)
+	}
+
+	if err = r.db.QueryRow(ctx, query, args...).Scan(&reward.ID); err != nil {
+		return errors.Wrap(err, op)
+	}
+
+	return nil
+}
+
+// GetRewardByID возвращает награду по ID.
+func (r *Repo) GetRewardByID(ctx context.Context, rewardID uint) (*models.Reward, error) {
+	const op = "repository.GetRewardByID"
+
+	query, args, err := r.builder.
+		Select(rewardColumns...).
+		From("rewards").
+		Where(squirrel.Eq{"id": rewardID}).
+		ToSql()
+	if err != nil {
+		return nil, errors.Wrap(err, op)
+	}
+
+	var reward models.Reward
+	err = r.db.QueryRow(ctx, query, args...).Scan(
+		&reward.ID,
+		&reward.Name,
+		&reward.Description,
+		&reward.Cost,
+		&reward.Stock,
+		&reward.Active,
+		&reward.CreatedAt,
+	)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, ErrRewardNotFound
+		}
+		return nil, errors.Wrap(err, op)
+	}
+
+	return &reward, nil
+}
+
+// GetRewards возвращает каталог наград, onlyActive оставляет только доступные для обмена.
+func (r *Repo) GetRewards(ctx context.Context, onlyActive bool, limit, offset uint) ([]*models.Reward, error) {
+	const op = "repository.GetRewards"
+
+	builder := r.builder.
+		Select(rewardColumns...).
+		From("rewards").
+		OrderBy("id").
+		Limit(uint64(limit)).
+		Offset(uint64(offset))
+	if onlyActive {
+		builder = builder.Where(squirrel.Eq{"active": true})
+	}
+
+	query, args, err := builder.ToSql()
+	if err != nil {
+		return nil, errors.Wrap(err, op)
+	}
+
+	rows, err := r.db.Query(ctx, query, args...)
+	if err != nil {
+		return nil, errors.Wrap(err, op)
+	}
+	defer rows.Close()
+
+	rewards := make([]*models.Reward, 0)
+	for rows.Next() {
+		reward := &models.Reward{}
+		err = rows.Scan(
+			&reward.ID,
+			&reward.Name,
+			&reward.Description,
+			&reward.Cost,
+			&reward.Stock,
+			&reward.Active,
+			&reward.CreatedAt,
+		)
+		if err != nil {
+			return nil, errors.Wrap(err, op)
+		}
+		rewards = append(rewards, reward)
+	}
+
+	if err = rows.Err(); err != nil {
+		return nil, errors.Wrap(err, op)
+	}
+
+	return rewards, nil
+}
+
+// UpdateReward обновляет награду в каталоге.
+func (r *Repo) UpdateReward(ctx context.Context, reward *models.Reward) error {
+	const op = "repository.UpdateReward"
+
+	query, args, err := r.builder.
+		Update("rewards").
+		Set("name", reward.Name).
+		Set("description", reward.Description).
+		Set("cost", reward.Cost).
+		Set("stock", reward.Stock).
+		Set("active", reward.Active).
+		Where(squirrel.Eq{"id": reward.ID}).
+		ToSql()
+	if err != nil {
+		return errors.Wrap(err, op)
+	}
+
+	result, err := r.db.Exec(ctx, query, args...)
+	if err != nil {
+		return errors.Wrap(err, op)
+	}
+	if result.RowsAffected() == 0 {
+		return ErrRewardNotFound
+	}
+
+	return nil
+}
+
+// DeleteReward удаляет награду, на которую еще не было заказов.
+func (r *Repo) DeleteReward(ctx context.Context, rewardID uint) error {
+	const op = "repository.DeleteReward"
+
+	query, args, err := r.builder.
+		Delete("rewards").
+		Where(squirrel.Eq{"id": rewardID}).
+		ToSql()
+	if err != nil {
+		return errors.Wrap(err, op)
+	}
+
+	result, err := r.db.Exec(ctx, query, args...)
+	if err != nil {
+		var pgErr *pgconn.PgError
+		if errors.As(err, &pgErr) && pgErr.Code == "23503" {
+			return ErrRewardHasOrders
+		}
+		return errors.Wrap(err, op)
+	}
+	if result.RowsAffected() == 0 {
+		return ErrRewardNotFound
+	}
+
+	return nil
+}
+
+// RedeemReward обменивает баллы пользователя на награду: в одной транзакции уменьшает остаток награды,
+// создает заказ в статусе pending и списывает стоимость с баланса.
+func (r *Repo) RedeemReward(ctx context.Context, userID uint, rewardID uint) (*models.RewardOrder, error) {
+	const op = "repository.RedeemReward"
+
+	tx, err := r.db.Begin(ctx)
+	if err != nil {
+		return nil, errors.Wrap(err, op)
+	}
+	defer func() {
+		_ = tx.Rollback(ctx)
+	}()
+
+	// Резервируем награду: строка блокируется до конца транзакции
+	query, args, err := r.builder.
+		Update("rewards").
+		Set("stock", squirrel.Expr("stock - 1")).
+		Where(squirrel.Eq{"id": rewardID, "active": true}).
+		Where("stock > 0").
+		Suffix(`RETURNING "name", "cost"`).
+		ToSql()
+	if err != nil {
+		return nil, errors.Wrap(err, op)
+	}
+
+	order := models.RewardOrder{
+		UserID:   userID,
+		RewardID: rewardID,
+		Status:   models.OrderStatusPending,
+	}
+	err = tx.QueryRow(ctx, query, args...).Scan(&order.RewardName, &order.Cost)
+	if err != nil {
+		if !errors.Is(err, pgx.ErrNoRows) {
+			return nil, errors.Wrap(err, op)
+		}
+
+		// Награда не зарезервирована: ее нет, она неактивна или закончилась
+		reward, err := r.GetRewardByID(ctx, rewardID)
+		if err != nil {
+			return nil, err
+		}
+		if !reward.Active {
+			return nil, ErrRewardNotFound
+		}
+		return nil, ErrRewardOutOfStock
+	}
+
+	now := time.Now().UTC()
+	order.CreatedAt = &now
+	order.UpdatedAt = &now
+
+	query, args, err = r.builder.
+		Insert("reward_orders").
+		Columns("user_id", "reward_id", "cost", "status", "created_at", "updated_at").
+		Values(order.UserID, order.RewardID, order.Cost, order.Status, order.CreatedAt, order.UpdatedAt).
+		Suffix(`RETURNING "id"`).
+		ToSql()
+	if err != nil {
+		return nil, errors.Wrap(err, op)
+	}
+
+	err = tx.QueryRow(ctx, query, args...).Scan(&order.ID)
+	if err != nil {
+		var pgErr *pgconn.PgError
+		if errors.As(err, &pgErr) && pgErr.Code == "23503" {
+			return nil, ErrUserNotFound
+		}
+		return nil, errors.Wrap(err, op)
+	}
+
+	err = r.ChangeBalance(ctx, tx, &models.BalanceTransaction{
+		UserID:      userID,
+		Amount:      -int64(order.Cost),
+		Type:        models.TransactionRedemption,
+		ReferenceID: order.ID,
+	})
+	if err != nil {
+		if errors.Is(err, ErrUserNotFound) || errors.Is(err, ErrInsufficientFunds) {
+			return nil, err
+		}
+		return nil, errors.Wrap(err, op)
+	}
+
+	if err = tx.Commit(ctx); err != nil {
+		return nil, errors.Wrap(err, op)
+	}
+
+	return &order, nil
+}
+
+// GetRewardOrders возвращает заказы, новые первыми. userID = 0 - заказы всех пользователей,
+// пустой status - заказы в любом статусе.
+func (r *Repo) GetRewardOrders(ctx context.Context, userID uint, status string, limit, offset uint) ([]*models.RewardOrder, error) {
+	const op = "repository.GetRewardOrders"
+
+	builder := r.builder.
+		Select(rewardOrderColumns...).
+		From(rewardOrderJoinTable).
+		OrderBy("o.id DESC").
+		Limit(uint64(limit)).
+		Offset(uint64(offset))
+	if userID != 0 {
+		builder = builder.Where(squirrel.Eq{"o.user_id": userID})
+	}
+	if status != "" {
+		builder = builder.Where(squirrel.Eq{"o.status": status})
+	}
+
+	query, args, err := builder.ToSql()
+	if err != nil {
+		return nil, errors.Wrap(err, op)
+	}
+
+	rows, err := r.db.Query(ctx, query, args...)
+	if err != nil {
+		return nil, errors.Wrap(err, op)
+	}
+	defer rows.Close()
+
+	orders := make([]*models.RewardOrder, 0)
+	for rows.Next() {
+		order := &models.RewardOrder{}
+		err = rows.Scan(
+			&order.ID,
+			&order.UserID,
+			&order.RewardID,
+			&order.RewardName,
+			&order.Cost,
+			&order.Status,
+			&order.CreatedAt,
+			&order.UpdatedAt,
+		)
+		if err != nil {
+			return nil, errors.Wrap(err, op)
+		}
+		orders = append(orders, order)
+	}
+
+	if err = rows.Err(); err != nil {
+		return nil, errors.Wrap(err, op)
+	}
+
+	return orders, nil
+}
+
+// FulfillRewardOrder переводит ожидающий заказ в статус fulfilled.
+func (r *Repo) FulfillRewardOrder(ctx context.Context, orderID uint) (*models.RewardOrder, error) {
+	const op = "repository.FulfillRewardOrder"
+
+	tx, err := r.db.Begin(ctx)
+	if err != nil {
+		return nil, errors.Wrap(err, op)
+	}
+	defer func() {
+		_ = tx.Rollback(ctx)
+	}()
+
+	order, err := r.setOrderStatus(ctx, tx, orderID, 0, models.OrderStatusFulfilled)
+	if err != nil {
+		return nil, err
+	}
+
+	if err = tx.Commit(ctx); err != nil {
+		return nil, errors.Wrap(err, op)
+	}
+
+	return order, nil
+}
+
+// CancelRewardOrder отменяет ожидающий заказ: возвращает награду на склад и баллы пользователю.
+// userID != 0 ограничивает отмену заказами этого пользователя.
+func (r *Repo) CancelRewardOrder(ctx context.Context, orderID uint, userID uint) (*models.RewardOrder, error) {
+	const op = "repository.CancelRewardOrder"
+
+	tx, err := r.db.Begin(ctx)
+	if err != nil {
+		return nil, errors.Wrap(err, op)
+	}
+	defer func() {
+		_ = tx.Rollback(ctx)
+	}()
+
+	order, err := r.setOrderStatus(ctx, tx, orderID, userID, models.OrderStatusCancelled)
+	if err != nil {
+		return nil, err
+	}
+
+	query, args, err := r.builder.
+		Update("rewards").
+		Set("stock", squirrel.Expr("stock + 1")).
+		Where(squirrel.Eq{"id": order.RewardID}).
+		ToSql()
+	if err != nil {
+		return nil, errors.Wrap(err, op)
+	}
+	if _, err = tx.Exec(ctx, query, args...); err != nil {
+		return nil, errors.Wrap(err, op)
+	}
+
+	err = r.ChangeBalance(ctx, tx, &models.BalanceTransaction{
+		UserID:      order.UserID,
+		Amount:      int64(order.Cost),
+		Type:        models.TransactionRefund,
+		ReferenceID: order.ID,
+	})
+	if err != nil {
+		return nil, errors.Wrap(err, op)
+	}
+
+	if err = tx.Commit(ctx); err != nil {
+		return nil, errors.Wrap(err, op)
+	}
+
+	return order, nil
+}
+
+// setOrderStatus переводит заказ из статуса pending в status. Условие по статусу в UPDATE
+// исключает повторную обработку заказа параллельными запросами.
+func (r *Repo) setOrderStatus(ctx context.Context, tx pgx.Tx, orderID uint, userID uint, status string) (*models.RewardOrder, error) {
+	const op = "repository.setOrderStatus"
+
+	where := squirrel.Eq{"id": orderID}
+	if userID != 0 {
+		where["user_id"] = userID
+	}
+
+	query, args, err := r.builder.
+		Update("reward_orders").
+		Set("status", status).
+		Set("updated_at", time.Now().UTC()).
+		Where(where).
+		Where(squirrel.Eq{"status": models.OrderStatusPending}).
+		Suffix(`RETURNING "id", "user_id", "reward_id", "cost", "status", "created_at", "updated_at"`).
+		ToSql()
+	if err != nil {
+		return nil, errors.Wrap(err, op)
+	}
+
+	var order models.RewardOrder
+	err = tx.QueryRow(ctx, query, args...).Scan(
+		&order.ID,
+		&order.UserID,
+		&order.RewardID,
+		&order.Cost,
+		&order.Status,
+		&order.CreatedAt,
+		&order.UpdatedAt,
+	)
+	if err == nil {
+		return &order, nil
+	}
+	if !errors.Is(err, pgx.ErrNoRows) {
+		return nil, errors.Wrap(err, op)
+	}
+
+	// Заказ не обновлен: его нет, он чужой или уже обработан
+	query, args, err = r.builder.
+		Select("status").
+		From("reward_orders").
+		Where(where).
+		ToSql()
+	if err != nil {
+		return nil, errors.Wrap(err, op)
+	}
+
+	var current string
+	if err = tx.QueryRow(ctx, query, args...).Scan(&current); err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, ErrOrderNotFound
+		}
+		return nil, errors.Wrap(err, op)
+	}
+
+	return nil, ErrOrderNotPending
+}
@@ -0,0 +1,383 @@
+package repository
+
+import (
+	"context"
+	"github.com/Masterminds/squirrel"
+	"github.com/RVodassa/TaskReward/internal/domain/models"
+	"github.com/jackc/pgx/v5"
+	"github.com/pkg/errors"
+	"time"
+)
+
+var (
+	ErrSeasonNotFound = errors.New("ошибка: сезон не найден")
+	ErrSeasonOverlap  = errors.New("ошибка: сезон пересекается с другим сезоном")
+	ErrSeasonClosed   = errors.New("ошибка: итоги сезона уже подведены")
+	ErrSeasonNotEnded = errors.New("ошибка: сезон еще не завершился")
+)
+
+// seasonColumns колонки seasons в порядке scanSeason
+var seasonColumns = []string{"id", "name", "starts_at", "ends_at", "prizes", "closed_at", "created_at"}
+
+// seasonStandingsQuery сохраняет итоговую таблицу сезона $1: места по баллам (DENSE_RANK) и призы $2
+// по местам, $3 - только пользователи с подтвержденным email. Скрывшие себя и исключенные администратором
+// пользователи в итоги не попадают.
+const seasonStandingsQuery = `
+INSERT INTO season_standings (season_id, user_id, rank, score, prize)
+SELECT $1, ranked.user_id, ranked.rank, ranked.score, COALESCE(($2::integer[])[ranked.rank::integer], 0)
+FROM (
+    SELECT s.user_id, s.score, DENSE_RANK() OVER (ORDER BY s.score DESC) AS rank
+    FROM season_scores s
+    JOIN users u ON u.id = s.user_id
+    WHERE s.season_id = $1 AND s.score > 0
+      AND (NOT $3 OR u.email_verified_at IS NOT NULL)
+      AND ` + rankedUserCondition + `
+) ranked
+RETURNING user_id, prize`
+
+// seasonScoreQuery добавляет пользователю $1 баллы $2 в сезоне, который идет в момент $3.
+const seasonScoreQuery = `
+INSERT INTO season_scores (season_id, user_id, score)
+SELECT id, $1::integer, $2::bigint
+FROM seasons
+WHERE closed_at IS NULL AND starts_at <= $3::timestamp AND ends_at > $3::timestamp
+ON CONFLICT (season_id, user_id) DO UPDATE SET score = season_scores.score + EXCLUDED.score`
+
+// AddSeason сохраняет новый сезон. Сезоны не могут пересекаться по времени, чтобы выполнение задачи
+// приносило баллы не более чем в одном сезоне.
+func (r *Repo) AddSeason(ctx context.Context, season *models.Season) error {
+	const op = "repository.AddSeason"
+
+	tx, err := r.db.Begin(ctx)
+	if err != nil {
+		return errors.Wrap(err, op)
+	}
+	defer func() {
+		_ = tx.Rollback(ctx)
+	}()
+
+	// Блокировка исключает одновременное создание пересекающихся сезонов
+	if _, err = tx.Exec(ctx, "LOCK TABLE seasons IN SHARE ROW EXCLUSIVE MODE"); err != nil {
+		return errors.Wrap(err, op)
+	}
+
+	query, args, err := r.builder.
+		Select("1").
+		Prefix("SELECT EXISTS (").
+		From("seasons").
+		Where(squirrel.Lt{"starts_at": season.EndsAt}).
+		Where(squirrel.Gt{"ends_at": season.StartsAt}).
+		Suffix(")").
+		ToSql()
+	if err != nil {
+		return errors.Wrap(err, op)
+	}
+
+	var overlap bool
+	if err = tx.QueryRow(ctx, query, args...).Scan(&overlap); err != nil {
+		return errors.Wrap(err, op)
+	}
+	if overlap {
+		return ErrSeasonOverlap
+	}
+
+	query, args, err = r.builder.
+		Insert("seasons").
+		Columns("name", "starts_at", "ends_at", "prizes", "created_at").
+		Values(season.Name, season.StartsAt, season.EndsAt, season.Prizes, season.CreatedAt).
+		Suffix(`RETURNING "id"`).
+		ToSql()
+	if err != nil {
+		return errors.Wrap(err, op)
+	}
+
+	if err = tx.QueryRow(ctx, query, args...).Scan(&season.ID); err != nil {
+		return errors.Wrap(err, op)
+	}
+
+	if err = tx.Commit(ctx); err != nil {
+		return errors.Wrap(err, op)
+	}
+
+	return nil
+}
+
+// GetSeasons возвращает страницу сезонов, последние первыми.
+func (r *Repo) GetSeasons(ctx context.Context, limit, offset uint) ([]*models.Season, error) {
+	const op = "repository.GetSeasons"
+
+	query, args, err := r.builder.
+		Select(seasonColumns...).
+		From("seasons").
+		OrderBy("starts_at DESC").
+		Limit(uint64(limit)).
+		Offset(uint64(offset)).
+		ToSql()
+	if err != nil {
+		return nil, errors.Wrap(err, op)
+	}
+
+	rows, err := r.db.Query(ctx, query, args...)
+	if err != nil {
+		return nil, errors.Wrap(err, op)
+	}
+	defer rows.Close()
+
+	seasons := make([]*models.Season, 0)
+	for rows.Next() {
+		season, err := scanSeason(rows)
+		if err != nil {
+			return nil, errors.Wrap(err, op)
+		}
+		seasons = append(seasons, season)
+	}
+
+	if err = rows.Err(); err != nil {
+		return nil, errors.Wrap(err, op)
+	}
+
+	return seasons, nil
+}
+
+// GetSeason возвращает сезон по ID.
+func (r *Repo) GetSeason(ctx context.Context, seasonID uint) (*models.Season, error) {
+	const op = "repository.GetSeason"
+
+	query, args, err := r.builder.
+		Select(seasonColumns...).
+		From("seasons").
+		Where(squirrel.Eq{"id": seasonID}).
+		ToSql()
+	if err != nil {
+		return nil, errors.Wrap(err, op)
+	}
+
+	season, err := scanSeason(r.db.QueryRow(ctx, query, args...))
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, ErrSeasonNotFound
+		}
+		return nil, errors.Wrap(err, op)
+	}
+
+	return season, nil
+}
+
+// GetSeasonScores возвращает текущую таблицу незакрытого сезона по баллам, verifiedOnly оставляет
+// только пользователей с подтвержденным email. Скрывшие себя и исключенные администратором
+// пользователи в таблицу не попадают.
+func (r *Repo) GetSeasonScores(ctx context.Context, seasonID uint, verifiedOnly bool, limit, offset uint) ([]*models.SeasonStanding, error) {
+	const op = "repository.GetSeasonScores"
+
+	builder := r.builder.
+		Select("DENSE_RANK() OVER (ORDER BY s.score DESC)", "s.user_id", "s.score").
+		Columns(leaderboardProfileColumns...).
+		From("season_scores s").
+		Join("users u ON u.id = s.user_id").
+		Where(squirrel.Eq{"s.season_id": seasonID}).
+		Where(squirrel.Gt{"s.score": 0}).
+		Where(rankedUserCondition).
+		OrderBy("s.score DESC", "s.user_id").
+		Limit(uint64(limit)).
+		Offset(uint64(offset))
+	if verifiedOnly {
+		builder = builder.Where(squirrel.NotEq{"u.email_verified_at": nil})
+	}
+
+	query, args, err := builder.ToSql()
+	if err != nil {
+		return nil, errors.Wrap(err, op)
+	}
+
+	rows, err := r.db.Query(ctx, query, args...)
+	if err != nil {
+		return nil, errors.Wrap(err, op)
+	}
+	defer rows.Close()
+
+	standings := make([]*models.SeasonStanding, 0)
+	for rows.Next() {
+		var standing models.SeasonStanding
+		err = rows.Scan(&standing.Rank, &standing.UserID, &standing.Score, &standing.DisplayName, &standing.AvatarURL, &standing.Visibility)
+		if err != nil {
+			return nil, errors.Wrap(err, op)
+		}
+		standings = append(standings, &standing)
+	}
+
+	if err = rows.Err(); err != nil {
+		return nil, errors.Wrap(err, op)
+	}
+
+	return standings, nil
+}
+
+// GetSeasonStandings возвращает сохраненную итоговую таблицу закрытого сезона.
+func (r *Repo) GetSeasonStandings(ctx context.Context, seasonID uint, limit, offset uint) ([]*models.SeasonStanding, error) {
+	const op = "repository.GetSeasonStandings"
+
+	query, args, err := r.builder.
+		Select("st.rank", "st.user_id", "st.score", "st.prize").
+		Columns(leaderboardProfileColumns...).
+		From("season_standings st").
+		Join("users u ON u.id = st.user_id").
+		Where(squirrel.Eq{"st.season_id": seasonID}).
+		OrderBy("st.rank", "st.user_id").
+		Limit(uint64(limit)).
+		Offset(uint64(offset)).
+		ToSql()
+	if err != nil {
+		return nil, errors.Wrap(err, op)
+	}
+
+	rows, err := r.db.Query(ctx, query, args...)
+	if err != nil {
+		return nil, errors.Wrap(err, op)
+	}
+	defer rows.Close()
+
+	standings := make([]*models.SeasonStanding, 0)
+	for rows.Next() {
+		var standing models.SeasonStanding
+		err = rows.Scan(&standing.Rank, &standing.UserID, &standing.Score, &standing.Prize, &standing.DisplayName, &standing.AvatarURL, &standing.Visibility)
+		if err != nil {
+			return nil, errors.Wrap(err, op)
+		}
+		standings = append(standings, &standing)
+	}
+
+	if err = rows.Err(); err != nil {
+		return nil, errors.Wrap(err, op)
+	}
+
+	return standings, nil
+}
+
+// CloseSeason подводит итоги завершившегося сезона: сохраняет итоговую таблицу и начисляет призы
+// на баланс от имени администратора adminID. Все изменения выполняются в одной транзакции,
+// поэтому повторное закрытие сезона невозможно.
+func (r *Repo) CloseSeason(ctx context.Context, seasonID uint, adminID uint, verifiedOnly bool) (*models.Season, error) {
+	const op = "repository.CloseSeason"
+
+	tx, err := r.db.Begin(ctx)
+	if err != nil {
+		return nil, errors.Wrap(err, op)
+	}
+	defer func() {
+		_ = tx.Rollback(ctx)
+	}()
+
+	query, args, err := r.builder.
+		Select(seasonColumns...).
+		From("seasons").
+		Where(squirrel.Eq{"id": seasonID}).
+		Suffix("FOR UPDATE").
+		ToSql()
+	if err != nil {
+		return nil, errors.Wrap(err, op)
+	}
+
+	season, err := scanSeason(tx.QueryRow(ctx, query, args...))
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, ErrSeasonNotFound
+		}
+		return nil, errors.Wrap(err, op)
+	}
+
+	now := time.Now().UTC()
+	if season.ClosedAt != nil {
+		return nil, ErrSeasonClosed
+	}
+	if now.Before(season.EndsAt) {
+		return nil, ErrSeasonNotEnded
+	}
+
+	rows, err := tx.Query(ctx, seasonStandingsQuery, seasonID, season.Prizes, verifiedOnly)
+	if err != nil {
+		return nil, errors.Wrap(err, op)
+	}
+	type prize struct {
+		userID uint
+		amount uint
+	}
+	var prizes []prize
+	for rows.Next() {
+		var p prize
+		if err = rows.Scan(&p.userID, &p.amount); err != nil {
+			rows.Close()
+			return nil, errors.Wrap(err, op)
+		}
+		if p.amount > 0 {
+			prizes = append(prizes, p)
+		}
+	}
+	rows.Close()
+	if err = rows.Err(); err != nil {
+		return nil, errors.Wrap(err, op)
+	}
+
+	for _, p := range prizes {
+		err = r.ChangeBalance(ctx, tx, &models.BalanceTransaction{
+			UserID:      p.userID,
+			Amount:      int64(p.amount),
+			Type:        models.TransactionSeasonPrize,
+			ReferenceID: seasonID,
+			AdminID:     adminID,
+			Comment:     season.Name,
+		})
+		if err != nil {
+			return nil, errors.Wrap(err, op)
+		}
+	}
+
+	query, args, err = r.builder.
+		Update("seasons").
+		Set("closed_at", now).
+		Where(squirrel.Eq{"id": seasonID}).
+		ToSql()
+	if err != nil {
+		return nil, errors.Wrap(err, op)
+	}
+
+	if _, err = tx.Exec(ctx, query, args...); err != nil {
+		return nil, errors.Wrap(err, op)
+	}
+
+	if err = tx.Commit(ctx); err != nil {
+		return nil, errors.Wrap(err, op)
+	}
+
+	season.ClosedAt = &now
+	return season, nil
+}
+
+// addSeasonScore начисляет баллы за задачу, выполненную в момент at, в сезоне, который идет в этот момент.
+// Вызывается в транзакции выполнения задачи.
+func (r *Repo) addSeasonScore(ctx context.Context, tx pgx.Tx, userID uint, score uint, at time.Time) error {
+	const op = "repository.addSeasonScore"
+
+	if _, err := tx.Exec(ctx, seasonScoreQuery, userID, score, at); err != nil {
+		return errors.Wrap(err, op)
+	}
+
+	return nil
+}
+
+// scanSeason читает сезон в порядке seasonColumns.
+func scanSeason(row pgx.Row) (*models.Season, error) {
+	season := &models.Season{}
+	err := row.Scan(
+		&season.ID,
+		&season.Name,
+		&season.StartsAt,
+		&season.EndsAt,
+		&season.Prizes,
+		&season.ClosedAt,
+		&season.CreatedAt,
+	)
+	if err != nil {
+		return nil, err
+	}
+	return season, nil
+}
@@ -0,0 +1,187 @@
+package repository
+
+import (
+	"context"
+	"github.com/Masterminds/squirrel"
+	"github.com/RVodassa/TaskReward/internal/domain/models"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgconn"
+	"github.com/pkg/errors"
+)
+
+// completionsCountExpr подзапрос кол-ва выполнений задачи t.
+const completionsCountExpr = "(SELECT COUNT(*) FROM task_completions c WHERE c.task_id = t.id)"
+
+// GetTaskByID возвращает задачу с кол-вом ее выполнений.
+func (r *Repo) GetTaskByID(ctx context.Context, taskID uint) (*models.Task, error) {
+	const op = "repository.GetTaskByID"
+
+	query, args, err := r.builder.
+		Select("t.id", "t.status", "t.description", "t.bonus", "t.max_completions", "t.created_at", completionsCountExpr).
+		From("tasks t").
+		Where(squirrel.Eq{"t.id": taskID}).
+		ToSql()
+	if err != nil {
+		return nil, errors.Wrap(err, op)
+	}
+
+	var task models.Task
+	err = r.db.QueryRow(ctx, query, args...).Scan(
+		&task.ID,
+		&task.Status,
+		&task.Description,
+		&task.Bonus,
+		&task.MaxCompletions,
+		&task.CreatedAt,
+		&task.Completions,
+	)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, ErrTaskNotFound
+		}
+		return nil, errors.Wrap(err, op)
+	}
+
+	return &task, nil
+}
+
+// GetAllTasks возвращает задачи в любом статусе, status фильтрует список если не пустой.
+func (r *Repo) GetAllTasks(ctx context.Context, status string, limit, offset uint) ([]*models.Task, error) {
+	const op = "repository.GetAllTasks"
+
+	builder := r.builder.
+		Select("t.id", "t.status", "t.description", "t.bonus", "t.max_completions", "t.created_at", completionsCountExpr).
+		From("tasks t").
+		OrderBy("t.id").
+		Limit(uint64(limit)).
+		Offset(uint64(offset))
+	if status != "" {
+		builder = builder.Where(squirrel.Eq{"t.status": status})
+	}
+
+	query, args, err := builder.ToSql()
+	if err != nil {
+		return nil, errors.Wrap(err, op)
+	}
+
+	rows, err := r.db.Query(ctx, query, args...)
+	if err != nil {
+		return nil, errors.Wrap(err, op)
+	}
+	defer rows.Close()
+
+	tasks := make([]*models.Task, 0)
+	for rows.Next() {
+		task := &models.Task{}
+		err = rows.Scan(
+			&task.ID,
+			&task.Status,
+			&task.Description,
+			&task.Bonus,
+			&task.MaxCompletions,
+			&task.CreatedAt,
+			&task.Completions,
+		)
+		if err != nil {
+			return nil, errors.Wrap(err, op)
+		}
+		tasks = append(tasks, task)
+	}
+
+	if err = rows.Err(); err != nil {
+		return nil, errors.Wrap(err, op)
+	}
+
+	return tasks, nil
+}
+
+// UpdateTask обновляет описание, бонус и лимит выполнений задачи.
+// Статус неархивной задачи пересчитывается исходя из нового лимита.
+func (r *Repo) UpdateTask(ctx context.Context, task *models.Task) error {
+	const op = "repository.UpdateTask"
+
+	statusExpr := squirrel.Expr(
+		"CASE WHEN status = ? THEN status WHEN ? > 0 AND "+completionsCountExpr+" >= ? THEN ? ELSE ? END",
+		StatusTaskArchived, task.MaxCompletions, task.MaxCompletions, StatusTaskClose, StatusTaskOpen,
+	)
+
+	query, args, err := r.builder.
+		Update("tasks t").
+		Set("description", task.Description).
+		Set("bonus", task.Bonus).
+		Set("max_completions", task.MaxCompletions).
+		Set("status", statusExpr).
+		Where(squirrel.Eq{"t.id": task.ID}).
+		Suffix(`RETURNING t.status`).
+		ToSql()
+	if err != nil {
+		return errors.Wrap(err, op)
+	}
+
+	err = r.db.QueryRow(ctx, query, args...).Scan(&task.Status)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return ErrTaskNotFound
+		}
+		return errors.Wrap(err, op)
+	}
+
+	return nil
+}
+
+// ArchiveTask переводит задачу в архив, после чего ее нельзя выполнить.
+func (r *Repo) ArchiveTask(ctx context.Context, taskID uint) error {
+	const op = "repository.ArchiveTask"
+
+	query, args, err := r.builder.
+		Update("tasks").
+		Set("status", StatusTaskArchived).
+		Where(squirrel.Eq{"id": taskID}).
+		ToSql()
+	if err != nil {
+		return errors.Wrap(err, op)
+	}
+
+	result, err := r.db.Exec(ctx, query, args...)
+	if err != nil {
+		return errors.Wrap(err, op)
+	}
+	if result.RowsAffected() == 0 {
+		return ErrTaskNotFound
+	}
+
+	return nil
+}
+
+// DeleteTask удаляет задачу, которую еще никто не выполнял.
+func (r *Repo) DeleteTask(ctx context.Context, taskID uint) error {
+	const op = "repository.DeleteTask"
+
+	query, args, err := r.builder.
+		Delete("tasks").
+		Where(squirrel.Eq{"id": taskID}).
+		Where("NOT EXISTS (SELECT 1 FROM task_completions c WHERE c.task_id = tasks.id)").
+		ToSql()
+	if err != nil {
+		return errors.Wrap(err, op)
+	}
+
+	result, err := r.db.Exec(ctx, query, args...)
+	if err != nil {
+		var pgErr *pgconn.PgError
+		if errors.As(err, &pgErr) && pgErr.Code == "23503" {
+			return ErrTaskHasCompletions
+		}
+		return errors.Wrap(err, op)
+	}
+	if result.RowsAffected() > 0 {
+		return nil
+	}
+
+	// Задача не удалена: либо ее нет, либо она уже выполнялась
+	if _, err = r.GetTaskByID(ctx, taskID); err != nil {
+		return err
+	}
+
+	return ErrTaskHasCompletions
+}
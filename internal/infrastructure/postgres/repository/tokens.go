@@ -0,0 +1,385 @@
+package repository
+
+import (
+	"context"
+	"github.com/Masterminds/squirrel"
+	"github.com/RVodassa/TaskReward/internal/domain/models"
+	"github.com/jackc/pgx/v5"
+	"github.com/pkg/errors"
+	"time"
+)
+
+var (
+	ErrRefreshTokenNotFound = errors.New("ошибка: refresh токен не найден")
+	ErrRefreshTokenExpired  = errors.New("ошибка: срок действия refresh токена истек")
+	ErrRefreshTokenRevoked  = errors.New("ошибка: refresh токен отозван")
+	ErrRefreshTokenReused   = errors.New("ошибка: повторное использование refresh токена")
+)
+
+// AddRefreshToken сохраняет новый refresh токен.
+func (r *Repo) AddRefreshToken(ctx context.Context, token *models.RefreshToken) error {
+	const op = "repository.AddRefreshToken"
+
+	if err := r.insertRefreshToken(ctx, r.db, token); err != nil {
+		return errors.Wrap(err, op)
+	}
+
+	return nil
+}
+
+// RotateRefreshToken обменивает refresh токен с хэшем oldHash на newToken из той же цепочки.
+// Повторное предъявление уже обмененного токена считается кражей: вся цепочка отзывается
+// и возвращается ErrRefreshTokenReused. После успешного выполнения newToken содержит ID,
+// пользователя и цепочку.
+func (r *Repo) RotateRefreshToken(ctx context.Context, oldHash string, newToken *models.RefreshToken) error {
+	const op = "repository.RotateRefreshToken"
+
+	tx, err := r.db.Begin(ctx)
+	if err != nil {
+		return errors.Wrap(err, op)
+	}
+	defer func() {
+		_ = tx.Rollback(ctx)
+	}()
+
+	query, args, err := r.builder.
+		Select("id", "user_id", "family_id", "expires_at", "rotated_at", "revoked_at").
+		From("refresh_tokens").
+		Where(squirrel.Eq{"token_hash": oldHash}).
+		Suffix("FOR UPDATE").
+		ToSql()
+	if err != nil {
+		return errors.Wrap(err, op)
+	}
+
+	old := &models.RefreshToken{}
+	err = tx.QueryRow(ctx, query, args...).Scan(&old.ID, &old.UserID, &old.FamilyID, &old.ExpiresAt, &old.RotatedAt, &old.RevokedAt)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return ErrRefreshTokenNotFound
+		}
+		return errors.Wrap(err, op)
+	}
+
+	now := time.Now().UTC()
+	switch {
+	case old.RotatedAt != nil:
+		if err = r.revokeRefreshTokens(ctx, tx, squirrel.Eq{"family_id": old.FamilyID}, now); err != nil {
+			return errors.Wrap(err, op)
+		}
+		if err = tx.Commit(ctx); err != nil {
+			return errors.Wrap(err, op)
+		}
+		return ErrRefreshTokenReused
+	case old.RevokedAt != nil:
+		return ErrRefreshTokenRevoked
+	case !old.ExpiresAt.After(now):
+		return ErrRefreshTokenExpired
+	}
+
+	query, args, err = r.builder.
+		Update("refresh_tokens").
+		Set("rotated_at", now).
+		Where(squirrel.Eq{"id": old.ID}).
+		ToSql()
+	if err != nil {
+		return errors.Wrap(err, op)
+	}
+	if _, err = tx.Exec(ctx, query, args...); err != nil {
+		return errors.Wrap(err, op)
+	}
+
+	newToken.UserID = old.UserID
+	newToken.FamilyID = old.FamilyID
+	if err = r.insertRefreshToken(ctx, tx, newToken); err != nil {
+		return errors.Wrap(err, op)
+	}
+
+	if err = tx.Commit(ctx); err != nil {
+		return errors.Wrap(err, op)
+	}
+
+	return nil
+}
+
+// revokeRefreshTokens отзывает еще не отозванные refresh токены, подходящие под условие where.
+func (r *Repo) revokeRefreshTokens(ctx context.Context, tx pgx.Tx, where squirrel.Sqlizer, now time.Time) error {
+	const op = "repository.revokeRefreshTokens"
+
+	query, args, err := r.builder.
+		Update("refresh_tokens").
+		Set("revoked_at", now).
+		Where(where).
+		Where(squirrel.Eq{"revoked_at": nil}).
+		ToSql()
+	if err != nil {
+		return errors.Wrap(err, op)
+	}
+
+	if _, err = tx.Exec(ctx, query, args...); err != nil {
+		return errors.Wrap(err, op)
+	}
+
+	return nil
+}
+
+// queryRower общий интерфейс пула соединений и транзакции для выполнения запроса с одной строкой результата.
+type queryRower interface {
+	QueryRow(ctx context.Context, sql string, args ...any) pgx.Row
+}
+
+// insertRefreshToken вставляет refresh токен через пул или транзакцию.
+func (r *Repo) insertRefreshToken(ctx context.Context, q queryRower, token *models.RefreshToken) error {
+	query, args, err := r.builder.
+		Insert("refresh_tokens").
+		Columns("user_id", "family_id", "token_hash", "expires_at", "created_at").
+		Values(token.UserID, token.FamilyID, token.TokenHash, token.ExpiresAt, token.CreatedAt).
+		Suffix(`RETURNING "id"`).
+		ToSql()
+	if err != nil {
+		return err
+	}
+
+	return q.QueryRow(ctx, query, args...).Scan(&token.ID)
+}
+
+// RevokeAccessToken добавляет access токен jti в список отозванных до момента его истечения.
+// Заодно удаляет записи о токенах, срок действия которых уже истек.
+func (r *Repo) RevokeAccessToken(ctx context.Context, jti string, userID uint, expiresAt time.Time) error {
+	const op = "repository.RevokeAccessToken"
+
+	query, args, err := r.builder.
+		Insert("revoked_tokens").
+		Columns("jti", "user_id", "expires_at").
+		Values(jti, userID, expiresAt).
+		Suffix("ON CONFLICT (jti) DO NOTHING").
+		ToSql()
+	if err != nil {
+		return errors.Wrap(err, op)
+	}
+	if _, err = r.db.Exec(ctx, query, args...); err != nil {
+		return errors.Wrap(err, op)
+	}
+
+	query, args, err = r.builder.
+		Delete("revoked_tokens").
+		Where(squirrel.Lt{"expires_at": time.Now().UTC()}).
+		ToSql()
+	if err != nil {
+		return errors.Wrap(err, op)
+	}
+	if _, err = r.db.Exec(ctx, query, args...); err != nil {
+		return errors.Wrap(err, op)
+	}
+
+	return nil
+}
+
+// IsAccessTokenRevoked проверяет, отозван ли access токен jti.
+func (r *Repo) IsAccessTokenRevoked(ctx context.Context, jti string) (bool, error) {
+	const op = "repository.IsAccessTokenRevoked"
+
+	query, args, err := r.builder.
+		Select("1").
+		Prefix("SELECT EXISTS (").
+		From("revoked_tokens").
+		Where(squirrel.Eq{"jti": jti}).
+		Suffix(")").
+		ToSql()
+	if err != nil {
+		return false, errors.Wrap(err, op)
+	}
+
+	var revoked bool
+	if err = r.db.QueryRow(ctx, query, args...).Scan(&revoked); err != nil {
+		return false, errors.Wrap(err, op)
+	}
+
+	return revoked, nil
+}
+
+// RevokeRefreshTokenFamily отзывает цепочку, которой принадлежит refresh токен с хэшем tokenHash
+// пользователя userID. Неизвестный токен игнорируется.
+func (r *Repo) RevokeRefreshTokenFamily(ctx context.Context, userID uint, tokenHash string) error {
+	const op = "repository.RevokeRefreshTokenFamily"
+
+	tx, err := r.db.Begin(ctx)
+	if err != nil {
+		return errors.Wrap(err, op)
+	}
+	defer func() {
+		_ = tx.Rollback(ctx)
+	}()
+
+	// Подзапрос строится без r.builder: плейсхолдеры нумеруются один раз во внешнем запросе
+	family := squirrel.
+		Select("family_id").
+		From("refresh_tokens").
+		Where(squirrel.Eq{"token_hash": tokenHash, "user_id": userID})
+	err = r.revokeRefreshTokens(ctx, tx, squirrel.Expr("family_id IN (?)", family), time.Now().UTC())
+	if err != nil {
+		return errors.Wrap(err, op)
+	}
+
+	if err = tx.Commit(ctx); err != nil {
+		return errors.Wrap(err, op)
+	}
+
+	return nil
+}
+
+// RevokeAllTokens делает недействительными все access и refresh токены пользователя.
+func (r *Repo) RevokeAllTokens(ctx context.Context, userID uint) error {
+	const op = "repository.RevokeAllTokens"
+
+	tx, err := r.db.Begin(ctx)
+	if err != nil {
+		return errors.Wrap(err, op)
+	}
+	defer func() {
+		_ = tx.Rollback(ctx)
+	}()
+
+	if err = r.revokeUserTokens(ctx, tx, userID); err != nil {
+		if errors.Is(err, ErrUserNotFound) {
+			return err
+		}
+		return errors.Wrap(err, op)
+	}
+
+	if err = tx.Commit(ctx); err != nil {
+		return errors.Wrap(err, op)
+	}
+
+	return nil
+}
+
+// UpdatePassword меняет хэш пароля пользователя и отзывает все его токены.
+func (r *Repo) UpdatePassword(ctx context.Context, userID uint, passwordHash string) error {
+	const op = "repository.UpdatePassword"
+
+	tx, err := r.db.Begin(ctx)
+	if err != nil {
+		return errors.Wrap(err, op)
+	}
+	defer func() {
+		_ = tx.Rollback(ctx)
+	}()
+
+	query, args, err := r.builder.
+		Update("users").
+		Set("password_hash", passwordHash).
+		Where(squirrel.Eq{"id": userID}).
+		ToSql()
+	if err != nil {
+		return errors.Wrap(err, op)
+	}
+	if _, err = tx.Exec(ctx, query, args...); err != nil {
+		return errors.Wrap(err, op)
+	}
+
+	if err = r.revokeUserTokens(ctx, tx, userID); err != nil {
+		if errors.Is(err, ErrUserNotFound) {
+			return err
+		}
+		return errors.Wrap(err, op)
+	}
+
+	if err = tx.Commit(ctx); err != nil {
+		return errors.Wrap(err, op)
+	}
+
+	return nil
+}
+
+// RehashPassword заменяет хэш пароля oldHash на newHash того же пароля. Токены не отзываются.
+// Если пароль уже изменен параллельно, хэш не обновляется.
+func (r *Repo) RehashPassword(ctx context.Context, userID uint, oldHash, newHash string) error {
+	const op = "repository.RehashPassword"
+
+	query, args, err := r.builder.
+		Update("users").
+		Set("password_hash", newHash).
+		Where(squirrel.Eq{"id": userID, "password_hash": oldHash}).
+		ToSql()
+	if err != nil {
+		return errors.Wrap(err, op)
+	}
+
+	if _, err = r.db.Exec(ctx, query, args...); err != nil {
+		return errors.Wrap(err, op)
+	}
+
+	return nil
+}
+
+// SetUserBanned блокирует или разблокирует пользователя. При блокировке все токены пользователя отзываются.
+func (r *Repo) SetUserBanned(ctx context.Context, userID uint, banned bool) error {
+	const op = "repository.SetUserBanned"
+
+	tx, err := r.db.Begin(ctx)
+	if err != nil {
+		return errors.Wrap(err, op)
+	}
+	defer func() {
+		_ = tx.Rollback(ctx)
+	}()
+
+	var bannedAt interface{}
+	if banned {
+		bannedAt = time.Now().UTC()
+	}
+
+	query, args, err := r.builder.
+		Update("users").
+		Set("banned_at", bannedAt).
+		Where(squirrel.Eq{"id": userID}).
+		ToSql()
+	if err != nil {
+		return errors.Wrap(err, op)
+	}
+
+	result, err := tx.Exec(ctx, query, args...)
+	if err != nil {
+		return errors.Wrap(err, op)
+	}
+	if result.RowsAffected() == 0 {
+		return ErrUserNotFound
+	}
+
+	if banned {
+		if err = r.revokeUserTokens(ctx, tx, userID); err != nil {
+			return errors.Wrap(err, op)
+		}
+	}
+
+	if err = tx.Commit(ctx); err != nil {
+		return errors.Wrap(err, op)
+	}
+
+	return nil
+}
+
+// revokeUserTokens увеличивает версию токенов пользователя и отзывает его refresh токены в рамках транзакции.
+func (r *Repo) revokeUserTokens(ctx context.Context, tx pgx.Tx, userID uint) error {
+	const op = "repository.revokeUserTokens"
+
+	query, args, err := r.builder.
+		Update("users").
+		Set("token_version", squirrel.Expr("token_version + 1")).
+		Where(squirrel.Eq{"id": userID}).
+		ToSql()
+	if err != nil {
+		return errors.Wrap(err, op)
+	}
+
+	result, err := tx.Exec(ctx, query, args...)
+	if err != nil {
+		return errors.Wrap(err, op)
+	}
+	if result.RowsAffected() == 0 {
+		return ErrUserNotFound
+	}
+
+	return r.revokeRefreshTokens(ctx, tx, squirrel.Eq{"user_id": userID}, time.Now().UTC())
+}
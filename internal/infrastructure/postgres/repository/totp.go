@@ -0,0 +1,227 @@
+package repository
+
+import (
+	"context"
+	"github.com/Masterminds/squirrel"
+	"github.com/jackc/pgx/v5"
+	"github.com/pkg/errors"
+	"time"
+)
+
+var ErrTOTPAlreadyEnabled = errors.New("ошибка: двухфакторная аутентификация уже включена")
+
+// GetTOTPSecret возвращает секрет TOTP пользователя и признак включенной 2FA.
+// Пустой секрет - 2FA не подключалась.
+func (r *Repo) GetTOTPSecret(ctx context.Context, userID uint) (string, bool, error) {
+	const op = "repository.GetTOTPSecret"
+
+	query, args, err := r.builder.
+		Select("COALESCE(totp_secret, '')", "totp_enabled").
+		From("users").
+		Where(squirrel.Eq{"id": userID}).
+		ToSql()
+	if err != nil {
+		return "", false, errors.Wrap(err, op)
+	}
+
+	var secret string
+	var enabled bool
+	if err = r.db.QueryRow(ctx, query, args...).Scan(&secret, &enabled); err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return "", false, ErrUserNotFound
+		}
+		return "", false, errors.Wrap(err, op)
+	}
+
+	return secret, enabled, nil
+}
+
+// SetTOTPSecret сохраняет секрет TOTP, ожидающий подтверждения. Если 2FA уже включена,
+// возвращает ErrTOTPAlreadyEnabled.
+func (r *Repo) SetTOTPSecret(ctx context.Context, userID uint, secret string) error {
+	const op = "repository.SetTOTPSecret"
+
+	query, args, err := r.builder.
+		Update("users").
+		Set("totp_secret", secret).
+		Set("totp_last_step", nil).
+		Where(squirrel.Eq{"id": userID, "totp_enabled": false}).
+		ToSql()
+	if err != nil {
+		return errors.Wrap(err, op)
+	}
+
+	result, err := r.db.Exec(ctx, query, args...)
+	if err != nil {
+		return errors.Wrap(err, op)
+	}
+	if result.RowsAffected() == 0 {
+		return ErrTOTPAlreadyEnabled
+	}
+
+	return nil
+}
+
+// EnableTOTP включает 2FA, запоминает использованный при подтверждении шаг и заменяет
+// резервные коды пользователя на backupHashes.
+func (r *Repo) EnableTOTP(ctx context.Context, userID uint, step int64, backupHashes []string) error {
+	const op = "repository.EnableTOTP"
+
+	tx, err := r.db.Begin(ctx)
+	if err != nil {
+		return errors.Wrap(err, op)
+	}
+	defer func() {
+		_ = tx.Rollback(ctx)
+	}()
+
+	query, args, err := r.builder.
+		Update("users").
+		Set("totp_enabled", true).
+		Set("totp_last_step", step).
+		Where(squirrel.Eq{"id": userID, "totp_enabled": false}).
+		Where(squirrel.NotEq{"totp_secret": nil}).
+		ToSql()
+	if err != nil {
+		return errors.Wrap(err, op)
+	}
+
+	result, err := tx.Exec(ctx, query, args...)
+	if err != nil {
+		return errors.Wrap(err, op)
+	}
+	if result.RowsAffected() == 0 {
+		return ErrTOTPAlreadyEnabled
+	}
+
+	if err = r.replaceBackupCodes(ctx, tx, userID, backupHashes); err != nil {
+		return errors.Wrap(err, op)
+	}
+
+	if err = tx.Commit(ctx); err != nil {
+		return errors.Wrap(err, op)
+	}
+
+	return nil
+}
+
+// DisableTOTP выключает 2FA, удаляет секрет и резервные коды пользователя.
+func (r *Repo) DisableTOTP(ctx context.Context, userID uint) error {
+	const op = "repository.DisableTOTP"
+
+	tx, err := r.db.Begin(ctx)
+	if err != nil {
+		return errors.Wrap(err, op)
+	}
+	defer func() {
+		_ = tx.Rollback(ctx)
+	}()
+
+	query, args, err := r.builder.
+		Update("users").
+		Set("totp_enabled", false).
+		Set("totp_secret", nil).
+		Set("totp_last_step", nil).
+		Where(squirrel.Eq{"id": userID}).
+		ToSql()
+	if err != nil {
+		return errors.Wrap(err, op)
+	}
+
+	result, err := tx.Exec(ctx, query, args...)
+	if err != nil {
+		return errors.Wrap(err, op)
+	}
+	if result.RowsAffected() == 0 {
+		return ErrUserNotFound
+	}
+
+	if err = r.replaceBackupCodes(ctx, tx, userID, nil); err != nil {
+		return errors.Wrap(err, op)
+	}
+
+	if err = tx.Commit(ctx); err != nil {
+		return errors.Wrap(err, op)
+	}
+
+	return nil
+}
+
+// UseTOTPStep отмечает шаг TOTP использованным. Возвращает false, если этот или более поздний
+// шаг уже был принят - код использован повторно.
+func (r *Repo) UseTOTPStep(ctx context.Context, userID uint, step int64) (bool, error) {
+	const op = "repository.UseTOTPStep"
+
+	query, args, err := r.builder.
+		Update("users").
+		Set("totp_last_step", step).
+		Where(squirrel.Eq{"id": userID}).
+		Where(squirrel.Or{squirrel.Eq{"totp_last_step": nil}, squirrel.Lt{"totp_last_step": step}}).
+		ToSql()
+	if err != nil {
+		return false, errors.Wrap(err, op)
+	}
+
+	result, err := r.db.Exec(ctx, query, args...)
+	if err != nil {
+		return false, errors.Wrap(err, op)
+	}
+
+	return result.RowsAffected() == 1, nil
+}
+
+// UseBackupCode погашает неиспользованный резервный код с хэшем codeHash. Возвращает false,
+// если такого кода нет или он уже использован.
+func (r *Repo) UseBackupCode(ctx context.Context, userID uint, codeHash string) (bool, error) {
+	const op = "repository.UseBackupCode"
+
+	query, args, err := r.builder.
+		Update("totp_backup_codes").
+		Set("used_at", time.Now().UTC()).
+		Where(squirrel.Eq{"user_id": userID, "code_hash": codeHash, "used_at": nil}).
+		ToSql()
+	if err != nil {
+		return false, errors.Wrap(err, op)
+	}
+
+	result, err := r.db.Exec(ctx, query, args...)
+	if err != nil {
+		return false, errors.Wrap(err, op)
+	}
+
+	return result.RowsAffected() > 0, nil
+}
+
+// replaceBackupCodes удаляет резервные коды пользователя и сохраняет новые в рамках транзакции.
+func (r *Repo) replaceBackupCodes(ctx context.Context, tx pgx.Tx, userID uint, hashes []string) error {
+	const op = "repository.replaceBackupCodes"
+
+	query, args, err := r.builder.
+		Delete("totp_backup_codes").
+		Where(squirrel.Eq{"user_id": userID}).
+		ToSql()
+	if err != nil {
+		return errors.Wrap(err, op)
+	}
+	if _, err = tx.Exec(ctx, query, args...); err != nil {
+		return errors.Wrap(err, op)
+	}
+
+	if len(hashes) == 0 {
+		return nil
+	}
+
+	insert := r.builder.Insert("totp_backup_codes").Columns("user_id", "code_hash")
+	for _, hash := range hashes {
+		insert = insert.Values(userID, hash)
+	}
+	query, args, err = insert.ToSql()
+	if err != nil {
+		return errors.Wrap(err, op)
+	}
+	if _, err = tx.Exec(ctx, query, args...); err != nil {
+		return errors.Wrap(err, op)
+	}
+
+	return nil
+}
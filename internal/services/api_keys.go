@@ -0,0 +1,167 @@
+package services
+
+import (
+	"context"
+	"crypto/subtle"
+	"github.com/RVodassa/TaskReward/internal/domain/models"
+	repo "github.com/RVodassa/TaskReward/internal/infrastructure/postgres/repository"
+	"github.com/RVodassa/TaskReward/internal/services/auth"
+	"github.com/pkg/errors"
+	"log"
+	"strings"
+	"time"
+)
+
+const (
+	// apiKeyPrefix отличает API ключи от других токенов, например при поиске утечек в логах и репозиториях
+	apiKeyPrefix       = "trk"
+	apiKeyPrefixLength = 8
+	apiKeyNameMaxLen   = 100
+	// apiKeyAttempts кол-во попыток сгенерировать префикс, не совпадающий с существующими
+	apiKeyAttempts = 5
+)
+
+// CreateAPIKey создает API ключ администратора adminID с правами scopes.
+// Полный ключ вида trk_<префикс>_<секрет> возвращается только здесь, хранится хэш секрета.
+// expiresAt = nil - бессрочный ключ.
+func (s *Service) CreateAPIKey(ctx context.Context, adminID uint, name string, scopes []string, expiresAt *time.Time) (*models.APIKey, error) {
+	const op = "services.CreateAPIKey"
+
+	name = strings.TrimSpace(name)
+	if name == "" || len([]rune(name)) > apiKeyNameMaxLen || len(scopes) == 0 {
+		return nil, ErrInvalidAPIKeyData
+	}
+
+	unique := make([]string, 0, len(scopes))
+	seen := make(map[string]struct{}, len(scopes))
+	for _, scope := range scopes {
+		if !models.IsValidScope(scope) {
+			return nil, ErrInvalidAPIKeyData
+		}
+		if _, ok := seen[scope]; ok {
+			continue
+		}
+		seen[scope] = struct{}{}
+		unique = append(unique, scope)
+	}
+
+	now := time.Now().UTC()
+	if expiresAt != nil {
+		if !expiresAt.After(now) {
+			return nil, ErrInvalidAPIKeyData
+		}
+		utc := expiresAt.UTC()
+		expiresAt = &utc
+	}
+
+	secret, secretHash, err := auth.NewRefreshToken()
+	if err != nil {
+		return nil, errors.Wrap(err, op)
+	}
+
+	key := &models.APIKey{
+		Name:       name,
+		SecretHash: secretHash,
+		Scopes:     unique,
+		CreatedBy:  adminID,
+		CreatedAt:  &now,
+		ExpiresAt:  expiresAt,
+	}
+
+	for attempt := 0; ; attempt++ {
+		prefix, err := randomCode(apiKeyPrefixLength)
+		if err != nil {
+			return nil, errors.Wrap(err, op)
+		}
+		key.Prefix = strings.ToLower(prefix)
+
+		err = s.repo.AddAPIKey(ctx, key)
+		if err == nil {
+			break
+		}
+		if errors.Is(err, repo.ErrUserNotFound) {
+			return nil, ErrUserNotFound
+		}
+		if !errors.Is(err, repo.ErrAPIKeyExists) || attempt+1 == apiKeyAttempts {
+			return nil, errors.Wrap(err, op)
+		}
+	}
+
+	key.Key = apiKeyPrefix + "_" + key.Prefix + "_" + secret
+	return key, nil
+}
+
+// GetAPIKeys возвращает страницу API ключей без секретов.
+func (s *Service) GetAPIKeys(ctx context.Context, limit, offset uint) ([]*models.APIKey, error) {
+	const op = "services.GetAPIKeys"
+
+	keys, err := s.repo.GetAPIKeys(ctx, limit, offset)
+	if err != nil {
+		return nil, errors.Wrap(err, op)
+	}
+
+	return keys, nil
+}
+
+// RevokeAPIKey отзывает API ключ, запросы с ним сразу перестают приниматься.
+func (s *Service) RevokeAPIKey(ctx context.Context, keyID uint) (*models.APIKey, error) {
+	const op = "services.RevokeAPIKey"
+
+	key, err := s.repo.RevokeAPIKey(ctx, keyID)
+	if err != nil {
+		if errors.Is(err, repo.ErrAPIKeyNotFound) {
+			return nil, ErrAPIKeyNotFound
+		}
+		return nil, errors.Wrap(err, op)
+	}
+
+	return key, nil
+}
+
+// AuthenticateAPIKey проверяет ключ из запроса и возвращает его вместе с создавшим его администратором.
+// Ключ недействителен, если он отозван, просрочен, а также если создатель ключа заблокирован
+// или больше не администратор.
+func (s *Service) AuthenticateAPIKey(ctx context.Context, rawKey string) (*models.APIKey, *models.User, error) {
+	const op = "services.AuthenticateAPIKey"
+
+	parts := strings.SplitN(rawKey, "_", 3)
+	if len(parts) != 3 || parts[0] != apiKeyPrefix || parts[1] == "" || parts[2] == "" {
+		return nil, nil, ErrInvalidAPIKey
+	}
+
+	key, err := s.repo.GetAPIKeyByPrefix(ctx, parts[1])
+	if err != nil {
+		if errors.Is(err, repo.ErrAPIKeyNotFound) {
+			return nil, nil, ErrInvalidAPIKey
+		}
+		return nil, nil, errors.Wrap(err, op)
+	}
+
+	hash := auth.HashRefreshToken(parts[2])
+	if subtle.ConstantTimeCompare([]byte(hash), []byte(key.SecretHash)) != 1 {
+		return nil, nil, ErrInvalidAPIKey
+	}
+
+	now := time.Now().UTC()
+	if key.RevokedAt != nil || (key.ExpiresAt != nil && !key.ExpiresAt.After(now)) {
+		return nil, nil, ErrInvalidAPIKey
+	}
+
+	user, err := s.repo.GetUserByID(ctx, key.CreatedBy)
+	if err != nil {
+		if errors.Is(err, repo.ErrUserNotFound) {
+			return nil, nil, ErrInvalidAPIKey
+		}
+		return nil, nil, errors.Wrap(err, op)
+	}
+	if user.BannedAt != nil || user.Role != models.RoleAdmin {
+		return nil, nil, ErrInvalidAPIKey
+	}
+
+	// Время использования не должно влиять на ответ, ошибку только журналируем
+	if err = s.repo.TouchAPIKey(ctx, key.ID, now); err != nil {
+		log.Printf("%s: %v", op, err)
+	}
+
+	return key, user, nil
+}
@@ -1,37 +1,302 @@
 package auth
 
 import (
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"errors"
 	"fmt"
+	"github.com/RVodassa/TaskReward/internal/config"
 	"github.com/go-chi/jwtauth/v5"
+	"github.com/lestrrat-go/jwx/v2/jwa"
+	"github.com/lestrrat-go/jwx/v2/jwk"
+	"github.com/lestrrat-go/jwx/v2/jws"
+	"github.com/lestrrat-go/jwx/v2/jwt"
 	"os"
+	"strconv"
 	"time"
 )
 
-var JWTAuth *jwtauth.JWTAuth
+const (
+	// typeChallenge значение claim typ токена второго шага входа, такой токен не дает доступа к API
+	typeChallenge = "2fa_challenge"
+	// challengeTTL время на ввод кода второго фактора
+	challengeTTL = 5 * time.Minute
+)
+
+var ErrInvalidChallenge = errors.New("invalid challenge token")
+
+// Manager подписывает и проверяет access токены. Токены подписываются активным ключом, а проверяются
+// любым известным ключом по kid из заголовка, поэтому ротация ключа не делает выданные токены недействительными.
+type Manager struct {
+	signingKey jwk.Key
+	signingAlg jwa.SignatureAlgorithm
+	// keys ключи проверки по kid, алгоритм определяется ключом, а не заголовком токена
+	keys map[string]jwk.Key
+	// legacy ключ HS256 из JWT_SECRET для токенов без kid
+	legacy    jwk.Key
+	accessTTL time.Duration
+}
+
+// NewManager загружает ключи подписи из настроек.
+func NewManager(cfg config.JWT) (*Manager, error) {
+	const op = "auth.NewManager"
+
+	m := &Manager{
+		keys:      make(map[string]jwk.Key, len(cfg.Keys)),
+		accessTTL: cfg.AccessTTL,
+	}
+
+	if cfg.Secret != "" {
+		legacy, err := jwk.FromRaw([]byte(cfg.Secret))
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", op, err)
+		}
+		if err = legacy.Set(jwk.AlgorithmKey, jwa.HS256); err != nil {
+			return nil, fmt.Errorf("%s: %w", op, err)
+		}
+		m.legacy = legacy
+		m.signingKey, m.signingAlg = legacy, jwa.HS256
+	}
+
+	signingKeyID := cfg.SigningKeyID
+	for _, keyCfg := range cfg.Keys {
+		key, private, err := loadPEMKey(keyCfg)
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", op, err)
+		}
+		if _, ok := m.keys[keyCfg.ID]; ok {
+			return nil, fmt.Errorf("%s: повторяющийся kid %q", op, keyCfg.ID)
+		}
+
+		public, err := jwk.PublicKeyOf(key)
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", op, err)
+		}
+		m.keys[keyCfg.ID] = public
+
+		if signingKeyID == "" && private {
+			signingKeyID = keyCfg.ID
+		}
+		if keyCfg.ID == signingKeyID {
+			if !private {
+				return nil, fmt.Errorf("%s: ключ подписи %q должен быть закрытым", op, keyCfg.ID)
+			}
+			m.signingKey, m.signingAlg = key, key.Algorithm().(jwa.SignatureAlgorithm)
+		}
+	}
+
+	if signingKeyID != "" && m.signingAlg == jwa.HS256 {
+		return nil, fmt.Errorf("%s: ключ подписи %q не найден в JWT_KEYS", op, signingKeyID)
+	}
+	if m.signingKey == nil {
+		return nil, fmt.Errorf("%s: не задан ключ подписи", op)
+	}
+
+	return m, nil
+}
+
+// loadPEMKey читает ключ из PEM файла и определяет алгоритм по типу ключа: RSA - RS256, Ed25519 - EdDSA.
+// Возвращает признак закрытого ключа.
+func loadPEMKey(cfg config.JWTKey) (jwk.Key, bool, error) {
+	data, err := os.ReadFile(cfg.PEMFile)
+	if err != nil {
+		return nil, false, fmt.Errorf("ключ %q: %w", cfg.ID, err)
+	}
+
+	key, err := jwk.ParseKey(data, jwk.WithPEM(true))
+	if err != nil {
+		return nil, false, fmt.Errorf("ключ %q: %w", cfg.ID, err)
+	}
+
+	var alg jwa.SignatureAlgorithm
+	var private bool
+	switch key.(type) {
+	case jwk.RSAPrivateKey:
+		alg, private = jwa.RS256, true
+	case jwk.RSAPublicKey:
+		alg = jwa.RS256
+	case jwk.OKPPrivateKey:
+		alg, private = jwa.EdDSA, true
+	case jwk.OKPPublicKey:
+		alg = jwa.EdDSA
+	default:
+		return nil, false, fmt.Errorf("ключ %q: неподдерживаемый тип ключа %s, допустимо RSA и Ed25519", cfg.ID, key.KeyType())
+	}
+
+	if err = key.Set(jwk.KeyIDKey, cfg.ID); err != nil {
+		return nil, false, fmt.Errorf("ключ %q: %w", cfg.ID, err)
+	}
+	if err = key.Set(jwk.AlgorithmKey, alg); err != nil {
+		return nil, false, fmt.Errorf("ключ %q: %w", cfg.ID, err)
+	}
+	if err = key.Set(jwk.KeyUsageKey, jwk.ForSignature); err != nil {
+		return nil, false, fmt.Errorf("ключ %q: %w", cfg.ID, err)
+	}
+
+	return key, private, nil
+}
+
+// GenerateToken создает JWT токен, ID пользователя передается в claim sub.
+// Claim jti позволяет отозвать отдельный токен, ver - все токены пользователя с версией ниже текущей.
+func (m *Manager) GenerateToken(userID uint, login string, role string, version uint) (string, error) {
+	const op = "auth.GenerateToken"
+
+	jti, err := NewTokenFamily()
+	if err != nil {
+		return "", fmt.Errorf("%w: %s", err, op)
+	}
+
+	token, err := jwt.NewBuilder().
+		Subject(strconv.FormatUint(uint64(userID), 10)).
+		JwtID(jti).
+		Claim("ver", version).
+		Claim("login", login).
+		Claim("role", role).
+		IssuedAt(time.Now()).
+		Expiration(time.Now().Add(m.accessTTL)).
+		Build()
+	if err != nil {
+		return "", fmt.Errorf("%w: %s", err, op)
+	}
+
+	// kid ключа подписи попадает в заголовок токена
+	signed, err := jwt.Sign(token, jwt.WithKey(m.signingAlg, m.signingKey))
+	if err != nil {
+		return "", fmt.Errorf("%w: %s", err, op)
+	}
+
+	return string(signed), nil
+}
+
+// Verify проверяет подпись и срок действия токена. Ошибки приводятся к ошибкам jwtauth.
+func (m *Manager) Verify(tokenString string) (jwt.Token, error) {
+	token, err := jwt.Parse([]byte(tokenString), jwt.WithKeyProvider(jws.KeyProviderFunc(m.provideKey)), jwt.WithValidate(false))
+	if err != nil {
+		return nil, jwtauth.ErrorReason(err)
+	}
+
+	if err = jwt.Validate(token); err != nil {
+		return token, jwtauth.ErrorReason(err)
+	}
 
-func InitJWTAuth(secretKey []byte) (*jwtauth.JWTAuth, error) {
-	JWTAuth = jwtauth.New("HS256", secretKey, nil)
-	return JWTAuth, nil
+	// Токен второго шага входа не является access токеном
+	if typ, ok := token.Get("typ"); ok && typ == typeChallenge {
+		return nil, jwtauth.ErrUnauthorized
+	}
+
+	return token, nil
 }
 
-func GenerateToken(login string) (string, error) {
-	const op = "service.GenerateToken"
+// GenerateChallengeToken создает короткоживущий токен второго шага входа для пользователя с включенной 2FA.
+// Токен обменивается на access токен вместе с кодом второго фактора.
+func (m *Manager) GenerateChallengeToken(userID uint) (string, error) {
+	const op = "auth.GenerateChallengeToken"
 
-	expStr := os.Getenv("JWT_EXPIRATION")
-	expDur, err := time.ParseDuration(expStr)
+	// jti позволяет ограничить кол-во попыток ввода кода для одного токена
+	jti, err := NewTokenFamily()
 	if err != nil {
-		fmt.Println("Error parsing duration:", err)
-		return "", nil
+		return "", fmt.Errorf("%w: %s", err, op)
 	}
 
-	// Создание токена
-	_, tokenString, err := JWTAuth.Encode(map[string]interface{}{
-		"login": login,
-		"exp":   time.Now().Add(expDur).Unix(),
-	})
+	token, err := jwt.NewBuilder().
+		Subject(strconv.FormatUint(uint64(userID), 10)).
+		JwtID(jti).
+		Claim("typ", typeChallenge).
+		IssuedAt(time.Now()).
+		Expiration(time.Now().Add(challengeTTL)).
+		Build()
 	if err != nil {
 		return "", fmt.Errorf("%w: %s", err, op)
 	}
 
-	return tokenString, nil
+	signed, err := jwt.Sign(token, jwt.WithKey(m.signingAlg, m.signingKey))
+	if err != nil {
+		return "", fmt.Errorf("%w: %s", err, op)
+	}
+
+	return string(signed), nil
+}
+
+// VerifyChallengeToken проверяет токен второго шага входа и возвращает ID пользователя и jti токена.
+func (m *Manager) VerifyChallengeToken(tokenString string) (uint, string, error) {
+	token, err := jwt.Parse([]byte(tokenString), jwt.WithKeyProvider(jws.KeyProviderFunc(m.provideKey)))
+	if err != nil {
+		return 0, "", ErrInvalidChallenge
+	}
+
+	if typ, ok := token.Get("typ"); !ok || typ != typeChallenge {
+		return 0, "", ErrInvalidChallenge
+	}
+
+	userID, err := strconv.ParseUint(token.Subject(), 10, 64)
+	if err != nil || userID == 0 || token.JwtID() == "" {
+		return 0, "", ErrInvalidChallenge
+	}
+
+	return uint(userID), token.JwtID(), nil
+}
+
+// provideKey выбирает ключ проверки по kid. Токены без kid проверяются ключом HS256 из JWT_SECRET.
+// Алгоритм из заголовка должен совпадать с алгоритмом ключа.
+func (m *Manager) provideKey(_ context.Context, sink jws.KeySink, sig *jws.Signature, _ *jws.Message) error {
+	headers := sig.ProtectedHeaders()
+
+	key := m.legacy
+	if kid := headers.KeyID(); kid != "" {
+		key = m.keys[kid]
+	}
+	if key == nil {
+		return fmt.Errorf("неизвестный ключ подписи %q", headers.KeyID())
+	}
+
+	alg, ok := key.Algorithm().(jwa.SignatureAlgorithm)
+	if !ok || headers.Algorithm() != alg {
+		return fmt.Errorf("алгоритм %s не соответствует ключу", headers.Algorithm())
+	}
+
+	sink.Key(alg, key)
+	return nil
+}
+
+// PublicKeys возвращает открытые ключи проверки в формате JWKS. Ключ HS256 не публикуется.
+func (m *Manager) PublicKeys() jwk.Set {
+	set := jwk.NewSet()
+	for _, key := range m.keys {
+		_ = set.AddKey(key)
+	}
+	return set
+}
+
+// NewRefreshToken создает случайный refresh токен и возвращает его вместе с хэшем для хранения.
+func NewRefreshToken() (token string, hash string, err error) {
+	const op = "service.NewRefreshToken"
+
+	buf := make([]byte, 32)
+	if _, err = rand.Read(buf); err != nil {
+		return "", "", fmt.Errorf("%w: %s", err, op)
+	}
+
+	token = base64.RawURLEncoding.EncodeToString(buf)
+	return token, HashRefreshToken(token), nil
+}
+
+// HashRefreshToken возвращает sha256 хэш refresh токена в hex.
+func HashRefreshToken(token string) string {
+	sum := sha256.Sum256([]byte(token))
+	return hex.EncodeToString(sum[:])
+}
+
+// NewTokenFamily создает случайный идентификатор: цепочки refresh токенов или jti access токена.
+func NewTokenFamily() (string, error) {
+	const op = "service.NewTokenFamily"
+
+	buf := make([]byte, 16)
+	if _, err := rand.Read(buf); err != nil {
+		return "", fmt.Errorf("%w: %s", err, op)
+	}
+
+	return hex.EncodeToString(buf), nil
 }
@@ -0,0 +1,274 @@
+package auth
+
+import (
+	"crypto/ed25519"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/x509"
+	"encoding/pem"
+	"github.com/RVodassa/TaskReward/internal/config"
+	"github.com/lestrrat-go/jwx/v2/jwa"
+	"github.com/lestrrat-go/jwx/v2/jwk"
+	"github.com/lestrrat-go/jwx/v2/jwt"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+// writePEM сохраняет DER ключ в PEM файл во временном каталоге теста.
+func writePEM(t *testing.T, name, blockType string, der []byte) string {
+	t.Helper()
+
+	path := filepath.Join(t.TempDir(), name)
+	data := pem.EncodeToMemory(&pem.Block{Type: blockType, Bytes: der})
+	if err := os.WriteFile(path, data, 0o600); err != nil {
+		t.Fatalf("write %s: %v", name, err)
+	}
+	return path
+}
+
+// newRSAKeyFiles создает пару RSA ключей и возвращает пути к закрытому и открытому ключу,
+// а также открытый ключ в PEM.
+func newRSAKeyFiles(t *testing.T) (privatePath, publicPath string, publicPEM []byte) {
+	t.Helper()
+
+	key, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatalf("rsa.GenerateKey: %v", err)
+	}
+	private, err := x509.MarshalPKCS8PrivateKey(key)
+	if err != nil {
+		t.Fatalf("MarshalPKCS8PrivateKey: %v", err)
+	}
+	public, err := x509.MarshalPKIXPublicKey(&key.PublicKey)
+	if err != nil {
+		t.Fatalf("MarshalPKIXPublicKey: %v", err)
+	}
+
+	privatePath = writePEM(t, "rsa.pem", "PRIVATE KEY", private)
+	publicPath = writePEM(t, "rsa.pub.pem", "PUBLIC KEY", public)
+	return privatePath, publicPath, pem.EncodeToMemory(&pem.Block{Type: "PUBLIC KEY", Bytes: public})
+}
+
+// newEd25519KeyFile создает закрытый ключ Ed25519 и возвращает путь к нему.
+func newEd25519KeyFile(t *testing.T) string {
+	t.Helper()
+
+	_, key, err := ed25519.GenerateKey(rand.Reader)
+	if err != nil {
+		t.Fatalf("ed25519.GenerateKey: %v", err)
+	}
+	private, err := x509.MarshalPKCS8PrivateKey(key)
+	if err != nil {
+		t.Fatalf("MarshalPKCS8PrivateKey: %v", err)
+	}
+	return writePEM(t, "ed25519.pem", "PRIVATE KEY", private)
+}
+
+func newTestManager(t *testing.T, cfg config.JWT) *Manager {
+	t.Helper()
+
+	if cfg.AccessTTL == 0 {
+		cfg.AccessTTL = time.Hour
+	}
+	m, err := NewManager(cfg)
+	if err != nil {
+		t.Fatalf("NewManager: %v", err)
+	}
+	return m
+}
+
+// signTestToken подписывает access токен ключом key алгоритмом alg. Если kid не пуст,
+// он передается в заголовке токена.
+func signTestToken(t *testing.T, alg jwa.SignatureAlgorithm, raw interface{}, kid string) string {
+	t.Helper()
+
+	key, err := jwk.FromRaw(raw)
+	if err != nil {
+		t.Fatalf("jwk.FromRaw: %v", err)
+	}
+	if kid != "" {
+		if err = key.Set(jwk.KeyIDKey, kid); err != nil {
+			t.Fatalf("set kid: %v", err)
+		}
+	}
+
+	token, err := jwt.NewBuilder().
+		Subject("1").
+		Expiration(time.Now().Add(time.Hour)).
+		Build()
+	if err != nil {
+		t.Fatalf("jwt.NewBuilder: %v", err)
+	}
+
+	signed, err := jwt.Sign(token, jwt.WithKey(alg, key))
+	if err != nil {
+		t.Fatalf("jwt.Sign: %v", err)
+	}
+	return string(signed)
+}
+
+func TestManagerSignsAndVerifies(t *testing.T) {
+	rsaPrivate, _, _ := newRSAKeyFiles(t)
+
+	tests := []struct {
+		name string
+		cfg  config.JWT
+	}{
+		{name: "HS256", cfg: config.JWT{Secret: "secret"}},
+		{name: "RS256", cfg: config.JWT{Keys: []config.JWTKey{{ID: "rsa", PEMFile: rsaPrivate}}}},
+		{name: "EdDSA", cfg: config.JWT{Keys: []config.JWTKey{{ID: "ed", PEMFile: newEd25519KeyFile(t)}}}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			m := newTestManager(t, tt.cfg)
+
+			token, err := m.GenerateToken(1, "user", "user", 0)
+			if err != nil {
+				t.Fatalf("GenerateToken: %v", err)
+			}
+			if _, err = m.Verify(token); err != nil {
+				t.Errorf("Verify: %v", err)
+			}
+		})
+	}
+}
+
+func TestManagerKeyRotation(t *testing.T) {
+	rsaPrivate, _, _ := newRSAKeyFiles(t)
+	edPrivate := newEd25519KeyFile(t)
+	keys := []config.JWTKey{{ID: "old", PEMFile: rsaPrivate}, {ID: "new", PEMFile: edPrivate}}
+
+	old := newTestManager(t, config.JWT{Keys: keys, SigningKeyID: "old"})
+	token, err := old.GenerateToken(1, "user", "user", 0)
+	if err != nil {
+		t.Fatalf("GenerateToken: %v", err)
+	}
+
+	rotated := newTestManager(t, config.JWT{Keys: keys, SigningKeyID: "new"})
+	if _, err = rotated.Verify(token); err != nil {
+		t.Errorf("Verify token of previous signing key: %v", err)
+	}
+}
+
+func TestManagerRejectsAlgorithmConfusion(t *testing.T) {
+	rsaPrivate, rsaPublic, rsaPublicPEM := newRSAKeyFiles(t)
+	edPrivate := newEd25519KeyFile(t)
+
+	otherRSA, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatalf("rsa.GenerateKey: %v", err)
+	}
+
+	asymmetric := config.JWT{Keys: []config.JWTKey{
+		{ID: "rsa", PEMFile: rsaPrivate},
+		{ID: "ed", PEMFile: edPrivate},
+	}}
+
+	tests := []struct {
+		name  string
+		cfg   config.JWT
+		token string
+	}{
+		{
+			// Классическая атака: открытый RSA ключ используется как секрет HMAC
+			name:  "HS256 with kid of RSA key",
+			cfg:   asymmetric,
+			token: signTestToken(t, jwa.HS256, rsaPublicPEM, "rsa"),
+		},
+		{
+			name:  "HS256 without kid and without JWT_SECRET",
+			cfg:   asymmetric,
+			token: signTestToken(t, jwa.HS256, rsaPublicPEM, ""),
+		},
+		{
+			name:  "HS256 with kid of RSA key when JWT_SECRET is set",
+			cfg:   config.JWT{Secret: "secret", Keys: asymmetric.Keys},
+			token: signTestToken(t, jwa.HS256, []byte("secret"), "rsa"),
+		},
+		{
+			name:  "RS256 with kid of Ed25519 key",
+			cfg:   asymmetric,
+			token: signTestToken(t, jwa.RS256, otherRSA, "ed"),
+		},
+		{
+			name:  "unknown kid",
+			cfg:   asymmetric,
+			token: signTestToken(t, jwa.RS256, otherRSA, "unknown"),
+		},
+		{
+			name:  "RS256 signed by another key",
+			cfg:   asymmetric,
+			token: signTestToken(t, jwa.RS256, otherRSA, "rsa"),
+		},
+		{
+			name:  "RS256 with kid of public-only key",
+			cfg:   config.JWT{Keys: []config.JWTKey{{ID: "ed", PEMFile: edPrivate}, {ID: "rsa", PEMFile: rsaPublic}}},
+			token: signTestToken(t, jwa.RS256, otherRSA, "rsa"),
+		},
+		{
+			name:  "HS256 with wrong secret",
+			cfg:   config.JWT{Secret: "secret"},
+			token: signTestToken(t, jwa.HS256, []byte("other"), ""),
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			m := newTestManager(t, tt.cfg)
+			if _, err := m.Verify(tt.token); err == nil {
+				t.Errorf("Verify accepted forged token")
+			}
+			if _, _, err := m.VerifyChallengeToken(tt.token); err == nil {
+				t.Errorf("VerifyChallengeToken accepted forged token")
+			}
+		})
+	}
+}
+
+func TestManagerLegacySecretWithKeys(t *testing.T) {
+	rsaPrivate, _, _ := newRSAKeyFiles(t)
+
+	// Токены без kid, выданные до перехода на асимметричные ключи, остаются действительными
+	m := newTestManager(t, config.JWT{Secret: "secret", Keys: []config.JWTKey{{ID: "rsa", PEMFile: rsaPrivate}}})
+	if _, err := m.Verify(signTestToken(t, jwa.HS256, []byte("secret"), "")); err != nil {
+		t.Errorf("Verify legacy HS256 token: %v", err)
+	}
+
+	// Новые токены подписываются RS256
+	token, err := m.GenerateToken(1, "user", "user", 0)
+	if err != nil {
+		t.Fatalf("GenerateToken: %v", err)
+	}
+	parsed, err := m.Verify(token)
+	if err != nil {
+		t.Fatalf("Verify: %v", err)
+	}
+	if parsed.Subject() != "1" {
+		t.Errorf("Subject = %q, want 1", parsed.Subject())
+	}
+}
+
+func TestNewManagerRejectsInvalidKeys(t *testing.T) {
+	rsaPrivate, rsaPublic, _ := newRSAKeyFiles(t)
+
+	tests := []struct {
+		name string
+		cfg  config.JWT
+	}{
+		{name: "no keys", cfg: config.JWT{}},
+		{name: "public signing key", cfg: config.JWT{Keys: []config.JWTKey{{ID: "rsa", PEMFile: rsaPublic}}, SigningKeyID: "rsa"}},
+		{name: "unknown signing key", cfg: config.JWT{Secret: "secret", Keys: []config.JWTKey{{ID: "rsa", PEMFile: rsaPrivate}}, SigningKeyID: "other"}},
+		{name: "duplicate kid", cfg: config.JWT{Keys: []config.JWTKey{{ID: "rsa", PEMFile: rsaPrivate}, {ID: "rsa", PEMFile: rsaPublic}}}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := NewManager(tt.cfg); err == nil {
+				t.Errorf("NewManager: expected error")
+			}
+		})
+	}
+}
@@ -0,0 +1,183 @@
+package auth
+
+import (
+	"crypto/rand"
+	"crypto/subtle"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"github.com/RVodassa/TaskReward/internal/config"
+	"golang.org/x/crypto/argon2"
+	"golang.org/x/crypto/bcrypt"
+	"strings"
+)
+
+var (
+	ErrPasswordMismatch    = errors.New("пароль не совпадает с хэшем")
+	ErrUnknownHashEncoding = errors.New("неизвестный формат хэша пароля")
+)
+
+const (
+	argon2idSaltLen = 16
+	argon2idKeyLen  = 32
+)
+
+// PasswordHasher хэширует пароли текущим алгоритмом и проверяет хэши любого поддерживаемого формата.
+type PasswordHasher interface {
+	// Hash возвращает закодированный хэш, в котором указаны алгоритм и параметры.
+	Hash(password string) (string, error)
+	// Verify возвращает ErrPasswordMismatch, если пароль не совпадает с хэшем.
+	Verify(encodedHash, password string) error
+	// NeedsRehash сообщает, что хэш получен другим алгоритмом или с устаревшими параметрами.
+	NeedsRehash(encodedHash string) bool
+}
+
+// NewPasswordHasher создает хэшер паролей с алгоритмом из настроек. Хэши других алгоритмов
+// продолжают проверяться, чтобы их можно было прозрачно перехэшировать при входе.
+func NewPasswordHasher(cfg config.PasswordHashing) (PasswordHasher, error) {
+	switch cfg.Algorithm {
+	case config.PasswordHashArgon2id:
+		return &argon2idHasher{
+			time:    cfg.Argon2Time,
+			memory:  cfg.Argon2Memory,
+			threads: cfg.Argon2Threads,
+		}, nil
+	case config.PasswordHashBcrypt:
+		return &bcryptHasher{cost: cfg.BcryptCost}, nil
+	default:
+		return nil, fmt.Errorf("неизвестный алгоритм хэширования паролей %q", cfg.Algorithm)
+	}
+}
+
+// bcryptHasher хэширует пароли bcrypt, хэши argon2id только проверяет.
+type bcryptHasher struct {
+	cost int
+}
+
+func (h *bcryptHasher) Hash(password string) (string, error) {
+	hash, err := bcrypt.GenerateFromPassword([]byte(password), h.cost)
+	if err != nil {
+		return "", err
+	}
+	return string(hash), nil
+}
+
+func (h *bcryptHasher) Verify(encodedHash, password string) error {
+	return verifyPassword(encodedHash, password)
+}
+
+func (h *bcryptHasher) NeedsRehash(encodedHash string) bool {
+	cost, err := bcrypt.Cost([]byte(encodedHash))
+	return err != nil || cost != h.cost
+}
+
+// argon2idHasher хэширует пароли argon2id, хэши bcrypt только проверяет.
+type argon2idHasher struct {
+	time    uint32
+	memory  uint32 // KiB
+	threads uint8
+}
+
+func (h *argon2idHasher) Hash(password string) (string, error) {
+	salt := make([]byte, argon2idSaltLen)
+	if _, err := rand.Read(salt); err != nil {
+		return "", err
+	}
+
+	key := argon2.IDKey([]byte(password), salt, h.time, h.memory, h.threads, argon2idKeyLen)
+	return encodeArgon2id(argon2idParams{time: h.time, memory: h.memory, threads: h.threads}, salt, key), nil
+}
+
+func (h *argon2idHasher) Verify(encodedHash, password string) error {
+	return verifyPassword(encodedHash, password)
+}
+
+func (h *argon2idHasher) NeedsRehash(encodedHash string) bool {
+	params, salt, key, err := decodeArgon2id(encodedHash)
+	if err != nil {
+		return true
+	}
+	return params.time != h.time || params.memory != h.memory || params.threads != h.threads ||
+		len(salt) != argon2idSaltLen || len(key) != argon2idKeyLen
+}
+
+// argon2idParams параметры argon2id, записанные в хэше.
+type argon2idParams struct {
+	time    uint32
+	memory  uint32
+	threads uint8
+}
+
+// encodeArgon2id кодирует хэш в формате PHC: $argon2id$v=19$m=<KiB>,t=<time>,p=<threads>$<salt>$<key>.
+func encodeArgon2id(params argon2idParams, salt, key []byte) string {
+	return fmt.Sprintf("$argon2id$v=%d$m=%d,t=%d,p=%d$%s$%s",
+		argon2.Version, params.memory, params.time, params.threads,
+		base64.RawStdEncoding.EncodeToString(salt), base64.RawStdEncoding.EncodeToString(key))
+}
+
+// decodeArgon2id разбирает хэш в формате PHC.
+func decodeArgon2id(encodedHash string) (argon2idParams, []byte, []byte, error) {
+	var params argon2idParams
+
+	parts := strings.Split(encodedHash, "$")
+	if len(parts) != 6 || parts[1] != "argon2id" {
+		return params, nil, nil, ErrUnknownHashEncoding
+	}
+
+	var version int
+	if _, err := fmt.Sscanf(parts[2], "v=%d", &version); err != nil || version != argon2.Version {
+		return params, nil, nil, ErrUnknownHashEncoding
+	}
+	_, err := fmt.Sscanf(parts[3], "m=%d,t=%d,p=%d", &params.memory, &params.time, &params.threads)
+	if err != nil || params.time == 0 || params.threads == 0 {
+		return params, nil, nil, ErrUnknownHashEncoding
+	}
+
+	salt, err := base64.RawStdEncoding.DecodeString(parts[4])
+	if err != nil {
+		return params, nil, nil, ErrUnknownHashEncoding
+	}
+	key, err := base64.RawStdEncoding.DecodeString(parts[5])
+	if err != nil || len(key) == 0 {
+		return params, nil, nil, ErrUnknownHashEncoding
+	}
+
+	return params, salt, key, nil
+}
+
+// verifyPassword проверяет пароль по хэшу, алгоритм определяется по префиксу хэша.
+func verifyPassword(encodedHash, password string) error {
+	if strings.HasPrefix(encodedHash, "$argon2id$") {
+		return verifyArgon2id(encodedHash, password)
+	}
+	return verifyBcrypt(encodedHash, password)
+}
+
+// verifyArgon2id проверяет пароль с параметрами, записанными в хэше.
+func verifyArgon2id(encodedHash, password string) error {
+	params, salt, key, err := decodeArgon2id(encodedHash)
+	if err != nil {
+		return err
+	}
+
+	candidate := argon2.IDKey([]byte(password), salt, params.time, params.memory, params.threads, uint32(len(key)))
+	if subtle.ConstantTimeCompare(candidate, key) != 1 {
+		return ErrPasswordMismatch
+	}
+	return nil
+}
+
+// verifyBcrypt проверяет пароль по хэшу bcrypt.
+func verifyBcrypt(encodedHash, password string) error {
+	err := bcrypt.CompareHashAndPassword([]byte(encodedHash), []byte(password))
+	switch {
+	case err == nil:
+		return nil
+	case errors.Is(err, bcrypt.ErrMismatchedHashAndPassword):
+		return ErrPasswordMismatch
+	case errors.Is(err, bcrypt.ErrHashTooShort), errors.Is(err, bcrypt.ErrPasswordTooLong):
+		return err
+	default:
+		return fmt.Errorf("%w: %v", ErrUnknownHashEncoding, err)
+	}
+}
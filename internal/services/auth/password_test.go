@@ -0,0 +1,201 @@
+package auth
+
+import (
+	"errors"
+	"github.com/RVodassa/TaskReward/internal/config"
+	"golang.org/x/crypto/bcrypt"
+	"strings"
+	"testing"
+)
+
+// Параметры с минимальной стоимостью, чтобы тесты работали быстро
+var (
+	testArgon2id = config.PasswordHashing{Algorithm: config.PasswordHashArgon2id, Argon2Time: 1, Argon2Memory: 64, Argon2Threads: 1}
+	testBcrypt   = config.PasswordHashing{Algorithm: config.PasswordHashBcrypt, BcryptCost: bcrypt.MinCost}
+)
+
+func newTestHasher(t *testing.T, cfg config.PasswordHashing) PasswordHasher {
+	t.Helper()
+
+	hasher, err := NewPasswordHasher(cfg)
+	if err != nil {
+		t.Fatalf("NewPasswordHasher: %v", err)
+	}
+	return hasher
+}
+
+func TestPasswordHasherRoundTrip(t *testing.T) {
+	tests := []struct {
+		name   string
+		cfg    config.PasswordHashing
+		prefix string
+	}{
+		{name: "argon2id", cfg: testArgon2id, prefix: "$argon2id$v=19$m=64,t=1,p=1$"},
+		{name: "bcrypt", cfg: testBcrypt, prefix: "$2a$04$"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			hasher := newTestHasher(t, tt.cfg)
+
+			hash, err := hasher.Hash("correct horse")
+			if err != nil {
+				t.Fatalf("Hash: %v", err)
+			}
+			if !strings.HasPrefix(hash, tt.prefix) {
+				t.Errorf("hash = %q, want prefix %q", hash, tt.prefix)
+			}
+			if err = hasher.Verify(hash, "correct horse"); err != nil {
+				t.Errorf("Verify correct password: %v", err)
+			}
+			if err = hasher.Verify(hash, "wrong horse"); !errors.Is(err, ErrPasswordMismatch) {
+				t.Errorf("Verify wrong password = %v, want ErrPasswordMismatch", err)
+			}
+			if hasher.NeedsRehash(hash) {
+				t.Errorf("NeedsRehash = true for hash with current parameters")
+			}
+
+			// Одинаковые пароли дают разные хэши за счет соли
+			other, err := hasher.Hash("correct horse")
+			if err != nil {
+				t.Fatalf("Hash: %v", err)
+			}
+			if other == hash {
+				t.Errorf("two hashes of the same password are equal")
+			}
+		})
+	}
+}
+
+func TestPasswordHasherCrossAlgorithm(t *testing.T) {
+	argon2id := newTestHasher(t, testArgon2id)
+	bcryptHasher := newTestHasher(t, testBcrypt)
+
+	bcryptHash, err := bcryptHasher.Hash("password1")
+	if err != nil {
+		t.Fatalf("Hash: %v", err)
+	}
+	argon2idHash, err := argon2id.Hash("password1")
+	if err != nil {
+		t.Fatalf("Hash: %v", err)
+	}
+
+	tests := []struct {
+		name       string
+		hasher     PasswordHasher
+		hash       string
+		wantRehash bool
+	}{
+		{name: "bcrypt hash after switching to argon2id", hasher: argon2id, hash: bcryptHash, wantRehash: true},
+		{name: "argon2id hash after switching to bcrypt", hasher: bcryptHasher, hash: argon2idHash, wantRehash: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if err := tt.hasher.Verify(tt.hash, "password1"); err != nil {
+				t.Errorf("Verify: %v", err)
+			}
+			if err := tt.hasher.Verify(tt.hash, "password2"); !errors.Is(err, ErrPasswordMismatch) {
+				t.Errorf("Verify wrong password = %v, want ErrPasswordMismatch", err)
+			}
+			if got := tt.hasher.NeedsRehash(tt.hash); got != tt.wantRehash {
+				t.Errorf("NeedsRehash = %v, want %v", got, tt.wantRehash)
+			}
+		})
+	}
+}
+
+func TestPasswordHasherNeedsRehashOnParameterChange(t *testing.T) {
+	argon2idHash, err := newTestHasher(t, testArgon2id).Hash("password1")
+	if err != nil {
+		t.Fatalf("Hash: %v", err)
+	}
+	bcryptHash, err := newTestHasher(t, testBcrypt).Hash("password1")
+	if err != nil {
+		t.Fatalf("Hash: %v", err)
+	}
+
+	withTime, withMemory, withThreads := testArgon2id, testArgon2id, testArgon2id
+	withTime.Argon2Time = 2
+	withMemory.Argon2Memory = 128
+	withThreads.Argon2Threads = 2
+	withCost := testBcrypt
+	withCost.BcryptCost = bcrypt.MinCost + 1
+
+	tests := []struct {
+		name string
+		cfg  config.PasswordHashing
+		hash string
+		want bool
+	}{
+		{name: "argon2id same parameters", cfg: testArgon2id, hash: argon2idHash, want: false},
+		{name: "argon2id time", cfg: withTime, hash: argon2idHash, want: true},
+		{name: "argon2id memory", cfg: withMemory, hash: argon2idHash, want: true},
+		{name: "argon2id threads", cfg: withThreads, hash: argon2idHash, want: true},
+		{name: "bcrypt same cost", cfg: testBcrypt, hash: bcryptHash, want: false},
+		{name: "bcrypt cost", cfg: withCost, hash: bcryptHash, want: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := newTestHasher(t, tt.cfg).NeedsRehash(tt.hash); got != tt.want {
+				t.Errorf("NeedsRehash = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestPasswordHasherRejectsMalformedHashes(t *testing.T) {
+	valid, err := newTestHasher(t, testArgon2id).Hash("password1")
+	if err != nil {
+		t.Fatalf("Hash: %v", err)
+	}
+	parts := strings.Split(valid, "$")
+	salt, key := parts[4], parts[5]
+
+	tests := []struct {
+		name string
+		hash string
+	}{
+		{name: "empty", hash: ""},
+		{name: "plain text", hash: "password1"},
+		{name: "unknown algorithm", hash: "$argon2i$v=19$m=64,t=1,p=1$" + salt + "$" + key},
+		{name: "missing key", hash: "$argon2id$v=19$m=64,t=1,p=1$" + salt},
+		{name: "extra part", hash: valid + "$extra"},
+		{name: "wrong version", hash: "$argon2id$v=16$m=64,t=1,p=1$" + salt + "$" + key},
+		{name: "missing version", hash: "$argon2id$$m=64,t=1,p=1$" + salt + "$" + key},
+		{name: "zero time", hash: "$argon2id$v=19$m=64,t=0,p=1$" + salt + "$" + key},
+		{name: "zero threads", hash: "$argon2id$v=19$m=64,t=1,p=0$" + salt + "$" + key},
+		{name: "threads overflow", hash: "$argon2id$v=19$m=64,t=1,p=256$" + salt + "$" + key},
+		{name: "garbage parameters", hash: "$argon2id$v=19$memory$" + salt + "$" + key},
+		{name: "invalid salt", hash: "$argon2id$v=19$m=64,t=1,p=1$!!!$" + key},
+		{name: "invalid key", hash: "$argon2id$v=19$m=64,t=1,p=1$" + salt + "$!!!"},
+		{name: "empty key", hash: "$argon2id$v=19$m=64,t=1,p=1$" + salt + "$"},
+		{name: "truncated bcrypt", hash: "$2a$04$abc"},
+	}
+
+	for _, cfg := range []config.PasswordHashing{testArgon2id, testBcrypt} {
+		hasher := newTestHasher(t, cfg)
+		for _, tt := range tests {
+			t.Run(cfg.Algorithm+"/"+tt.name, func(t *testing.T) {
+				if err := hasher.Verify(tt.hash, "password1"); err == nil {
+					t.Errorf("Verify accepted malformed hash")
+				}
+				if !hasher.NeedsRehash(tt.hash) {
+					t.Errorf("NeedsRehash = false for malformed hash")
+				}
+			})
+		}
+	}
+
+	// Некорректный хэш argon2id не должен уходить в проверку bcrypt
+	if err := newTestHasher(t, testArgon2id).Verify("$argon2id$v=19$m=64,t=0,p=1$"+salt+"$"+key, "password1"); !errors.Is(err, ErrUnknownHashEncoding) {
+		t.Errorf("Verify = %v, want ErrUnknownHashEncoding", err)
+	}
+}
+
+func TestNewPasswordHasherUnknownAlgorithm(t *testing.T) {
+	if _, err := NewPasswordHasher(config.PasswordHashing{Algorithm: "md5"}); err == nil {
+		t.Errorf("NewPasswordHasher: expected error")
+	}
+}
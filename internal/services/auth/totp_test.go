@@ -0,0 +1,136 @@
+package auth
+
+import (
+	"strings"
+	"testing"
+	"time"
+)
+
+// rfcKey общий секрет тестовых векторов RFC 4226 и RFC 6238 (SHA-1)
+var rfcKey = []byte("12345678901234567890")
+
+func TestHOTPCodeRFC4226(t *testing.T) {
+	// RFC 4226, приложение D
+	want := []string{"755224", "287082", "359152", "969429", "338314", "254676", "287922", "162583", "399871", "520489"}
+
+	for counter, code := range want {
+		if got := totpCode(rfcKey, int64(counter)); got != code {
+			t.Errorf("totpCode(%d) = %s, want %s", counter, got, code)
+		}
+	}
+}
+
+func TestTOTPCodeRFC6238(t *testing.T) {
+	// RFC 6238, приложение B, SHA-1: коды из 8 цифр, последние 6 совпадают с кодом из 6 цифр
+	tests := []struct {
+		unix int64
+		code string
+	}{
+		{unix: 59, code: "94287082"},
+		{unix: 1111111109, code: "07081804"},
+		{unix: 1111111111, code: "14050471"},
+		{unix: 1234567890, code: "89005924"},
+		{unix: 2000000000, code: "69279037"},
+		{unix: 20000000000, code: "65353130"},
+	}
+
+	for _, tt := range tests {
+		step := tt.unix / int64(totpPeriod.Seconds())
+		want := tt.code[len(tt.code)-totpDigits:]
+		if got := totpCode(rfcKey, step); got != want {
+			t.Errorf("totpCode(T=%d) = %s, want %s", tt.unix, got, want)
+		}
+
+		secret := totpEncoding.EncodeToString(rfcKey)
+		gotStep, ok := ValidateTOTP(secret, want, time.Unix(tt.unix, 0))
+		if !ok || gotStep != step {
+			t.Errorf("ValidateTOTP(T=%d) = %d, %v, want %d, true", tt.unix, gotStep, ok, step)
+		}
+	}
+}
+
+func TestValidateTOTPSkew(t *testing.T) {
+	secret := totpEncoding.EncodeToString(rfcKey)
+	now := time.Unix(1234567890, 0)
+	current := now.Unix() / int64(totpPeriod.Seconds())
+
+	tests := []struct {
+		name   string
+		offset int64
+		want   bool
+	}{
+		{name: "two steps early", offset: -2, want: false},
+		{name: "one step early", offset: -1, want: true},
+		{name: "current step", offset: 0, want: true},
+		{name: "one step late", offset: 1, want: true},
+		{name: "two steps late", offset: 2, want: false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			step := current + tt.offset
+			gotStep, ok := ValidateTOTP(secret, totpCode(rfcKey, step), now)
+			if ok != tt.want {
+				t.Fatalf("ValidateTOTP ok = %v, want %v", ok, tt.want)
+			}
+			if ok && gotStep != step {
+				t.Errorf("ValidateTOTP step = %d, want %d", gotStep, step)
+			}
+		})
+	}
+}
+
+func TestValidateTOTPRejectsMalformed(t *testing.T) {
+	secret := totpEncoding.EncodeToString(rfcKey)
+	now := time.Unix(1234567890, 0)
+	code := totpCode(rfcKey, now.Unix()/int64(totpPeriod.Seconds()))
+
+	tests := []struct {
+		name   string
+		secret string
+		code   string
+	}{
+		{name: "empty code", secret: secret, code: ""},
+		{name: "short code", secret: secret, code: code[:totpDigits-1]},
+		{name: "long code", secret: secret, code: code + "0"},
+		{name: "eight digit code", secret: secret, code: "89005924"},
+		{name: "invalid secret", secret: "not base32!", code: code},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, ok := ValidateTOTP(tt.secret, tt.code, now); ok {
+				t.Errorf("ValidateTOTP(%q) accepted", tt.code)
+			}
+		})
+	}
+}
+
+func TestValidateTOTPLowercaseSecret(t *testing.T) {
+	secret := strings.ToLower(totpEncoding.EncodeToString(rfcKey))
+	now := time.Unix(59, 0)
+
+	if _, ok := ValidateTOTP(secret, "287082", now); !ok {
+		t.Errorf("ValidateTOTP rejected lowercase secret")
+	}
+}
+
+func TestNewTOTPSecret(t *testing.T) {
+	secret, err := NewTOTPSecret()
+	if err != nil {
+		t.Fatalf("NewTOTPSecret: %v", err)
+	}
+
+	key, err := totpEncoding.DecodeString(secret)
+	if err != nil {
+		t.Fatalf("decode secret: %v", err)
+	}
+	if len(key) != 20 {
+		t.Errorf("secret length = %d bytes, want 20", len(key))
+	}
+
+	now := time.Now()
+	if _, ok := ValidateTOTP(secret, totpCode(key, now.Unix()/int64(totpPeriod.Seconds())), now); !ok {
+		t.Errorf("ValidateTOTP rejected code for generated secret")
+	}
+}
@@ -0,0 +1,57 @@
+package services
+
+import (
+	"context"
+	"github.com/RVodassa/TaskReward/internal/domain/models"
+	repo "github.com/RVodassa/TaskReward/internal/infrastructure/postgres/repository"
+	"github.com/pkg/errors"
+	"math"
+	"unicode/utf8"
+)
+
+// maxTransactionCommentLen ограничение длины комментария операции в хранилище.
+const maxTransactionCommentLen = 255
+
+// AdjustBalance изменяет баланс пользователя от имени администратора adminID.
+// Положительная сумма начисляет баллы, отрицательная - списывает. Баланс и история хранятся
+// в INTEGER, поэтому сумма вне диапазона int32 отклоняется.
+func (s *Service) AdjustBalance(ctx context.Context, userID uint, amount int64, adminID uint, comment string) (*models.BalanceTransaction, error) {
+	const op = "services.AdjustBalance"
+
+	if amount == 0 || amount < math.MinInt32 || amount > math.MaxInt32 ||
+		utf8.RuneCountInString(comment) > maxTransactionCommentLen {
+		return nil, ErrInvalidAmount
+	}
+
+	txn := &models.BalanceTransaction{
+		UserID:  userID,
+		Amount:  amount,
+		AdminID: adminID,
+		Comment: comment,
+	}
+
+	if err := s.repo.AdjustBalance(ctx, txn); err != nil {
+		switch {
+		case errors.Is(err, repo.ErrUserNotFound):
+			return nil, ErrUserNotFound
+		case errors.Is(err, repo.ErrInsufficientFunds):
+			return nil, ErrInsufficientFunds
+		default:
+			return nil, errors.Wrap(err, op)
+		}
+	}
+
+	return txn, nil
+}
+
+// GetBalanceTransactions возвращает страницу истории операций с балансом пользователя и их общее кол-во.
+func (s *Service) GetBalanceTransactions(ctx context.Context, userID uint, limit, offset uint) ([]*models.BalanceTransaction, uint, error) {
+	const op = "services.GetBalanceTransactions"
+
+	transactions, total, err := s.repo.GetBalanceTransactions(ctx, userID, limit, offset)
+	if err != nil {
+		return nil, 0, errors.Wrap(err, op)
+	}
+
+	return transactions, total, nil
+}
@@ -0,0 +1,106 @@
+package services
+
+import (
+	"context"
+	"fmt"
+	"github.com/RVodassa/TaskReward/internal/domain/models"
+	repo "github.com/RVodassa/TaskReward/internal/infrastructure/postgres/repository"
+	"github.com/RVodassa/TaskReward/internal/services/auth"
+	"github.com/pkg/errors"
+	"time"
+)
+
+// VerifyEmail подтверждает email пользователя по токену из письма.
+func (s *Service) VerifyEmail(ctx context.Context, token string) (*models.User, error) {
+	const op = "services.VerifyEmail"
+
+	if token == "" {
+		return nil, ErrInvalidVerifyToken
+	}
+
+	userID, err := s.repo.VerifyEmail(ctx, auth.HashRefreshToken(token))
+	if err != nil {
+		if errors.Is(err, repo.ErrEmailVerificationTokenInvalid) {
+			return nil, ErrInvalidVerifyToken
+		}
+		return nil, errors.Wrap(err, op)
+	}
+	s.notifyLeaderboardUser(ctx, userID)
+
+	user, err := s.repo.GetUserByID(ctx, userID)
+	if err != nil {
+		return nil, errors.Wrap(err, op)
+	}
+
+	return user, nil
+}
+
+// ResendEmailVerification повторно отправляет письмо для подтверждения email пользователя.
+func (s *Service) ResendEmailVerification(ctx context.Context, userID uint) error {
+	const op = "services.ResendEmailVerification"
+
+	user, err := s.repo.GetUserByID(ctx, userID)
+	if err != nil {
+		if errors.Is(err, repo.ErrUserNotFound) {
+			return ErrUserNotFound
+		}
+		return errors.Wrap(err, op)
+	}
+
+	switch {
+	case user.Email == "":
+		return ErrEmailNotSet
+	case user.Verified:
+		return ErrEmailAlreadyVerified
+	}
+
+	if err = s.sendEmailVerification(ctx, user); err != nil {
+		return errors.Wrap(err, op)
+	}
+
+	return nil
+}
+
+// sendEmailVerification создает токен подтверждения для текущего email пользователя и отправляет письмо.
+func (s *Service) sendEmailVerification(ctx context.Context, user *models.User) error {
+	const op = "services.sendEmailVerification"
+
+	value, hash, err := auth.NewRefreshToken()
+	if err != nil {
+		return errors.Wrap(err, op)
+	}
+
+	now := time.Now().UTC()
+	token := &models.EmailVerificationToken{
+		UserID:    user.ID,
+		Email:     user.Email,
+		TokenHash: hash,
+		ExpiresAt: now.Add(s.cfg.EmailVerificationTTL),
+		CreatedAt: now,
+	}
+	if err = s.repo.AddEmailVerificationToken(ctx, token); err != nil {
+		return errors.Wrap(err, op)
+	}
+
+	go s.sendMail(&models.Email{
+		To:      user.Email,
+		Subject: "Подтверждение email",
+		Body:    s.emailVerificationBody(user.Login, value),
+	})
+
+	return nil
+}
+
+// emailVerificationBody формирует текст письма со ссылкой или токеном подтверждения email.
+func (s *Service) emailVerificationBody(login, token string) string {
+	ttl := s.cfg.EmailVerificationTTL.String()
+
+	if s.cfg.EmailVerificationURL == "" {
+		return fmt.Sprintf("Здравствуйте, %s!\n\nДля подтверждения email передайте этот токен в POST /auth/email/verify:\n%s\n\n"+
+			"Токен действует %s. Если вы не указывали этот адрес, просто проигнорируйте письмо.", login, token, ttl)
+	}
+
+	return fmt.Sprintf("Здравствуйте, %s!\n\nДля подтверждения email перейдите по ссылке:\n%s\n\n"+
+		"Ссылка действует %s. Если вы не указывали этот адрес, просто проигнорируйте письмо.",
+		login, tokenLink(s.cfg.EmailVerificationURL, token), ttl)
+}
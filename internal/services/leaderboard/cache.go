@@ -0,0 +1,410 @@
+package leaderboard
+
+import (
+	"github.com/RVodassa/TaskReward/internal/domain/models"
+	"sort"
+	"sync"
+	"time"
+)
+
+// periods периоды таблиц лидеров, которые хранит кэш
+var periods = []string{models.PeriodDay, models.PeriodWeek, models.PeriodMonth, models.PeriodAll}
+
+// member данные пользователя, от которых зависит участие в рейтингах
+type member struct {
+	profile  models.LeaderboardProfile
+	verified bool
+	excluded bool
+}
+
+// Cache таблицы лидеров за день, неделю, месяц и все время в памяти процесса. Заполняется снимком
+// из базы данных (Replace) и обновляется событиями (ApplyScore, UpdateUser), чтение не обращается к базе.
+// Пока кэш не заполнен или период сменился после снимка, чтение возвращает ok = false.
+type Cache struct {
+	mu              sync.RWMutex
+	location        *time.Location
+	requireVerified bool
+	ready           bool
+	snapshot        *models.LeaderboardSnapshot // последний снимок, по нему отбрасываются уже учтенные бонусы
+	members         map[uint]*member
+	boards          map[string]*board
+	rebuild         chan struct{}
+}
+
+// NewCache создает пустой кэш. Границы периодов считаются в часовом поясе location,
+// requireVerified оставляет в рейтингах только пользователей с подтвержденным email.
+func NewCache(location *time.Location, requireVerified bool) *Cache {
+	return &Cache{
+		location:        location,
+		requireVerified: requireVerified,
+		members:         make(map[uint]*member),
+		boards:          make(map[string]*board),
+		rebuild:         make(chan struct{}, 1),
+	}
+}
+
+// Replace заменяет содержимое кэша снимком, посчитанным с началами периодов starts.
+func (c *Cache) Replace(snapshot *models.LeaderboardSnapshot, starts map[string]*time.Time) {
+	members := make(map[uint]*member, len(snapshot.Scores))
+	boards := make(map[string]*board, len(periods))
+	for _, period := range periods {
+		boards[period] = newBoard(starts[period], len(snapshot.Scores))
+	}
+
+	for _, score := range snapshot.Scores {
+		m := &member{profile: score.LeaderboardProfile, verified: score.Verified, excluded: score.Excluded}
+		members[score.UserID] = m
+
+		ranked := c.ranked(m)
+		totals := map[string]int64{
+			models.PeriodDay:   score.Day,
+			models.PeriodWeek:  score.Week,
+			models.PeriodMonth: score.Month,
+			models.PeriodAll:   score.All,
+		}
+		for period, total := range totals {
+			if total > 0 {
+				boards[period].scores[score.UserID] = total
+				if ranked {
+					boards[period].ranked = append(boards[period].ranked, item{userID: score.UserID, score: total})
+				}
+			}
+		}
+	}
+
+	for _, b := range boards {
+		b.sort()
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.members = members
+	c.boards = boards
+	c.snapshot = snapshot
+	c.ready = true
+}
+
+// Invalidate помечает кэш устаревшим: до следующего Replace чтение возвращает ok = false.
+func (c *Cache) Invalidate() {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.ready = false
+}
+
+// Knows проверяет, что данные пользователя уже есть в кэше.
+func (c *Cache) Knows(userID uint) bool {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	_, ok := c.members[userID]
+	return ok
+}
+
+// ApplyScore добавляет бонус, начисленный пользователю в транзакции xid в момент completedAt, в таблицы
+// периодов, к которым относится completedAt. Бонусы транзакций, вошедших в последний снимок, пропускаются.
+func (c *Cache) ApplyScore(userID uint, bonus int64, completedAt time.Time, xid uint64) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if !c.ready || c.snapshot.Visible(xid) {
+		return
+	}
+
+	m, ok := c.members[userID]
+	ranked := ok && c.ranked(m)
+	for _, b := range c.boards {
+		if b.start == nil || !completedAt.Before(*b.start) {
+			b.add(userID, bonus, ranked)
+		}
+	}
+}
+
+// UpdateUser обновляет профиль пользователя и его участие в рейтингах.
+func (c *Cache) UpdateUser(user *models.User) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	m := &member{
+		profile: models.LeaderboardProfile{
+			DisplayName: user.DisplayName,
+			AvatarURL:   user.AvatarURL,
+			Visibility:  user.LeaderboardVisibility,
+		},
+		verified: user.Verified,
+		excluded: user.LeaderboardExcluded,
+	}
+	c.members[user.ID] = m
+
+	ranked := c.ranked(m)
+	for _, b := range c.boards {
+		b.setRanked(user.ID, ranked)
+	}
+}
+
+// Top возвращает limit лидеров за период period на момент now.
+func (c *Cache) Top(period string, now time.Time, limit uint) (entries []*models.LeaderboardEntry, ok bool) {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+
+	b, ok := c.board(period, now)
+	if !ok {
+		return nil, false
+	}
+
+	end := len(b.ranked)
+	if int(limit) < end {
+		end = int(limit)
+	}
+	return c.entries(b, 0, end), true
+}
+
+// Neighbors возвращает место пользователя за период period на момент now и до neighbors соседей
+// выше и ниже него, в порядке таблицы. Пустой список - пользователь не участвует в рейтинге за период.
+func (c *Cache) Neighbors(userID uint, period string, now time.Time, neighbors uint) (entries []*models.LeaderboardEntry, ok bool) {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+
+	b, ok := c.board(period, now)
+	if !ok {
+		return nil, false
+	}
+
+	i, found := b.find(userID)
+	if !found {
+		return []*models.LeaderboardEntry{}, true
+	}
+
+	start, end := i-int(neighbors), i+int(neighbors)+1
+	if start < 0 {
+		start = 0
+	}
+	if end > len(b.ranked) {
+		end = len(b.ranked)
+	}
+	return c.entries(b, start, end), true
+}
+
+// Rebuild возвращает канал запросов на перестройку кэша.
+func (c *Cache) Rebuild() <-chan struct{} {
+	return c.rebuild
+}
+
+// requestRebuild запрашивает перестройку кэша, повторные запросы до перестройки объединяются.
+func (c *Cache) requestRebuild() {
+	select {
+	case c.rebuild <- struct{}{}:
+	default:
+	}
+}
+
+// board возвращает таблицу периода, если кэш заполнен и период не сменился после снимка.
+// Иначе запрашивает перестройку кэша.
+func (c *Cache) board(period string, now time.Time) (*board, bool) {
+	if !c.ready {
+		c.requestRebuild()
+		return nil, false
+	}
+
+	b, ok := c.boards[period]
+	if !ok {
+		return nil, false
+	}
+
+	start, _ := models.PeriodStart(period, now, c.location)
+	if start != nil && !start.Equal(*b.start) {
+		c.requestRebuild()
+		return nil, false
+	}
+
+	return b, true
+}
+
+// entries возвращает копии мест таблицы b с позиции start до end, Rank считается по уникальным суммам.
+func (c *Cache) entries(b *board, start, end int) []*models.LeaderboardEntry {
+	entries := make([]*models.LeaderboardEntry, 0, end-start)
+	if start >= end {
+		return entries
+	}
+
+	rank := b.rank(b.ranked[start].score)
+	for i := start; i < end; i++ {
+		if i > start && b.ranked[i].score != b.ranked[i-1].score {
+			rank++
+		}
+		entry := &models.LeaderboardEntry{
+			Rank:   rank,
+			UserID: b.ranked[i].userID,
+			Score:  b.ranked[i].score,
+		}
+		if m, ok := c.members[entry.UserID]; ok {
+			entry.LeaderboardProfile = m.profile
+		}
+		entries = append(entries, entry)
+	}
+
+	return entries
+}
+
+// ranked проверяет, что пользователь участвует в рейтингах: не скрыл себя, не исключен администратором
+// и, если требуется, подтвердил email.
+func (c *Cache) ranked(m *member) bool {
+	if m.profile.Visibility == models.VisibilityHidden || m.excluded {
+		return false
+	}
+	return m.verified || !c.requireVerified
+}
+
+// item место пользователя в таблице
+type item struct {
+	userID uint
+	score  int64
+}
+
+// board таблица лидеров одного периода. scores - суммы всех пользователей с бонусами за период,
+// ranked - участвующие в рейтинге по убыванию суммы и возрастанию ID, distinct - уникальные суммы
+// ranked по убыванию и кол-во пользователей с каждой из них для подсчета мест без пропусков.
+type board struct {
+	start    *time.Time
+	scores   map[uint]int64
+	ranked   []item
+	distinct []int64
+	counts   map[int64]int
+}
+
+func newBoard(start *time.Time, capacity int) *board {
+	return &board{
+		start:  start,
+		scores: make(map[uint]int64, capacity),
+		ranked: make([]item, 0, capacity),
+		counts: make(map[int64]int),
+	}
+}
+
+// sort упорядочивает ranked и заполняет уникальные суммы после загрузки снимка.
+func (b *board) sort() {
+	sort.Slice(b.ranked, func(i, j int) bool {
+		return less(b.ranked[i], b.ranked[j])
+	})
+	for _, it := range b.ranked {
+		if b.counts[it.score] == 0 {
+			b.distinct = append(b.distinct, it.score)
+		}
+		b.counts[it.score]++
+	}
+}
+
+// add увеличивает сумму пользователя на bonus. Участвующий в рейтинге пользователь сдвигается
+// на новое место, копируются только места между старой и новой позицией.
+func (b *board) add(userID uint, bonus int64, ranked bool) {
+	i, found := b.find(userID)
+	b.scores[userID] += bonus
+	if !ranked {
+		return
+	}
+
+	it := item{userID: userID, score: b.scores[userID]}
+	if !found {
+		b.insert(it)
+		return
+	}
+
+	b.uncount(b.ranked[i].score)
+	// Позиция считается, пока старое место еще в ranked
+	j := sort.Search(len(b.ranked), func(k int) bool {
+		return !less(b.ranked[k], it)
+	})
+	if j <= i {
+		copy(b.ranked[j+1:i+1], b.ranked[j:i])
+		b.ranked[j] = it
+	} else {
+		copy(b.ranked[i:j-1], b.ranked[i+1:j])
+		b.ranked[j-1] = it
+	}
+	b.count(it.score)
+}
+
+// setRanked включает пользователя с бонусами за период в рейтинг или исключает из него.
+func (b *board) setRanked(userID uint, ranked bool) {
+	score, ok := b.scores[userID]
+	if !ok {
+		return
+	}
+	b.remove(userID)
+	if ranked {
+		b.insert(item{userID: userID, score: score})
+	}
+}
+
+// find возвращает позицию пользователя в ranked.
+func (b *board) find(userID uint) (int, bool) {
+	score, ok := b.scores[userID]
+	if !ok {
+		return 0, false
+	}
+	target := item{userID: userID, score: score}
+	i := sort.Search(len(b.ranked), func(i int) bool {
+		return !less(b.ranked[i], target)
+	})
+	return i, i < len(b.ranked) && b.ranked[i] == target
+}
+
+func (b *board) insert(it item) {
+	i := sort.Search(len(b.ranked), func(i int) bool {
+		return !less(b.ranked[i], it)
+	})
+	b.ranked = append(b.ranked, item{})
+	copy(b.ranked[i+1:], b.ranked[i:])
+	b.ranked[i] = it
+	b.count(it.score)
+}
+
+// remove удаляет пользователя из ranked, если он там есть. Сумма в scores должна совпадать с позицией в ranked.
+func (b *board) remove(userID uint) {
+	i, found := b.find(userID)
+	if !found {
+		return
+	}
+	score := b.ranked[i].score
+	b.ranked = append(b.ranked[:i], b.ranked[i+1:]...)
+	b.uncount(score)
+}
+
+// count учитывает еще одного пользователя с суммой score в уникальных суммах.
+func (b *board) count(score int64) {
+	if b.counts[score] == 0 {
+		j := b.distinctIndex(score)
+		b.distinct = append(b.distinct, 0)
+		copy(b.distinct[j+1:], b.distinct[j:])
+		b.distinct[j] = score
+	}
+	b.counts[score]++
+}
+
+// uncount убирает пользователя с суммой score из уникальных сумм.
+func (b *board) uncount(score int64) {
+	b.counts[score]--
+	if b.counts[score] == 0 {
+		delete(b.counts, score)
+		j := b.distinctIndex(score)
+		b.distinct = append(b.distinct[:j], b.distinct[j+1:]...)
+	}
+}
+
+// rank возвращает место суммы score: кол-во больших уникальных сумм + 1.
+func (b *board) rank(score int64) uint {
+	return uint(b.distinctIndex(score)) + 1
+}
+
+// distinctIndex возвращает позицию score в distinct (или позицию для вставки).
+func (b *board) distinctIndex(score int64) int {
+	return sort.Search(len(b.distinct), func(i int) bool {
+		return b.distinct[i] <= score
+	})
+}
+
+// less порядок таблицы: по убыванию суммы, при равенстве - по возрастанию ID.
+func less(a, b item) bool {
+	if a.score != b.score {
+		return a.score > b.score
+	}
+	return a.userID < b.userID
+}
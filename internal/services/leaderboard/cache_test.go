@@ -0,0 +1,373 @@
+package leaderboard
+
+import (
+	"github.com/RVodassa/TaskReward/internal/domain/models"
+	"math/rand"
+	"reflect"
+	"testing"
+	"time"
+)
+
+// testNow среда, 14 октября 2026 12:00 UTC
+var testNow = time.Date(2026, time.October, 14, 12, 0, 0, 0, time.UTC)
+
+// newTestCache создает кэш в UTC, заполненный снимком snapshot на момент now.
+func newTestCache(snapshot *models.LeaderboardSnapshot, now time.Time, requireVerified bool) *Cache {
+	c := NewCache(time.UTC, requireVerified)
+	c.Replace(snapshot, periodStarts(now))
+	return c
+}
+
+func periodStarts(now time.Time) map[string]*time.Time {
+	starts := make(map[string]*time.Time, len(periods))
+	for _, period := range periods {
+		starts[period], _ = models.PeriodStart(period, now, time.UTC)
+	}
+	return starts
+}
+
+// testScore суммы публичного пользователя с подтвержденным email.
+func testScore(userID uint, day, week, month, all int64) *models.LeaderboardScore {
+	return &models.LeaderboardScore{
+		UserID:             userID,
+		Day:                day,
+		Week:               week,
+		Month:              month,
+		All:                all,
+		Verified:           true,
+		LeaderboardProfile: models.LeaderboardProfile{Visibility: models.VisibilityPublic},
+	}
+}
+
+// testSnapshot снимок со суммами scores и границами транзакций xmin, xmax, xip.
+func testSnapshot(xmin, xmax uint64, xip []uint64, scores ...*models.LeaderboardScore) *models.LeaderboardSnapshot {
+	snapshot := &models.LeaderboardSnapshot{
+		Scores: scores,
+		Xmin:   xmin,
+		Xmax:   xmax,
+		XIP:    make(map[uint64]struct{}, len(xip)),
+	}
+	for _, xid := range xip {
+		snapshot.XIP[xid] = struct{}{}
+	}
+	return snapshot
+}
+
+// rankedPair место и ID пользователя в ответе кэша
+type rankedPair struct {
+	rank   uint
+	userID uint
+}
+
+func pairs(entries []*models.LeaderboardEntry) []rankedPair {
+	result := make([]rankedPair, 0, len(entries))
+	for _, entry := range entries {
+		result = append(result, rankedPair{rank: entry.Rank, userID: entry.UserID})
+	}
+	return result
+}
+
+func assertTop(t *testing.T, c *Cache, period string, now time.Time, limit uint, want []rankedPair) {
+	t.Helper()
+
+	entries, ok := c.Top(period, now, limit)
+	if !ok {
+		t.Fatalf("Top(%s): ok = false", period)
+	}
+	if got := pairs(entries); !reflect.DeepEqual(got, want) {
+		t.Errorf("Top(%s) = %v, want %v", period, got, want)
+	}
+}
+
+func assertBoard(t *testing.T, b *board, ranked []item, distinct []int64) {
+	t.Helper()
+
+	if !reflect.DeepEqual(b.ranked, ranked) {
+		t.Errorf("ranked = %v, want %v", b.ranked, ranked)
+	}
+	if !reflect.DeepEqual(b.distinct, distinct) {
+		t.Errorf("distinct = %v, want %v", b.distinct, distinct)
+	}
+}
+
+func TestBoardInsertRemoveDenseRank(t *testing.T) {
+	b := newBoard(nil, 0)
+	b.add(1, 50, true)
+	b.add(2, 30, true)
+	b.add(3, 50, true)
+	b.add(4, 10, true)
+	b.add(5, 30, true)
+
+	assertBoard(t, b, []item{{1, 50}, {3, 50}, {2, 30}, {5, 30}, {4, 10}}, []int64{50, 30, 10})
+	for score, want := range map[int64]uint{50: 1, 30: 2, 10: 3} {
+		if got := b.rank(score); got != want {
+			t.Errorf("rank(%d) = %d, want %d", score, got, want)
+		}
+	}
+
+	// Удаление одного из пользователей с общей суммой сохраняет сумму
+	b.setRanked(1, false)
+	assertBoard(t, b, []item{{3, 50}, {2, 30}, {5, 30}, {4, 10}}, []int64{50, 30, 10})
+
+	// Удаление последнего пользователя с суммой сдвигает места ниже
+	b.setRanked(3, false)
+	assertBoard(t, b, []item{{2, 30}, {5, 30}, {4, 10}}, []int64{30, 10})
+	if got := b.rank(30); got != 1 {
+		t.Errorf("rank(30) = %d, want 1", got)
+	}
+
+	// Рост суммы переносит пользователя вверх, сумма 10 больше не встречается
+	b.add(4, 25, true)
+	assertBoard(t, b, []item{{4, 35}, {2, 30}, {5, 30}}, []int64{35, 30})
+
+	// Равные суммы упорядочены по ID
+	b.add(5, 5, true)
+	assertBoard(t, b, []item{{4, 35}, {5, 35}, {2, 30}}, []int64{35, 30})
+
+	// Исключенный пользователь копит сумму и возвращается в рейтинг с ней
+	b.setRanked(2, false)
+	b.add(2, 10, false)
+	assertBoard(t, b, []item{{4, 35}, {5, 35}}, []int64{35})
+	b.setRanked(2, true)
+	assertBoard(t, b, []item{{2, 40}, {4, 35}, {5, 35}}, []int64{40, 35})
+
+	if _, found := b.find(3); found {
+		t.Errorf("find(3): found removed user")
+	}
+	if i, found := b.find(5); !found || i != 2 {
+		t.Errorf("find(5) = %d, %v, want 2, true", i, found)
+	}
+}
+
+func TestBoardAddMatchesFullSort(t *testing.T) {
+	b := newBoard(nil, 0)
+	random := rand.New(rand.NewSource(1))
+
+	for step := 0; step < 2000; step++ {
+		userID := uint(random.Intn(50)) + 1
+		// Отрицательные бонусы проверяют сдвиг вниз
+		b.add(userID, random.Int63n(21)-5, true)
+
+		want := newBoard(nil, len(b.scores))
+		for id, score := range b.scores {
+			want.scores[id] = score
+			want.ranked = append(want.ranked, item{userID: id, score: score})
+		}
+		want.sort()
+
+		if !reflect.DeepEqual(b.ranked, want.ranked) || !reflect.DeepEqual(b.distinct, want.distinct) {
+			t.Fatalf("step %d: ranked = %v, distinct = %v, want %v, %v", step, b.ranked, b.distinct, want.ranked, want.distinct)
+		}
+	}
+}
+
+func TestCacheNotReady(t *testing.T) {
+	c := NewCache(time.UTC, false)
+
+	if _, ok := c.Top(models.PeriodAll, testNow, 10); ok {
+		t.Errorf("Top: ok = true before Replace")
+	}
+	select {
+	case <-c.Rebuild():
+	default:
+		t.Errorf("Top did not request rebuild")
+	}
+}
+
+func TestCacheTopAndNeighbors(t *testing.T) {
+	c := newTestCache(testSnapshot(1, 1, nil,
+		testScore(1, 0, 0, 0, 100),
+		testScore(2, 0, 0, 0, 80),
+		testScore(3, 0, 0, 0, 100),
+		testScore(4, 0, 0, 0, 50),
+	), testNow, false)
+
+	assertTop(t, c, models.PeriodAll, testNow, 3, []rankedPair{{1, 1}, {1, 3}, {2, 2}})
+	assertTop(t, c, models.PeriodAll, testNow, 10, []rankedPair{{1, 1}, {1, 3}, {2, 2}, {3, 4}})
+	assertTop(t, c, models.PeriodDay, testNow, 10, []rankedPair{})
+
+	tests := []struct {
+		name      string
+		userID    uint
+		neighbors uint
+		want      []rankedPair
+	}{
+		{name: "middle", userID: 2, neighbors: 1, want: []rankedPair{{1, 3}, {2, 2}, {3, 4}}},
+		{name: "first", userID: 1, neighbors: 2, want: []rankedPair{{1, 1}, {1, 3}, {2, 2}}},
+		{name: "last", userID: 4, neighbors: 5, want: []rankedPair{{1, 1}, {1, 3}, {2, 2}, {3, 4}}},
+		{name: "unknown", userID: 99, neighbors: 1, want: []rankedPair{}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			entries, ok := c.Neighbors(tt.userID, models.PeriodAll, testNow, tt.neighbors)
+			if !ok {
+				t.Fatalf("Neighbors: ok = false")
+			}
+			if got := pairs(entries); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("Neighbors(%d) = %v, want %v", tt.userID, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestCacheApplyScoreSkipsSnapshotTransactions(t *testing.T) {
+	c := newTestCache(testSnapshot(100, 110, []uint64{105},
+		testScore(1, 10, 10, 10, 10),
+		testScore(2, 20, 20, 20, 20),
+	), testNow, false)
+
+	// Транзакции, зафиксированные до снимка, уже учтены в нем
+	c.ApplyScore(1, 15, testNow, 99)
+	c.ApplyScore(1, 15, testNow, 103)
+	assertTop(t, c, models.PeriodDay, testNow, 10, []rankedPair{{1, 2}, {2, 1}})
+
+	// Транзакция, шедшая во время снимка, в него не вошла
+	c.ApplyScore(1, 15, testNow, 105)
+	assertTop(t, c, models.PeriodDay, testNow, 10, []rankedPair{{1, 1}, {2, 2}})
+
+	// Транзакция после снимка, сумма совпадает - место общее
+	c.ApplyScore(2, 5, testNow, 110)
+	assertTop(t, c, models.PeriodDay, testNow, 10, []rankedPair{{1, 1}, {1, 2}})
+
+	// Бонус за вчера не попадает в таблицу дня
+	c.ApplyScore(2, 100, testNow.Add(-24*time.Hour), 111)
+	assertTop(t, c, models.PeriodDay, testNow, 10, []rankedPair{{1, 1}, {1, 2}})
+	assertTop(t, c, models.PeriodWeek, testNow, 10, []rankedPair{{1, 2}, {2, 1}})
+	assertTop(t, c, models.PeriodAll, testNow, 10, []rankedPair{{1, 2}, {2, 1}})
+
+	// Бонус пользователя без данных в кэше учитывается, в рейтинг он попадает после загрузки данных
+	c.ApplyScore(3, 500, testNow, 112)
+	assertTop(t, c, models.PeriodAll, testNow, 1, []rankedPair{{1, 2}})
+	c.UpdateUser(&models.User{ID: 3, Verified: true, LeaderboardVisibility: models.VisibilityPublic})
+	assertTop(t, c, models.PeriodAll, testNow, 1, []rankedPair{{1, 3}})
+}
+
+func TestCachePeriodRollover(t *testing.T) {
+	c := newTestCache(testSnapshot(1, 1, nil,
+		testScore(1, 10, 10, 10, 10),
+	), testNow, false)
+
+	assertTop(t, c, models.PeriodDay, testNow.Add(11*time.Hour), 10, []rankedPair{{1, 1}})
+
+	// Наступил новый день: таблица дня устарела, остальные еще действуют
+	tomorrow := time.Date(2026, time.October, 15, 0, 30, 0, 0, time.UTC)
+	if _, ok := c.Top(models.PeriodDay, tomorrow, 10); ok {
+		t.Errorf("Top(day) after midnight: ok = true")
+	}
+	if _, ok := c.Neighbors(1, models.PeriodDay, tomorrow, 1); ok {
+		t.Errorf("Neighbors(day) after midnight: ok = true")
+	}
+	select {
+	case <-c.Rebuild():
+	default:
+		t.Errorf("rollover did not request rebuild")
+	}
+	assertTop(t, c, models.PeriodWeek, tomorrow, 10, []rankedPair{{1, 1}})
+	assertTop(t, c, models.PeriodAll, tomorrow, 10, []rankedPair{{1, 1}})
+
+	nextMonth := time.Date(2026, time.November, 1, 0, 0, 0, 0, time.UTC)
+	if _, ok := c.Top(models.PeriodMonth, nextMonth, 10); ok {
+		t.Errorf("Top(month) in next month: ok = true")
+	}
+
+	// После перестройки таблица нового дня начинается заново
+	c.Replace(testSnapshot(2, 2, nil, testScore(1, 0, 10, 10, 10)), periodStarts(tomorrow))
+	assertTop(t, c, models.PeriodDay, tomorrow, 10, []rankedPair{})
+	assertTop(t, c, models.PeriodAll, tomorrow, 10, []rankedPair{{1, 1}})
+}
+
+func TestCacheUpdateUser(t *testing.T) {
+	unverified := testScore(2, 0, 0, 0, 50)
+	unverified.Verified = false
+	c := newTestCache(testSnapshot(1, 1, nil,
+		testScore(1, 0, 0, 0, 100),
+		unverified,
+		testScore(3, 0, 0, 0, 30),
+	), testNow, true)
+
+	assertTop(t, c, models.PeriodAll, testNow, 10, []rankedPair{{1, 1}, {2, 3}})
+
+	c.UpdateUser(&models.User{ID: 2, Verified: true, LeaderboardVisibility: models.VisibilityPublic, DisplayName: "bob"})
+	assertTop(t, c, models.PeriodAll, testNow, 10, []rankedPair{{1, 1}, {2, 2}, {3, 3}})
+	entries, _ := c.Top(models.PeriodAll, testNow, 10)
+	if entries[1].DisplayName != "bob" {
+		t.Errorf("DisplayName = %q, want %q", entries[1].DisplayName, "bob")
+	}
+
+	c.UpdateUser(&models.User{ID: 1, Verified: true, LeaderboardVisibility: models.VisibilityHidden})
+	assertTop(t, c, models.PeriodAll, testNow, 10, []rankedPair{{1, 2}, {2, 3}})
+
+	c.UpdateUser(&models.User{ID: 3, Verified: true, LeaderboardVisibility: models.VisibilityPublic, LeaderboardExcluded: true})
+	assertTop(t, c, models.PeriodAll, testNow, 10, []rankedPair{{1, 2}})
+
+	// Скрытый пользователь копит бонусы и возвращается в рейтинг с полной суммой
+	c.ApplyScore(1, 10, testNow, 2)
+	c.UpdateUser(&models.User{ID: 1, Verified: true, LeaderboardVisibility: models.VisibilityPublic})
+	assertTop(t, c, models.PeriodAll, testNow, 10, []rankedPair{{1, 1}, {2, 2}})
+	entries, _ = c.Top(models.PeriodAll, testNow, 1)
+	if entries[0].Score != 110 {
+		t.Errorf("Score = %d, want 110", entries[0].Score)
+	}
+}
+
+// benchmarkUsers кол-во пользователей в бенчмарках, совпадает с BenchmarkRepoGetListTopUsers
+const benchmarkUsers = 200_000
+
+// newBenchmarkCache создает кэш со случайными суммами benchmarkUsers пользователей. Суммы дня
+// берутся из небольшого диапазона, чтобы было много общих мест.
+func newBenchmarkCache(b *testing.B) *Cache {
+	b.Helper()
+
+	random := rand.New(rand.NewSource(1))
+	scores := make([]*models.LeaderboardScore, 0, benchmarkUsers)
+	for i := 1; i <= benchmarkUsers; i++ {
+		day := random.Int63n(100)
+		week := day + random.Int63n(1000)
+		month := week + random.Int63n(10_000)
+		scores = append(scores, testScore(uint(i), day, week, month, month+random.Int63n(100_000)))
+	}
+
+	c := newTestCache(testSnapshot(1, 1, nil, scores...), testNow, false)
+	b.ResetTimer()
+	return c
+}
+
+func BenchmarkCacheTop(b *testing.B) {
+	c := newBenchmarkCache(b)
+
+	for _, period := range []string{models.PeriodDay, models.PeriodAll} {
+		b.Run(period, func(b *testing.B) {
+			for i := 0; i < b.N; i++ {
+				if _, ok := c.Top(period, testNow, 10); !ok {
+					b.Fatal("Top: ok = false")
+				}
+			}
+		})
+	}
+}
+
+func BenchmarkCacheNeighbors(b *testing.B) {
+	c := newBenchmarkCache(b)
+
+	for _, period := range []string{models.PeriodDay, models.PeriodAll} {
+		b.Run(period, func(b *testing.B) {
+			random := rand.New(rand.NewSource(2))
+			for i := 0; i < b.N; i++ {
+				userID := uint(random.Intn(benchmarkUsers)) + 1
+				if _, ok := c.Neighbors(userID, period, testNow, 5); !ok {
+					b.Fatal("Neighbors: ok = false")
+				}
+			}
+		})
+	}
+}
+
+func BenchmarkCacheApplyScore(b *testing.B) {
+	c := newBenchmarkCache(b)
+
+	random := rand.New(rand.NewSource(3))
+	for i := 0; i < b.N; i++ {
+		userID := uint(random.Intn(benchmarkUsers)) + 1
+		c.ApplyScore(userID, 10, testNow, uint64(i)+2)
+	}
+}
@@ -0,0 +1,160 @@
+package leaderboard
+
+import (
+	"context"
+	"encoding/json"
+	"github.com/RVodassa/TaskReward/internal/domain/interfaces"
+	"github.com/RVodassa/TaskReward/internal/domain/models"
+	"log"
+	"sync/atomic"
+	"time"
+)
+
+const (
+	// retryDelay пауза перед повторной подпиской или перестройкой после ошибки
+	retryDelay = 5 * time.Second
+	// eventsBuffer кол-во событий, ожидающих обработки
+	eventsBuffer = 1024
+)
+
+// Syncer поддерживает кэш в актуальном состоянии: перестраивает его из базы данных каждые refresh
+// и по запросу кэша, а между перестройками применяет события LISTEN/NOTIFY, которые рассылаются
+// всем экземплярам сервиса, включая отправителя.
+type Syncer struct {
+	cache     *Cache
+	repo      interfaces.RepositoryProvider
+	refresh   time.Duration
+	listening atomic.Bool
+}
+
+func NewSyncer(cache *Cache, repo interfaces.RepositoryProvider, refresh time.Duration) *Syncer {
+	return &Syncer{
+		cache:   cache,
+		repo:    repo,
+		refresh: refresh,
+	}
+}
+
+// Run синхронизирует кэш до отмены ctx. События и перестройки обрабатываются по очереди в одной
+// горутине: событие, полученное до начала снимка, зафиксировано раньше снимка и уже в нем учтено.
+func (s *Syncer) Run(ctx context.Context) {
+	events := make(chan string, eventsBuffer)
+	go s.listen(ctx, events)
+
+	ticker := time.NewTicker(s.refresh)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			s.rebuild(ctx)
+		case <-s.cache.Rebuild():
+			s.rebuild(ctx)
+		case payload := <-events:
+			s.apply(ctx, payload)
+		}
+	}
+}
+
+// listen подписывается на события и переподключается после ошибок. Пока подписки нет,
+// кэш помечен устаревшим и чтение идет из базы данных.
+func (s *Syncer) listen(ctx context.Context, events chan<- string) {
+	const op = "leaderboard.listen"
+
+	for {
+		err := s.repo.ListenLeaderboard(ctx,
+			func() {
+				s.listening.Store(true)
+				s.cache.requestRebuild()
+			},
+			func(payload string) {
+				select {
+				case events <- payload:
+				case <-ctx.Done():
+				}
+			},
+		)
+		s.listening.Store(false)
+		s.cache.Invalidate()
+		if ctx.Err() != nil {
+			return
+		}
+		log.Printf("%s: %v", op, err)
+
+		select {
+		case <-time.After(retryDelay):
+		case <-ctx.Done():
+			return
+		}
+	}
+}
+
+// rebuild заменяет содержимое кэша снимком из базы данных. Без подписки на события снимок
+// сразу бы устаревал, поэтому перестройка ждет подписки.
+func (s *Syncer) rebuild(ctx context.Context) {
+	const op = "leaderboard.rebuild"
+
+	if !s.listening.Load() {
+		return
+	}
+
+	now := time.Now()
+	starts := make(map[string]*time.Time, len(periods))
+	for _, period := range periods {
+		starts[period], _ = models.PeriodStart(period, now, s.cache.location)
+	}
+
+	snapshot, err := s.repo.GetLeaderboardSnapshot(ctx, *starts[models.PeriodDay], *starts[models.PeriodWeek], *starts[models.PeriodMonth])
+	if err != nil {
+		log.Printf("%s: %v", op, err)
+		// Не повторяем перестройку по каждому запросу чтения, пока база недоступна
+		select {
+		case <-time.After(retryDelay):
+		case <-ctx.Done():
+		}
+		return
+	}
+	if !s.listening.Load() {
+		// Подписка оборвалась во время снимка, перестройка повторится после переподключения
+		return
+	}
+
+	s.cache.Replace(snapshot, starts)
+}
+
+// apply применяет событие к кэшу. Данные пользователя, которого еще нет в кэше, загружаются из базы данных.
+func (s *Syncer) apply(ctx context.Context, payload string) {
+	const op = "leaderboard.apply"
+
+	var event models.LeaderboardEvent
+	if err := json.Unmarshal([]byte(payload), &event); err != nil {
+		log.Printf("%s: некорректное событие %q: %v", op, payload, err)
+		return
+	}
+
+	switch event.Type {
+	case models.LeaderboardEventScore:
+		if !s.cache.Knows(event.UserID) {
+			s.refreshUser(ctx, event.UserID)
+		}
+		s.cache.ApplyScore(event.UserID, event.Bonus, event.CompletedAt, event.XID)
+	case models.LeaderboardEventUser:
+		s.refreshUser(ctx, event.UserID)
+	default:
+		log.Printf("%s: неизвестное событие %q", op, event.Type)
+	}
+}
+
+// refreshUser загружает профиль пользователя и его участие в рейтингах из базы данных.
+func (s *Syncer) refreshUser(ctx context.Context, userID uint) {
+	const op = "leaderboard.refreshUser"
+
+	user, err := s.repo.GetUserByID(ctx, userID)
+	if err != nil {
+		log.Printf("%s: %v", op, err)
+		return
+	}
+	s.cache.UpdateUser(user)
+}
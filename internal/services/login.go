@@ -0,0 +1,109 @@
+package services
+
+import (
+	"context"
+	"github.com/RVodassa/TaskReward/internal/domain/models"
+	repo "github.com/RVodassa/TaskReward/internal/infrastructure/postgres/repository"
+	"github.com/pkg/errors"
+	"log"
+	"time"
+)
+
+// LoginLockedError вход заблокирован после серии неудачных попыток до Until.
+// errors.Is(err, ErrLoginLocked) возвращает true.
+type LoginLockedError struct {
+	Until time.Time
+}
+
+func (e *LoginLockedError) Error() string {
+	return ErrLoginLocked.Error()
+}
+
+func (e *LoginLockedError) Unwrap() error {
+	return ErrLoginLocked
+}
+
+// checkLoginLock возвращает *LoginLockedError, если вход по логину или с IP адреса заблокирован.
+func (s *Service) checkLoginLock(ctx context.Context, login, ip string) error {
+	const op = "services.checkLoginLock"
+
+	lockedUntil, err := s.repo.GetLoginLockedUntil(ctx, login, ip)
+	if err != nil {
+		return errors.Wrap(err, op)
+	}
+	if lockedUntil != nil {
+		return &LoginLockedError{Until: *lockedUntil}
+	}
+
+	return nil
+}
+
+// loginFailed учитывает неудачную попытку входа по логину и IP адресу и при превышении лимита
+// блокирует вход. Возвращает ErrInvalidCredentials либо ошибку хранилища.
+func (s *Service) loginFailed(ctx context.Context, login, ip string) error {
+	const op = "services.loginFailed"
+
+	throttle := s.cfg.LoginThrottle
+	counters := []struct {
+		kind, subject string
+		maxFailures   uint
+	}{
+		{models.LoginFailureByLogin, login, throttle.MaxFailures},
+		{models.LoginFailureByIP, ip, throttle.IPMaxFailures},
+	}
+
+	now := time.Now().UTC()
+	for _, counter := range counters {
+		if counter.subject == "" {
+			continue
+		}
+
+		failures, err := s.repo.RecordLoginFailure(ctx, counter.kind, counter.subject, now.Add(-throttle.FailureWindow))
+		if err != nil {
+			return errors.Wrap(err, op)
+		}
+		if failures < counter.maxFailures {
+			continue
+		}
+
+		lockout := lockoutDuration(throttle, failures-counter.maxFailures)
+		if err = s.repo.LockLogin(ctx, counter.kind, counter.subject, now.Add(lockout)); err != nil {
+			return errors.Wrap(err, op)
+		}
+		log.Printf("%s: вход по %s %q заблокирован на %s после %d неудачных попыток", op, counter.kind, counter.subject, lockout, failures)
+	}
+
+	return ErrInvalidCredentials
+}
+
+// lockoutDuration возвращает длительность блокировки: BaseLockout, удваиваемая за каждую
+// неудачную попытку сверх лимита, но не больше MaxLockout.
+func lockoutDuration(throttle models.LoginThrottle, overLimit uint) time.Duration {
+	lockout := throttle.BaseLockout
+	for i := uint(0); i < overLimit && lockout < throttle.MaxLockout; i++ {
+		lockout *= 2
+	}
+	if lockout > throttle.MaxLockout {
+		lockout = throttle.MaxLockout
+	}
+	return lockout
+}
+
+// UnlockUser снимает блокировку входа и сбрасывает счетчик неудачных попыток по логину пользователя.
+func (s *Service) UnlockUser(ctx context.Context, userID uint) error {
+	const op = "services.UnlockUser"
+
+	user, err := s.repo.GetUserByID(ctx, userID)
+	if err != nil {
+		if errors.Is(err, repo.ErrUserNotFound) {
+			return ErrUserNotFound
+		}
+		return errors.Wrap(err, op)
+	}
+
+	if err = s.repo.ResetLoginFailures(ctx, models.LoginFailureByLogin, user.Login); err != nil {
+		return errors.Wrap(err, op)
+	}
+
+	return nil
+}
@@ -0,0 +1,40 @@
+package services
+
+import (
+	"github.com/RVodassa/TaskReward/internal/domain/models"
+	"testing"
+	"time"
+)
+
+func TestLockoutDuration(t *testing.T) {
+	throttle := models.LoginThrottle{BaseLockout: time.Minute, MaxLockout: 10 * time.Minute}
+
+	tests := []struct {
+		overLimit uint
+		want      time.Duration
+	}{
+		{overLimit: 0, want: time.Minute},
+		{overLimit: 1, want: 2 * time.Minute},
+		{overLimit: 2, want: 4 * time.Minute},
+		{overLimit: 3, want: 8 * time.Minute},
+		{overLimit: 4, want: 10 * time.Minute},
+		{overLimit: 5, want: 10 * time.Minute},
+		{overLimit: 1000, want: 10 * time.Minute},
+	}
+
+	for _, tt := range tests {
+		if got := lockoutDuration(throttle, tt.overLimit); got != tt.want {
+			t.Errorf("lockoutDuration(%d) = %s, want %s", tt.overLimit, got, tt.want)
+		}
+	}
+}
+
+func TestLockoutDurationBaseEqualsMax(t *testing.T) {
+	throttle := models.LoginThrottle{BaseLockout: time.Hour, MaxLockout: time.Hour}
+
+	for _, overLimit := range []uint{0, 1, 10} {
+		if got := lockoutDuration(throttle, overLimit); got != time.Hour {
+			t.Errorf("lockoutDuration(%d) = %s, want %s", overLimit, got, time.Hour)
+		}
+	}
+}
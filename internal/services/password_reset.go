@@ -0,0 +1,225 @@
+package services
+
+import (
+	"context"
+	"fmt"
+	"github.com/RVodassa/TaskReward/internal/domain/models"
+	repo "github.com/RVodassa/TaskReward/internal/infrastructure/postgres/repository"
+	"github.com/RVodassa/TaskReward/internal/services/auth"
+	"github.com/pkg/errors"
+	"log"
+	"net/mail"
+	"net/url"
+	"strings"
+	"time"
+)
+
+const (
+	maxEmailLen = 255
+	// mailSendTimeout ограничивает работу, запущенную в фоне: создание токена и отправку письма
+	mailSendTimeout = time.Minute
+)
+
+// normalizeEmail проверяет email и приводит его к нижнему регистру. Пустой email допустим.
+func normalizeEmail(email string) (string, error) {
+	email = strings.ToLower(strings.TrimSpace(email))
+	if email == "" {
+		return "", nil
+	}
+
+	address, err := mail.ParseAddress(email)
+	if err != nil || address.Address != email || len(email) > maxEmailLen {
+		return "", ErrInvalidEmail
+	}
+
+	return email, nil
+}
+
+// UpdateEmail меняет email пользователя, пустой email удаляет его. На новый адрес отправляется
+// письмо для подтверждения.
+func (s *Service) UpdateEmail(ctx context.Context, userID uint, email string) (*models.User, error) {
+	const op = "services.UpdateEmail"
+
+	email, err := normalizeEmail(email)
+	if err != nil {
+		return nil, err
+	}
+
+	if err = s.repo.UpdateEmail(ctx, userID, email); err != nil {
+		switch {
+		case errors.Is(err, repo.ErrEmailAlreadyUsed):
+			return nil, ErrEmailAlreadyUsed
+		case errors.Is(err, repo.ErrUserNotFound):
+			return nil, ErrUserNotFound
+		default:
+			return nil, errors.Wrap(err, op)
+		}
+	}
+	s.notifyLeaderboardUser(ctx, userID)
+
+	user, err := s.repo.GetUserByID(ctx, userID)
+	if err != nil {
+		return nil, errors.Wrap(err, op)
+	}
+
+	if user.Email != "" && !user.Verified {
+		if err = s.sendEmailVerification(ctx, user); err != nil {
+			return nil, errors.Wrap(err, op)
+		}
+	}
+
+	return user, nil
+}
+
+// RequestPasswordReset отправляет на email письмо с одноразовым токеном сброса пароля.
+// Чтобы не раскрывать, зарегистрирован ли email, для неизвестного адреса ошибка не возвращается,
+// а токен создается и письмо отправляется в фоне: для известного и неизвестного адреса запрос
+// выполняет одинаковую работу - один поиск пользователя.
+func (s *Service) RequestPasswordReset(ctx context.Context, email string) error {
+	const op = "services.RequestPasswordReset"
+
+	email, err := normalizeEmail(email)
+	if err != nil || email == "" {
+		return ErrInvalidEmail
+	}
+
+	user, err := s.repo.GetUserByEmail(ctx, email)
+	if err != nil {
+		if errors.Is(err, repo.ErrUserNotFound) {
+			return nil
+		}
+		return errors.Wrap(err, op)
+	}
+	if user.BannedAt != nil {
+		return nil
+	}
+
+	go s.sendPasswordReset(user, email)
+
+	return nil
+}
+
+// sendPasswordReset создает токен сброса пароля пользователя и отправляет его на email.
+// Выполняется в фоне, ошибки только записываются в журнал.
+func (s *Service) sendPasswordReset(user *models.User, email string) {
+	const op = "services.sendPasswordReset"
+
+	ctx, cancel := context.WithTimeout(context.Background(), mailSendTimeout)
+	defer cancel()
+
+	// Токен сброса создается так же, как refresh токен: случайное значение, хранится только хэш
+	value, hash, err := auth.NewRefreshToken()
+	if err != nil {
+		log.Printf("%s: %v", op, err)
+		return
+	}
+
+	now := time.Now().UTC()
+	token := &models.PasswordResetToken{
+		UserID:    user.ID,
+		TokenHash: hash,
+		ExpiresAt: now.Add(s.cfg.PasswordResetTTL),
+		CreatedAt: now,
+	}
+	if err = s.repo.AddPasswordResetToken(ctx, token); err != nil {
+		log.Printf("%s: %v", op, err)
+		return
+	}
+
+	s.sendMail(&models.Email{
+		To:      email,
+		Subject: "Восстановление пароля",
+		Body:    s.passwordResetBody(user.Login, value),
+	})
+}
+
+// ResetPassword устанавливает новый пароль по токену из письма. Токен одноразовый, все сессии
+// пользователя завершаются, блокировка входа по логину снимается.
+func (s *Service) ResetPassword(ctx context.Context, token, newPassword string) error {
+	const op = "services.ResetPassword"
+
+	if token == "" {
+		return ErrInvalidResetToken
+	}
+	if newPassword == "" {
+		return ErrCredentialsRequired
+	}
+
+	tokenHash := auth.HashRefreshToken(token)
+
+	// Пользователь нужен до сброса, чтобы проверить, что пароль не совпадает с логином
+	userID, err := s.repo.GetPasswordResetUserID(ctx, tokenHash)
+	if err != nil {
+		if errors.Is(err, repo.ErrPasswordResetTokenInvalid) {
+			return ErrInvalidResetToken
+		}
+		return errors.Wrap(err, op)
+	}
+
+	user, err := s.repo.GetUserByID(ctx, userID)
+	if err != nil {
+		if errors.Is(err, repo.ErrUserNotFound) {
+			return ErrInvalidResetToken
+		}
+		return errors.Wrap(err, op)
+	}
+
+	errs := &ValidationError{}
+	s.validatePassword(errs, "new_password", newPassword, user.Login)
+	if err = errs.errOrNil(); err != nil {
+		return err
+	}
+
+	hashedPassword, err := s.hashPassword(newPassword)
+	if err != nil {
+		return errors.Wrap(err, op)
+	}
+
+	if _, err = s.repo.ResetPassword(ctx, tokenHash, hashedPassword); err != nil {
+		if errors.Is(err, repo.ErrPasswordResetTokenInvalid) {
+			return ErrInvalidResetToken
+		}
+		return errors.Wrap(err, op)
+	}
+	if err = s.repo.ResetLoginFailures(ctx, models.LoginFailureByLogin, user.Login); err != nil {
+		return errors.Wrap(err, op)
+	}
+
+	return nil
+}
+
+// passwordResetBody формирует текст письма со ссылкой или токеном сброса пароля.
+func (s *Service) passwordResetBody(login, token string) string {
+	ttl := s.cfg.PasswordResetTTL.String()
+
+	if s.cfg.PasswordResetURL == "" {
+		return fmt.Sprintf("Здравствуйте, %s!\n\nДля восстановления пароля передайте этот токен в POST /auth/password/reset:\n%s\n\n"+
+			"Токен действует %s. Если вы не запрашивали восстановление, просто проигнорируйте письмо.", login, token, ttl)
+	}
+
+	link := tokenLink(s.cfg.PasswordResetURL, token)
+
+	return fmt.Sprintf("Здравствуйте, %s!\n\nДля восстановления пароля перейдите по ссылке:\n%s\n\n"+
+		"Ссылка действует %s. Если вы не запрашивали восстановление, просто проигнорируйте письмо.", login, link, ttl)
+}
+
+// tokenLink добавляет токен из письма к адресу страницы base.
+func tokenLink(base, token string) string {
+	separator := "?"
+	if strings.Contains(base, "?") {
+		separator = "&"
+	}
+	return base + separator + "token=" + url.QueryEscape(token)
+}
+
+// sendMail отправляет письмо в фоне, ошибка отправки только записывается в журнал.
+func (s *Service) sendMail(email *models.Email) {
+	const op = "services.sendMail"
+
+	ctx, cancel := context.WithTimeout(context.Background(), mailSendTimeout)
+	defer cancel()
+
+	if err := s.mailer.Send(ctx, email); err != nil {
+		log.Printf("%s: ошибка при отправке письма: %v", op, err)
+	}
+}
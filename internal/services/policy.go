@@ -0,0 +1,155 @@
+package services
+
+import (
+	_ "embed"
+	"fmt"
+	"github.com/RVodassa/TaskReward/internal/domain/models"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+)
+
+// commonPasswords встроенный список распространенных паролей, по одному в строке.
+//
+//go:embed common_passwords.txt
+var commonPasswords string
+
+// ValidationError ошибки проверки полей запроса. errors.Is(err, ErrValidation) возвращает true.
+type ValidationError struct {
+	Fields []models.FieldError
+}
+
+func (e *ValidationError) Error() string {
+	messages := make([]string, 0, len(e.Fields))
+	for _, field := range e.Fields {
+		messages = append(messages, field.Field+": "+field.Message)
+	}
+	return ErrValidation.Error() + ": " + strings.Join(messages, "; ")
+}
+
+func (e *ValidationError) Unwrap() error {
+	return ErrValidation
+}
+
+// add добавляет ошибку поля field.
+func (e *ValidationError) add(field, message string) {
+	e.Fields = append(e.Fields, models.FieldError{Field: field, Message: message})
+}
+
+// errOrNil возвращает ошибку, только если есть ошибки полей.
+func (e *ValidationError) errOrNil() error {
+	if len(e.Fields) == 0 {
+		return nil
+	}
+	return e
+}
+
+// newBlocklist собирает множество запрещенных паролей из встроенного списка и настроек.
+func newBlocklist(extra []string) map[string]struct{} {
+	lines := append(strings.Split(commonPasswords, "\n"), extra...)
+
+	blocklist := make(map[string]struct{}, len(lines))
+	for _, line := range lines {
+		if line = strings.ToLower(strings.TrimSpace(line)); line != "" {
+			blocklist[line] = struct{}{}
+		}
+	}
+	return blocklist
+}
+
+// validateLogin проверяет формат логина по LoginPolicy.
+func (s *Service) validateLogin(errs *ValidationError, login string) {
+	policy := s.cfg.LoginPolicy
+
+	length := uint(utf8.RuneCountInString(login))
+	if length < policy.MinLength || length > policy.MaxLength {
+		errs.add("login", fmt.Sprintf("длина логина должна быть от %d до %d символов", policy.MinLength, policy.MaxLength))
+		return
+	}
+
+	for i, r := range login {
+		isLetter := r < unicode.MaxASCII && unicode.IsLetter(r)
+		if i == 0 && !isLetter {
+			errs.add("login", "логин должен начинаться с латинской буквы")
+			return
+		}
+		if !isLetter && !(r >= '0' && r <= '9') && r != '_' && r != '.' && r != '-' {
+			errs.add("login", "логин может содержать только латинские буквы, цифры и символы _ . -")
+			return
+		}
+	}
+}
+
+// validatePassword проверяет пароль по PasswordPolicy. field - имя поля пароля в запросе.
+func (s *Service) validatePassword(errs *ValidationError, field, password, login string) {
+	policy := s.cfg.PasswordPolicy
+
+	length := uint(utf8.RuneCountInString(password))
+	switch {
+	case length < policy.MinLength:
+		errs.add(field, fmt.Sprintf("пароль должен содержать не менее %d символов", policy.MinLength))
+	case length > policy.MaxLength:
+		errs.add(field, fmt.Sprintf("пароль должен содержать не более %d символов", policy.MaxLength))
+	case policy.MaxBytes > 0 && uint(len(password)) > policy.MaxBytes:
+		errs.add(field, fmt.Sprintf("пароль длиннее %d байт", policy.MaxBytes))
+	}
+
+	for _, class := range policy.RequiredClasses {
+		if !hasCharClass(password, class) {
+			errs.add(field, "пароль должен содержать "+charClassName(class))
+		}
+	}
+
+	if login != "" && strings.EqualFold(password, login) {
+		errs.add(field, "пароль не должен совпадать с логином")
+	}
+
+	if _, ok := s.passwordBlocklist[strings.ToLower(password)]; ok {
+		errs.add(field, "пароль слишком распространен")
+	}
+}
+
+// hasCharClass проверяет, что в пароле есть символ класса class.
+func hasCharClass(password, class string) bool {
+	for _, r := range password {
+		switch class {
+		case models.CharClassLower:
+			if unicode.IsLower(r) {
+				return true
+			}
+		case models.CharClassUpper:
+			if unicode.IsUpper(r) {
+				return true
+			}
+		case models.CharClassLetter:
+			if unicode.IsLetter(r) {
+				return true
+			}
+		case models.CharClassDigit:
+			if unicode.IsDigit(r) {
+				return true
+			}
+		case models.CharClassSymbol:
+			if !unicode.IsLetter(r) && !unicode.IsDigit(r) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// charClassName возвращает описание класса символов для сообщения об ошибке.
+func charClassName(class string) string {
+	switch class {
+	case models.CharClassLower:
+		return "строчную букву"
+	case models.CharClassUpper:
+		return "заглавную букву"
+	case models.CharClassLetter:
+		return "букву"
+	case models.CharClassDigit:
+		return "цифру"
+	default:
+		return "специальный символ"
+	}
+}
@@ -0,0 +1,123 @@
+package services
+
+import (
+	"context"
+	"fmt"
+	"github.com/RVodassa/TaskReward/internal/domain/models"
+	repo "github.com/RVodassa/TaskReward/internal/infrastructure/postgres/repository"
+	"github.com/pkg/errors"
+	"log"
+	"net/url"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+)
+
+const (
+	displayNameMaxLen = 50
+	avatarURLMaxLen   = 512
+)
+
+// UpdateProfile изменяет публичный профиль пользователя: отображаемое имя и аватар в таблицах лидеров
+// и видимость в них (пустая - public). Пустые displayName и avatarURL удаляют значения.
+func (s *Service) UpdateProfile(ctx context.Context, userID uint, displayName, avatarURL, visibility string) (*models.User, error) {
+	const op = "services.UpdateProfile"
+
+	displayName = strings.TrimSpace(displayName)
+	avatarURL = strings.TrimSpace(avatarURL)
+	if visibility == "" {
+		visibility = models.VisibilityPublic
+	}
+
+	errs := &ValidationError{}
+	validateDisplayName(errs, displayName)
+	validateAvatarURL(errs, avatarURL)
+	if !models.IsValidVisibility(visibility) {
+		errs.add("leaderboard_visibility", "допустимо: public, anonymous, hidden")
+	}
+	if err := errs.errOrNil(); err != nil {
+		return nil, err
+	}
+
+	if err := s.repo.UpdateProfile(ctx, userID, displayName, avatarURL, visibility); err != nil {
+		if errors.Is(err, repo.ErrUserNotFound) {
+			return nil, ErrUserNotFound
+		}
+		return nil, errors.Wrap(err, op)
+	}
+	s.notifyLeaderboardUser(ctx, userID)
+
+	user, err := s.repo.GetUserByID(ctx, userID)
+	if err != nil {
+		return nil, errors.Wrap(err, op)
+	}
+
+	return user, nil
+}
+
+// SetLeaderboardExcluded исключает пользователя из таблиц лидеров и итогов сезонов или возвращает его.
+func (s *Service) SetLeaderboardExcluded(ctx context.Context, userID uint, excluded bool) error {
+	const op = "services.SetLeaderboardExcluded"
+
+	if err := s.repo.SetLeaderboardExcluded(ctx, userID, excluded); err != nil {
+		if errors.Is(err, repo.ErrUserNotFound) {
+			return ErrUserNotFound
+		}
+		return errors.Wrap(err, op)
+	}
+	s.notifyLeaderboardUser(ctx, userID)
+
+	return nil
+}
+
+// validateDisplayName проверяет отображаемое имя: не длиннее displayNameMaxLen символов, без управляющих символов.
+func validateDisplayName(errs *ValidationError, displayName string) {
+	if utf8.RuneCountInString(displayName) > displayNameMaxLen {
+		errs.add("display_name", fmt.Sprintf("имя должно быть не длиннее %d символов", displayNameMaxLen))
+		return
+	}
+	for _, r := range displayName {
+		if !unicode.IsPrint(r) {
+			errs.add("display_name", "имя содержит недопустимые символы")
+			return
+		}
+	}
+}
+
+// validateAvatarURL проверяет, что адрес аватара - абсолютная http(s) ссылка.
+func validateAvatarURL(errs *ValidationError, avatarURL string) {
+	if avatarURL == "" {
+		return
+	}
+	if len(avatarURL) > avatarURLMaxLen {
+		errs.add("avatar_url", fmt.Sprintf("ссылка должна быть не длиннее %d символов", avatarURLMaxLen))
+		return
+	}
+
+	parsed, err := url.Parse(avatarURL)
+	if err != nil || (parsed.Scheme != "https" && parsed.Scheme != "http") || parsed.Host == "" {
+		errs.add("avatar_url", "укажите ссылку вида https://...")
+	}
+}
+
+// notifyLeaderboardUser сообщает кэшам таблиц лидеров всех экземпляров сервиса об изменении данных
+// пользователя. Ошибка только записывается в журнал: кэш исправится при следующей перестройке.
+func (s *Service) notifyLeaderboardUser(ctx context.Context, userID uint) {
+	const op = "services.notifyLeaderboardUser"
+
+	if err := s.repo.NotifyLeaderboardUser(ctx, userID); err != nil {
+		log.Printf("%s: %v", op, err)
+	}
+}
+
+// anonymize скрывает ID, имя и аватар пользователя, выбравшего анонимное участие или скрывшего себя
+// после подведения итогов сезона.
+func anonymize(userID *uint, profile *models.LeaderboardProfile) {
+	if profile.Visibility == models.VisibilityPublic {
+		return
+	}
+	*userID = 0
+	profile.DisplayName = ""
+	profile.AvatarURL = ""
+	profile.Anonymous = true
+}
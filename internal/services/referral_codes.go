@@ -0,0 +1,131 @@
+package services
+
+import (
+	"context"
+	"crypto/rand"
+	"github.com/RVodassa/TaskReward/internal/domain/models"
+	repo "github.com/RVodassa/TaskReward/internal/infrastructure/postgres/repository"
+	"github.com/pkg/errors"
+	"math/big"
+	"net/url"
+	"strings"
+	"time"
+)
+
+const (
+	// codeAlphabet без похожих символов (0/O, 1/I/L), чтобы код было легко продиктовать
+	codeAlphabet       = "ABCDEFGHJKMNPQRSTUVWXYZ23456789"
+	referralCodeLength = 8
+	// referralCodeAttempts кол-во попыток сгенерировать код, не совпадающий с существующими
+	referralCodeAttempts = 5
+)
+
+// CreateReferralCode создает пользователю новый код приглашения.
+// maxUses = 0 - без ограничения кол-ва регистраций, expiresAt = nil - бессрочный код.
+func (s *Service) CreateReferralCode(ctx context.Context, userID uint, maxUses uint, expiresAt *time.Time) (*models.ReferralCode, error) {
+	const op = "services.CreateReferralCode"
+
+	now := time.Now().UTC()
+	if expiresAt != nil {
+		if !expiresAt.After(now) {
+			return nil, ErrInvalidReferralCode
+		}
+		utc := expiresAt.UTC()
+		expiresAt = &utc
+	}
+
+	code := &models.ReferralCode{
+		UserID:    userID,
+		MaxUses:   maxUses,
+		ExpiresAt: expiresAt,
+		Active:    true,
+		CreatedAt: &now,
+	}
+
+	for attempt := 0; ; attempt++ {
+		value, err := randomCode(referralCodeLength)
+		if err != nil {
+			return nil, errors.Wrap(err, op)
+		}
+		code.Code = value
+
+		err = s.repo.AddReferralCode(ctx, code)
+		if err == nil {
+			break
+		}
+		if !errors.Is(err, repo.ErrReferralCodeExists) || attempt+1 == referralCodeAttempts {
+			return nil, errors.Wrap(err, op)
+		}
+	}
+
+	s.setInviteLink(code)
+	return code, nil
+}
+
+// GetReferralCodes возвращает коды приглашения пользователя со ссылками-приглашениями.
+func (s *Service) GetReferralCodes(ctx context.Context, userID uint) ([]*models.ReferralCode, error) {
+	const op = "services.GetReferralCodes"
+
+	codes, err := s.repo.GetReferralCodes(ctx, userID)
+	if err != nil {
+		return nil, errors.Wrap(err, op)
+	}
+
+	for _, code := range codes {
+		s.setInviteLink(code)
+	}
+
+	return codes, nil
+}
+
+// DeactivateReferralCode отключает код приглашения пользователя, регистрации по нему больше не принимаются.
+func (s *Service) DeactivateReferralCode(ctx context.Context, codeID uint, userID uint) (*models.ReferralCode, error) {
+	const op = "services.DeactivateReferralCode"
+
+	code, err := s.repo.DeactivateReferralCode(ctx, codeID, userID)
+	if err != nil {
+		if errors.Is(err, repo.ErrReferralCodeNotFound) {
+			return nil, ErrReferralCodeNotFound
+		}
+		return nil, errors.Wrap(err, op)
+	}
+
+	s.setInviteLink(code)
+	return code, nil
+}
+
+// setInviteLink формирует ссылку-приглашение, если задан REFERRAL_INVITE_URL.
+func (s *Service) setInviteLink(code *models.ReferralCode) {
+	base := s.cfg.Referral.InviteURL
+	if base == "" {
+		return
+	}
+
+	separator := "?"
+	if strings.Contains(base, "?") {
+		separator = "&"
+	}
+	code.Link = base + separator + "ref=" + url.QueryEscape(code.Code)
+}
+
+// randomCode возвращает случайный код длины length из codeAlphabet.
+func randomCode(length int) (string, error) {
+	alphabetLen := big.NewInt(int64(len(codeAlphabet)))
+
+	var b strings.Builder
+	b.Grow(length)
+	for i := 0; i < length; i++ {
+		n, err := rand.Int(rand.Reader, alphabetLen)
+		if err != nil {
+			return "", err
+		}
+		b.WriteByte(codeAlphabet[n.Int64()])
+	}
+
+	return b.String(), nil
+}
+
+// normalizeReferralCode приводит введенный код к формату хранения: без пробелов, в верхнем регистре.
+func normalizeReferralCode(code string) string {
+	return strings.ToUpper(strings.TrimSpace(code))
+}
@@ -0,0 +1,43 @@
+package services
+
+import (
+	"context"
+	"github.com/RVodassa/TaskReward/internal/domain/models"
+	"github.com/pkg/errors"
+)
+
+// GetReferralTree возвращает приглашенных пользователя до глубины depth.
+// depth = 0 - максимальная глубина из настроек реферальной программы.
+func (s *Service) GetReferralTree(ctx context.Context, userID uint, depth uint, limit, offset uint) ([]*models.Referral, error) {
+	const op = "services.GetReferralTree"
+
+	maxDepth := s.cfg.Referral.TreeMaxDepth
+	if depth == 0 {
+		depth = maxDepth
+	}
+	if depth > maxDepth {
+		return nil, ErrInvalidReferralDepth
+	}
+
+	referrals, err := s.repo.GetReferralTree(ctx, userID, depth, limit, offset)
+	if err != nil {
+		return nil, errors.Wrap(err, op)
+	}
+
+	return referrals, nil
+}
+
+// GetReferralStats возвращает статистику приглашений пользователя.
+func (s *Service) GetReferralStats(ctx context.Context, userID uint) (*models.ReferralStats, error) {
+	const op = "services.GetReferralStats"
+
+	stats, err := s.repo.GetReferralStats(ctx, userID, s.cfg.Referral.TreeMaxDepth)
+	if err != nil {
+		return nil, errors.Wrap(err, op)
+	}
+	for _, code := range stats.Codes {
+		s.setInviteLink(code)
+	}
+
+	return stats, nil
+}
@@ -0,0 +1,191 @@
+package services
+
+import (
+	"context"
+	"github.com/RVodassa/TaskReward/internal/domain/models"
+	repo "github.com/RVodassa/TaskReward/internal/infrastructure/postgres/repository"
+	"github.com/pkg/errors"
+	"time"
+	"unicode/utf8"
+)
+
+const (
+	maxRewardNameLen        = 255
+	maxRewardDescriptionLen = 1000
+)
+
+// validReward проверяет название, описание и стоимость награды.
+func validReward(reward *models.Reward) bool {
+	return reward.Name != "" &&
+		utf8.RuneCountInString(reward.Name) <= maxRewardNameLen &&
+		utf8.RuneCountInString(reward.Description) <= maxRewardDescriptionLen &&
+		reward.Cost > 0
+}
+
+// AddReward добавляет награду в каталог.
+func (s *Service) AddReward(ctx context.Context, name, description string, cost, stock uint, active bool) (*models.Reward, error) {
+	const op = "services.AddReward"
+
+	now := time.Now().UTC()
+	reward := &models.Reward{
+		Name:        name,
+		Description: description,
+		Cost:        cost,
+		Stock:       stock,
+		Active:      active,
+		CreatedAt:   &now,
+	}
+	if !validReward(reward) {
+		return nil, ErrInvalidRewardData
+	}
+
+	if err := s.repo.AddReward(ctx, reward); err != nil {
+		return nil, errors.Wrap(err, op)
+	}
+
+	return reward, nil
+}
+
+// GetRewards возвращает каталог наград, onlyActive оставляет только доступные для обмена.
+func (s *Service) GetRewards(ctx context.Context, onlyActive bool, limit, offset uint) ([]*models.Reward, error) {
+	const op = "services.GetRewards"
+
+	rewards, err := s.repo.GetRewards(ctx, onlyActive, limit, offset)
+	if err != nil {
+		return nil, errors.Wrap(err, op)
+	}
+
+	return rewards, nil
+}
+
+// UpdateReward частично обновляет награду, nil поля остаются без изменений.
+func (s *Service) UpdateReward(ctx context.Context, rewardID uint, name, description *string, cost, stock *uint, active *bool) (*models.Reward, error) {
+	const op = "services.UpdateReward"
+
+	reward, err := s.repo.GetRewardByID(ctx, rewardID)
+	if err != nil {
+		if errors.Is(err, repo.ErrRewardNotFound) {
+			return nil, ErrRewardNotFound
+		}
+		return nil, errors.Wrap(err, op)
+	}
+
+	if name != nil {
+		reward.Name = *name
+	}
+	if description != nil {
+		reward.Description = *description
+	}
+	if cost != nil {
+		reward.Cost = *cost
+	}
+	if stock != nil {
+		reward.Stock = *stock
+	}
+	if active != nil {
+		reward.Active = *active
+	}
+	if !validReward(reward) {
+		return nil, ErrInvalidRewardData
+	}
+
+	if err = s.repo.UpdateReward(ctx, reward); err != nil {
+		if errors.Is(err, repo.ErrRewardNotFound) {
+			return nil, ErrRewardNotFound
+		}
+		return nil, errors.Wrap(err, op)
+	}
+
+	return reward, nil
+}
+
+// DeleteReward удаляет награду, на которую еще не было заказов.
+func (s *Service) DeleteReward(ctx context.Context, rewardID uint) error {
+	const op = "services.DeleteReward"
+
+	if err := s.repo.DeleteReward(ctx, rewardID); err != nil {
+		switch {
+		case errors.Is(err, repo.ErrRewardNotFound):
+			return ErrRewardNotFound
+		case errors.Is(err, repo.ErrRewardHasOrders):
+			return ErrRewardHasOrders
+		default:
+			return errors.Wrap(err, op)
+		}
+	}
+
+	return nil
+}
+
+// RedeemReward обменивает баллы пользователя на награду и создает заказ.
+func (s *Service) RedeemReward(ctx context.Context, userID uint, rewardID uint) (*models.RewardOrder, error) {
+	const op = "services.RedeemReward"
+
+	order, err := s.repo.RedeemReward(ctx, userID, rewardID)
+	if err != nil {
+		return nil, mapOrderError(err, op)
+	}
+
+	return order, nil
+}
+
+// GetRewardOrders возвращает заказы пользователя userID (0 - всех пользователей) с фильтром по статусу.
+func (s *Service) GetRewardOrders(ctx context.Context, userID uint, status string, limit, offset uint) ([]*models.RewardOrder, error) {
+	const op = "services.GetRewardOrders"
+
+	if status != "" && !models.IsValidOrderStatus(status) {
+		return nil, ErrInvalidOrderStatus
+	}
+
+	orders, err := s.repo.GetRewardOrders(ctx, userID, status, limit, offset)
+	if err != nil {
+		return nil, errors.Wrap(err, op)
+	}
+
+	return orders, nil
+}
+
+// FulfillRewardOrder отмечает заказ выданным.
+func (s *Service) FulfillRewardOrder(ctx context.Context, orderID uint) (*models.RewardOrder, error) {
+	const op = "services.FulfillRewardOrder"
+
+	order, err := s.repo.FulfillRewardOrder(ctx, orderID)
+	if err != nil {
+		return nil, mapOrderError(err, op)
+	}
+
+	return order, nil
+}
+
+// CancelRewardOrder отменяет ожидающий заказ и возвращает баллы. userID != 0 разрешает отмену
+// только собственных заказов пользователя.
+func (s *Service) CancelRewardOrder(ctx context.Context, orderID uint, userID uint) (*models.RewardOrder, error) {
+	const op = "services.CancelRewardOrder"
+
+	order, err := s.repo.CancelRewardOrder(ctx, orderID, userID)
+	if err != nil {
+		return nil, mapOrderError(err, op)
+	}
+
+	return order, nil
+}
+
+// mapOrderError преобразует ошибки хранилища при работе с заказами в ошибки сервиса.
+func mapOrderError(err error, op string) error {
+	switch {
+	case errors.Is(err, repo.ErrRewardNotFound):
+		return ErrRewardNotFound
+	case errors.Is(err, repo.ErrRewardOutOfStock):
+		return ErrRewardOutOfStock
+	case errors.Is(err, repo.ErrInsufficientFunds):
+		return ErrInsufficientFunds
+	case errors.Is(err, repo.ErrUserNotFound):
+		return ErrUserNotFound
+	case errors.Is(err, repo.ErrOrderNotFound):
+		return ErrOrderNotFound
+	case errors.Is(err, repo.ErrOrderNotPending):
+		return ErrOrderNotPending
+	default:
+		return errors.Wrap(err, op)
+	}
+}
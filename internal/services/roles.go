@@ -0,0 +1,87 @@
+package services
+
+import (
+	"context"
+	"github.com/RVodassa/TaskReward/internal/domain/models"
+	repo "github.com/RVodassa/TaskReward/internal/infrastructure/postgres/repository"
+	"github.com/pkg/errors"
+	"log"
+)
+
+// SetUserRole назначает пользователю роль.
+func (s *Service) SetUserRole(ctx context.Context, userID uint, role string) error {
+	const op = "services.SetUserRole"
+
+	if !models.IsValidRole(role) {
+		return ErrInvalidRole
+	}
+
+	if err := s.repo.SetUserRole(ctx, userID, role); err != nil {
+		if errors.Is(err, repo.ErrUserNotFound) {
+			return ErrUserNotFound
+		}
+		return errors.Wrap(err, op)
+	}
+
+	return nil
+}
+
+// SetUserBanned блокирует или разблокирует пользователя. Блокировка сразу отзывает все токены пользователя.
+func (s *Service) SetUserBanned(ctx context.Context, userID uint, banned bool) error {
+	const op = "services.SetUserBanned"
+
+	if err := s.repo.SetUserBanned(ctx, userID, banned); err != nil {
+		if errors.Is(err, repo.ErrUserNotFound) {
+			return ErrUserNotFound
+		}
+		return errors.Wrap(err, op)
+	}
+
+	return nil
+}
+
+// EnsureAdmin создает администратора с указанным логином и паролем.
+// Если пользователь уже существует, роль администратора назначается только при совпадении пароля,
+// иначе возвращается ErrAdminLoginTaken: логин мог зарегистрировать кто-то другой.
+func (s *Service) EnsureAdmin(ctx context.Context, login, password string) error {
+	const op = "services.EnsureAdmin"
+
+	existing, err := s.repo.GetUserByLogin(ctx, login)
+	switch {
+	case err == nil:
+		if existing.Role == models.RoleAdmin {
+			return nil
+		}
+		if err = s.checkPassword(existing.PasswordHash, password); err != nil {
+			if errors.Is(err, ErrIncorrectPassword) {
+				return ErrAdminLoginTaken
+			}
+			return errors.Wrap(err, op)
+		}
+		if err = s.repo.SetUserRole(ctx, existing.ID, models.RoleAdmin); err != nil {
+			return errors.Wrap(err, op)
+		}
+		log.Printf("%s: пользователю %s назначена роль администратора", op, login)
+		return nil
+	case !errors.Is(err, repo.ErrUserNotFound):
+		return errors.Wrap(err, op)
+	}
+
+	if login == "" || password == "" {
+		return ErrCredentialsRequired
+	}
+
+	hashedPassword, err := s.hashPassword(password)
+	if err != nil {
+		return errors.Wrap(err, op)
+	}
+
+	user := models.NewUser(login, hashedPassword, "", 0)
+	user.Role = models.RoleAdmin
+	if err = s.repo.RegisterUser(ctx, user, ""); err != nil {
+		return errors.Wrap(err, op)
+	}
+	log.Printf("%s: создан администратор %s", op, login)
+
+	return nil
+}
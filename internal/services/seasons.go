@@ -0,0 +1,123 @@
+package services
+
+import (
+	"context"
+	"github.com/RVodassa/TaskReward/internal/domain/models"
+	repo "github.com/RVodassa/TaskReward/internal/infrastructure/postgres/repository"
+	"github.com/pkg/errors"
+	"strings"
+	"time"
+)
+
+const (
+	seasonNameMaxLen = 100
+	// seasonMaxPrizes максимальное кол-во призовых мест
+	seasonMaxPrizes = 100
+)
+
+// CreateSeason создает сезон с startsAt по endsAt. prizes - призы за места, начисляемые на баланс
+// при закрытии сезона: prizes[0] - за 1 место и т.д. Сезон не может закончиться в прошлом
+// и пересекаться с другими сезонами.
+func (s *Service) CreateSeason(ctx context.Context, name string, startsAt, endsAt time.Time, prizes []uint) (*models.Season, error) {
+	const op = "services.CreateSeason"
+
+	name = strings.TrimSpace(name)
+	now := time.Now().UTC()
+	if name == "" || len([]rune(name)) > seasonNameMaxLen || len(prizes) > seasonMaxPrizes {
+		return nil, ErrInvalidSeasonData
+	}
+	if !endsAt.After(startsAt) || !endsAt.After(now) {
+		return nil, ErrInvalidSeasonData
+	}
+	if prizes == nil {
+		prizes = []uint{}
+	}
+
+	season := &models.Season{
+		Name:      name,
+		StartsAt:  startsAt.UTC(),
+		EndsAt:    endsAt.UTC(),
+		Prizes:    prizes,
+		CreatedAt: &now,
+	}
+
+	if err := s.repo.AddSeason(ctx, season); err != nil {
+		if errors.Is(err, repo.ErrSeasonOverlap) {
+			return nil, ErrSeasonOverlap
+		}
+		return nil, errors.Wrap(err, op)
+	}
+
+	season.Status = season.StatusAt(now)
+	return season, nil
+}
+
+// GetSeasons возвращает страницу сезонов, последние первыми.
+func (s *Service) GetSeasons(ctx context.Context, limit, offset uint) ([]*models.Season, error) {
+	const op = "services.GetSeasons"
+
+	seasons, err := s.repo.GetSeasons(ctx, limit, offset)
+	if err != nil {
+		return nil, errors.Wrap(err, op)
+	}
+
+	now := time.Now().UTC()
+	for _, season := range seasons {
+		season.Status = season.StatusAt(now)
+	}
+
+	return seasons, nil
+}
+
+// GetSeasonStandings возвращает сезон и страницу его таблицы: итоговую с призами для закрытого сезона,
+// текущую по баллам - для остальных.
+func (s *Service) GetSeasonStandings(ctx context.Context, seasonID uint, limit, offset uint) (*models.Season, []*models.SeasonStanding, error) {
+	const op = "services.GetSeasonStandings"
+
+	season, err := s.repo.GetSeason(ctx, seasonID)
+	if err != nil {
+		if errors.Is(err, repo.ErrSeasonNotFound) {
+			return nil, nil, ErrSeasonNotFound
+		}
+		return nil, nil, errors.Wrap(err, op)
+	}
+	season.Status = season.StatusAt(time.Now().UTC())
+
+	var standings []*models.SeasonStanding
+	if season.Status == models.SeasonStatusClosed {
+		standings, err = s.repo.GetSeasonStandings(ctx, seasonID, limit, offset)
+	} else {
+		standings, err = s.repo.GetSeasonScores(ctx, seasonID, s.cfg.RequireVerified, limit, offset)
+	}
+	if err != nil {
+		return nil, nil, errors.Wrap(err, op)
+	}
+
+	for _, standing := range standings {
+		anonymize(&standing.UserID, &standing.LeaderboardProfile)
+	}
+
+	return season, standings, nil
+}
+
+// CloseSeason подводит итоги завершившегося сезона: сохраняет итоговую таблицу и начисляет призы.
+// При включенном REQUIRE_VERIFIED в итоговую таблицу попадают только пользователи с подтвержденным email.
+func (s *Service) CloseSeason(ctx context.Context, seasonID uint, adminID uint) (*models.Season, error) {
+	const op = "services.CloseSeason"
+
+	season, err := s.repo.CloseSeason(ctx, seasonID, adminID, s.cfg.RequireVerified)
+	if err != nil {
+		switch {
+		case errors.Is(err, repo.ErrSeasonNotFound):
+			return nil, ErrSeasonNotFound
+		case errors.Is(err, repo.ErrSeasonClosed):
+			return nil, ErrSeasonClosed
+		case errors.Is(err, repo.ErrSeasonNotEnded):
+			return nil, ErrSeasonNotEnded
+		}
+		return nil, errors.Wrap(err, op)
+	}
+
+	season.Status = season.StatusAt(time.Now().UTC())
+	return season, nil
+}
@@ -3,12 +3,15 @@ package services
 import (
 	"context"
 	"fmt"
+	"github.com/RVodassa/TaskReward/internal/config"
 	"github.com/RVodassa/TaskReward/internal/domain/interfaces"
 	"github.com/RVodassa/TaskReward/internal/domain/models"
 	repo "github.com/RVodassa/TaskReward/internal/infrastructure/postgres/repository"
+	"github.com/RVodassa/TaskReward/internal/services/auth"
+	"github.com/RVodassa/TaskReward/internal/services/leaderboard"
 	"github.com/pkg/errors"
-	"golang.org/x/crypto/bcrypt"
 	"log"
+	"time"
 )
 
 var (
@@ -19,42 +22,199 @@ var (
 	ErrUserNotFound         = errors.New("ошибка: пользователь найден")
 	ErrTaskNotFound         = errors.New("ошибка: задача не найдена")
 	ErrTaskAlreadyCompleted = errors.New("ошибка: задача уже выполнена")
+	ErrTaskCompletionLimit  = errors.New("ошибка: достигнут лимит выполнений задачи")
+	ErrTaskArchived         = errors.New("ошибка: задача в архиве")
+	ErrTaskHasCompletions   = errors.New("ошибка: задача уже выполнялась пользователями")
+	ErrInvalidTaskData      = errors.New("ошибка: некорректное описание или бонус задачи")
+	ErrInvalidTaskStatus    = errors.New("ошибка: неизвестный статус задачи")
+	ErrInvalidRole          = errors.New("ошибка: неизвестная роль пользователя")
+	ErrInvalidAmount        = errors.New("ошибка: некорректная сумма или комментарий операции")
+	ErrInsufficientFunds    = errors.New("ошибка: недостаточно средств на балансе")
+	ErrInvalidRewardData    = errors.New("ошибка: некорректное название или стоимость награды")
+	ErrRewardNotFound       = errors.New("ошибка: награда не найдена")
+	ErrRewardOutOfStock     = errors.New("ошибка: награда закончилась")
+	ErrRewardHasOrders      = errors.New("ошибка: на награду уже есть заказы")
+	ErrOrderNotFound        = errors.New("ошибка: заказ не найден")
+	ErrOrderNotPending      = errors.New("ошибка: заказ уже обработан")
+	ErrInvalidOrderStatus   = errors.New("ошибка: неизвестный статус заказа")
+	ErrInvalidReferralDepth = errors.New("ошибка: некорректная глубина дерева приглашенных")
+	ErrReferralCodeInvalid  = errors.New("ошибка: код приглашения недействителен")
+	ErrReferralCodeNotFound = errors.New("ошибка: код приглашения не найден")
+	ErrInvalidReferralCode  = errors.New("ошибка: некорректные параметры кода приглашения")
+	ErrInvalidRefreshToken  = errors.New("ошибка: refresh токен недействителен")
+	ErrRefreshTokenReused   = errors.New("ошибка: повторное использование refresh токена, сессия отозвана")
+	ErrUserBanned           = errors.New("ошибка: пользователь заблокирован")
+	ErrInvalidCredentials   = errors.New("ошибка: неверный логин или пароль")
+	ErrLoginLocked          = errors.New("ошибка: вход временно заблокирован")
+	ErrTOTPAlreadyEnabled   = errors.New("ошибка: двухфакторная аутентификация уже включена")
+	ErrTOTPNotEnrolled      = errors.New("ошибка: двухфакторная аутентификация не подключена")
+	ErrTOTPNotEnabled       = errors.New("ошибка: двухфакторная аутентификация не включена")
+	ErrInvalidTOTPCode      = errors.New("ошибка: неверный код двухфакторной аутентификации")
+	ErrChallengeExhausted   = errors.New("ошибка: исчерпаны попытки ввода кода, войдите заново")
+	ErrInvalidEmail         = errors.New("ошибка: некорректный email")
+	ErrEmailAlreadyUsed     = errors.New("ошибка: email уже используется другим пользователем")
+	ErrInvalidResetToken    = errors.New("ошибка: токен сброса пароля недействителен или истек")
+	ErrInvalidVerifyToken   = errors.New("ошибка: токен подтверждения email недействителен или истек")
+	ErrEmailNotSet          = errors.New("ошибка: email не указан")
+	ErrEmailAlreadyVerified = errors.New("ошибка: email уже подтвержден")
+	ErrEmailNotVerified     = errors.New("ошибка: email не подтвержден")
+	ErrValidation           = errors.New("ошибка: данные не прошли проверку")
+	ErrAdminLoginTaken      = errors.New("ошибка: пользователь с логином администратора уже существует, пароль не совпадает")
+	ErrInvalidAPIKey        = errors.New("ошибка: API ключ недействителен")
+	ErrInvalidAPIKeyData    = errors.New("ошибка: некорректные название, права или срок действия API ключа")
+	ErrAPIKeyNotFound       = errors.New("ошибка: API ключ не найден")
+	ErrInvalidPeriod        = errors.New("ошибка: неизвестный период таблицы лидеров")
+	ErrInvalidSeasonData    = errors.New("ошибка: некорректные название, даты или призы сезона")
+	ErrSeasonNotFound       = errors.New("ошибка: сезон не найден")
+	ErrSeasonOverlap        = errors.New("ошибка: сезон пересекается с другим сезоном")
+	ErrSeasonClosed         = errors.New("ошибка: итоги сезона уже подведены")
+	ErrSeasonNotEnded       = errors.New("ошибка: сезон еще не завершился")
 )
 
 type Service struct {
-	repo interfaces.RepositoryProvider
+	repo   interfaces.RepositoryProvider
+	cfg    *config.Config
+	mailer interfaces.Mailer
+	hasher auth.PasswordHasher
+	// leaderboard кэш таблиц лидеров, nil - таблицы читаются из базы данных
+	leaderboard *leaderboard.Cache
+	// dummyPasswordHash хэш для проверки пароля при неизвестном логине
+	dummyPasswordHash string
+	passwordBlocklist map[string]struct{}
 }
 
-func NewService(repo interfaces.RepositoryProvider) *Service {
+func NewService(repo interfaces.RepositoryProvider, cfg *config.Config, mailer interfaces.Mailer, hasher auth.PasswordHasher, board *leaderboard.Cache) *Service {
+	dummyHash, err := hasher.Hash("dummy-password")
+	if err != nil {
+		log.Printf("services.NewService: ошибка при создании фиктивного хэша: %v", err)
+	}
+
 	return &Service{
-		repo: repo,
+		repo:              repo,
+		cfg:               cfg,
+		mailer:            mailer,
+		hasher:            hasher,
+		leaderboard:       board,
+		dummyPasswordHash: dummyHash,
+		passwordBlocklist: newBlocklist(cfg.PasswordPolicy.Blocklist),
 	}
 }
 
-func (s *Service) GetAllActiveTask(ctx context.Context) ([]*models.Task, error) {
+// GetAllActiveTask возвращает активные задачи с отметкой о выполнении пользователем userID.
+func (s *Service) GetAllActiveTask(ctx context.Context, userID uint) ([]*models.Task, error) {
 	const op = "services.GetAllActiveTask"
 
-	tasks, err := s.repo.GetAllActiveTask(ctx)
+	tasks, err := s.repo.GetAllActiveTask(ctx, userID)
 	if err != nil {
 		return nil, errors.Wrap(err, op)
 	}
 	return tasks, nil
 }
 
-func (s *Service) GetListTopUsers(ctx context.Context) ([]*models.User, error) {
+// GetListTopUsers возвращает лидеров по сумме бонусов за задачи, выполненные за период period:
+// day, week, month (с начала текущего дня, недели или месяца в часовом поясе LEADERBOARD_TIMEZONE) или all.
+// Пока кэш таблиц лидеров не готов, лидеры читаются из базы данных.
+func (s *Service) GetListTopUsers(ctx context.Context, period string) ([]*models.LeaderboardEntry, error) {
 	const op = "services.GetListTopUsers"
 
-	users, err := s.repo.GetListTopUsers(ctx)
+	now := time.Now()
+	since, err := s.periodStart(period, now)
 	if err != nil {
-		return nil, errors.Wrap(err, op)
+		return nil, err
+	}
+
+	var entries []*models.LeaderboardEntry
+	cached := false
+	if s.leaderboard != nil {
+		entries, cached = s.leaderboard.Top(period, now, s.cfg.Leaderboard.Limit)
+	}
+	if !cached {
+		entries, err = s.repo.GetListTopUsers(ctx, since, s.cfg.Leaderboard.Limit, s.cfg.RequireVerified)
+		if err != nil {
+			return nil, errors.Wrap(err, op)
+		}
 	}
 
-	return users, nil
+	for _, entry := range entries {
+		anonymize(&entry.UserID, &entry.LeaderboardProfile)
+	}
+
+	return entries, nil
 }
 
+// GetLeaderboardPosition возвращает место пользователя в таблице лидеров за период period
+// и до neighbors соседей выше и ниже него.
+func (s *Service) GetLeaderboardPosition(ctx context.Context, userID uint, period string, neighbors uint) (*models.LeaderboardPosition, error) {
+	const op = "services.GetLeaderboardPosition"
+
+	now := time.Now()
+	since, err := s.periodStart(period, now)
+	if err != nil {
+		return nil, err
+	}
+
+	var entries []*models.LeaderboardEntry
+	cached := false
+	if s.leaderboard != nil {
+		entries, cached = s.leaderboard.Neighbors(userID, period, now, neighbors)
+	}
+	if !cached {
+		entries, err = s.repo.GetLeaderboardNeighbors(ctx, userID, period, since, neighbors, s.cfg.RequireVerified)
+		if err != nil {
+			return nil, errors.Wrap(err, op)
+		}
+	}
+
+	position := &models.LeaderboardPosition{
+		Above: []*models.LeaderboardEntry{},
+		Below: []*models.LeaderboardEntry{},
+	}
+	for i, entry := range entries {
+		if entry.UserID == userID {
+			position.User = entry
+			position.Above = entries[:i]
+			position.Below = entries[i+1:]
+			break
+		}
+	}
+
+	// Пользователь видит свое место полностью, анонимность соседей сохраняется
+	for _, entry := range entries {
+		if entry != position.User {
+			anonymize(&entry.UserID, &entry.LeaderboardProfile)
+		}
+	}
+
+	return position, nil
+}
+
+// periodStart возвращает начало периода таблицы лидеров в UTC, nil - без ограничения (all).
+func (s *Service) periodStart(period string, now time.Time) (*time.Time, error) {
+	start, ok := models.PeriodStart(period, now, s.cfg.Leaderboard.Location)
+	if !ok {
+		return nil, ErrInvalidPeriod
+	}
+	return start, nil
+}
+
+// TaskComplete выполняет задачу пользователем. При включенном REQUIRE_VERIFIED пользователь
+// должен подтвердить email.
 func (s *Service) TaskComplete(ctx context.Context, taskID uint, userID uint) (*models.Task, error) {
 	const op = "services.TaskComplete"
 
+	if s.cfg.RequireVerified {
+		user, err := s.repo.GetUserByID(ctx, userID)
+		if err != nil {
+			if errors.Is(err, repo.ErrUserNotFound) {
+				return nil, ErrUserNotFound
+			}
+			return nil, errors.Wrap(err, op)
+		}
+		if !user.Verified {
+			return nil, ErrEmailNotVerified
+		}
+	}
+
 	task, err := s.repo.TaskComplete(ctx, taskID, userID)
 	if err != nil {
 		switch {
@@ -64,6 +224,10 @@ func (s *Service) TaskComplete(ctx context.Context, taskID uint, userID uint) (*
 			return nil, ErrUserNotFound
 		case errors.Is(err, repo.ErrTaskAlreadyCompleted):
 			return nil, ErrTaskAlreadyCompleted
+		case errors.Is(err, repo.ErrTaskCompletionLimit):
+			return nil, ErrTaskCompletionLimit
+		case errors.Is(err, repo.ErrTaskArchived):
+			return nil, ErrTaskArchived
 		default:
 			return nil, errors.Wrap(err, op)
 		}
@@ -86,52 +250,93 @@ func (s *Service) StatusUser(ctx context.Context, userID uint) (*models.User, er
 	return getUser, nil
 }
 
-func (s *Service) Login(ctx context.Context, login, password string) error {
+// Login проверяет логин и пароль, возвращает аутентифицированного пользователя.
+// Неизвестный логин и неверный пароль неразличимы для клиента (ErrInvalidCredentials), неудачные
+// попытки учитываются по логину и IP адресу ip, после превышения лимита вход временно блокируется.
+func (s *Service) Login(ctx context.Context, login, password, ip string) (*models.User, error) {
 	const op = "services.Login"
 
+	if err := s.checkLoginLock(ctx, login, ip); err != nil {
+		return nil, err
+	}
+
 	// Получение пользователя по логину
 	getUser, err := s.repo.GetUserByLogin(ctx, login)
 	if err != nil {
 		if errors.Is(err, repo.ErrUserNotFound) {
-			return ErrUserNotFound
+			// Проверка пароля с фиктивным хэшем, чтобы время ответа не выдавало существование логина
+			_ = s.checkPassword(s.dummyPasswordHash, password)
+			return nil, s.loginFailed(ctx, login, ip)
 		}
-		return errors.Wrap(err, op)
+		return nil, errors.Wrap(err, op)
 	}
 
 	// Проверка пароля
-	err = checkPassword(getUser.PasswordHash, password)
+	err = s.checkPassword(getUser.PasswordHash, password)
 	if err != nil {
 		if errors.Is(err, ErrIncorrectPassword) {
-			return ErrIncorrectPassword
+			return nil, s.loginFailed(ctx, login, ip)
 		}
-		return errors.Wrap(err, op)
+		return nil, errors.Wrap(err, op)
 	}
 
-	return nil
+	// При включенной 2FA счетчик сбрасывает только принятый код второго фактора, иначе повторный
+	// вход паролем обнулял бы неудачные попытки ввода кода
+	if !getUser.TOTPEnabled {
+		if err = s.repo.ResetLoginFailures(ctx, models.LoginFailureByLogin, login); err != nil {
+			return nil, errors.Wrap(err, op)
+		}
+	}
+
+	if getUser.BannedAt != nil {
+		return nil, ErrUserBanned
+	}
+
+	s.rehashPassword(ctx, getUser, password)
+
+	return getUser, nil
 }
 
-func (s *Service) RegisterUser(ctx context.Context, login, password string, referID uint) (*models.User, error) {
+// RegisterUser регистрирует пользователя. Пригласивший задается кодом приглашения referralCode
+// или, для обратной совместимости, числовым referID. email необязателен. Ошибки формата логина,
+// пароля и email возвращаются вместе в *ValidationError.
+func (s *Service) RegisterUser(ctx context.Context, login, password, email string, referID uint, referralCode string) (*models.User, error) {
 	const op = "services.RegisterUser"
 
 	if login == "" || password == "" {
 		return nil, ErrCredentialsRequired
 	}
 
+	errs := &ValidationError{}
+	s.validateLogin(errs, login)
+	s.validatePassword(errs, "password", password, login)
+	email, err := normalizeEmail(email)
+	if err != nil {
+		errs.add("email", ErrInvalidEmail.Error())
+	}
+	if err = errs.errOrNil(); err != nil {
+		return nil, err
+	}
+
 	// Хэшируем пароль
-	hashedPassword, err := hashPassword(password)
+	hashedPassword, err := s.hashPassword(password)
 	if err != nil {
 		log.Printf("%s: ошибка при хэшировании пароля: %v", op, err)
 		return nil, errors.Wrap(err, op)
 	}
 
 	// Новый инстанс пользователя
-	user := models.NewUser(login, hashedPassword, referID)
+	user := models.NewUser(login, hashedPassword, email, referID)
 
 	// Регистрируем пользователя в репозитории
-	if err = s.repo.RegisterUser(ctx, user); err != nil {
+	if err = s.repo.RegisterUser(ctx, user, normalizeReferralCode(referralCode)); err != nil {
 		switch {
+		case errors.Is(err, repo.ErrReferralCodeInvalid):
+			return nil, ErrReferralCodeInvalid
 		case errors.Is(err, repo.ErrUserAlreadyExist): // если уже существует
 			return nil, ErrUserAlreadyExist
+		case errors.Is(err, repo.ErrEmailAlreadyUsed):
+			return nil, ErrEmailAlreadyUsed
 		case errors.Is(err, repo.ErrReferUserNotFound): // если refer_id не найден
 			return nil, ErrReferUserNotFound
 		default: // другая ошибка
@@ -140,29 +345,43 @@ func (s *Service) RegisterUser(ctx context.Context, login, password string, refe
 		}
 	}
 
+	// Письмо с подтверждением не должно мешать регистрации, его можно запросить повторно
+	if user.Email != "" {
+		if err = s.sendEmailVerification(ctx, user); err != nil {
+			log.Printf("%s: ошибка при отправке подтверждения email: %v", op, err)
+		}
+	}
+
 	return user, nil
 }
 
-func (s *Service) AddTask(ctx context.Context, description string, bonus uint) error {
+// AddTask создает задачу, maxCompletions ограничивает кол-во выполнений (0 - без ограничений).
+func (s *Service) AddTask(ctx context.Context, description string, bonus uint, maxCompletions uint) (*models.Task, error) {
 	const op = "services.AddTask"
 
-	// Новый инстанс пользователя
+	if !validTask(description, bonus) {
+		return nil, ErrInvalidTaskData
+	}
+
+	// Новый инстанс задачи
 	task := models.NewTask(description, bonus)
+	task.MaxCompletions = maxCompletions
 
 	err := s.repo.AddTask(ctx, task)
 	if err != nil {
-		return errors.Wrap(err, op)
+		return nil, errors.Wrap(err, op)
 	}
 
-	return nil
+	return task, nil
 }
 
-func checkPassword(hashedPassword, password string) error {
+// checkPassword проверяет пароль по хэшу любого поддерживаемого алгоритма.
+func (s *Service) checkPassword(hashedPassword, password string) error {
 	const op = "services.checkPassword"
 
-	err := bcrypt.CompareHashAndPassword([]byte(hashedPassword), []byte(password))
+	err := s.hasher.Verify(hashedPassword, password)
 	if err != nil {
-		if errors.Is(err, bcrypt.ErrMismatchedHashAndPassword) {
+		if errors.Is(err, auth.ErrPasswordMismatch) {
 			return ErrIncorrectPassword
 		}
 		return fmt.Errorf("%s: %w", op, err)
@@ -170,15 +389,36 @@ func checkPassword(hashedPassword, password string) error {
 	return nil
 }
 
-// HashPassword хэширует пароль
-func hashPassword(password string) (string, error) {
+// HashPassword хэширует пароль текущим алгоритмом
+func (s *Service) hashPassword(password string) (string, error) {
 	const op = "services.HashPassword"
 
-	// Генерация хэша с использованием bcrypt
-	hashedPassword, err := bcrypt.GenerateFromPassword([]byte(password), bcrypt.DefaultCost)
+	hashedPassword, err := s.hasher.Hash(password)
 	if err != nil {
 		return "", fmt.Errorf("%s: %w", op, err)
 	}
 
-	return string(hashedPassword), nil
+	return hashedPassword, nil
+}
+
+// rehashPassword перехэширует пароль, если сохраненный хэш получен устаревшим алгоритмом или
+// с устаревшими параметрами. Ошибка только записывается в журнал: вход от нее не зависит.
+func (s *Service) rehashPassword(ctx context.Context, user *models.User, password string) {
+	const op = "services.rehashPassword"
+
+	if !s.hasher.NeedsRehash(user.PasswordHash) {
+		return
+	}
+
+	newHash, err := s.hashPassword(password)
+	if err != nil {
+		log.Printf("%s: %v", op, err)
+		return
+	}
+
+	if err = s.repo.RehashPassword(ctx, user.ID, user.PasswordHash, newHash); err != nil {
+		log.Printf("%s: %v", op, err)
+		return
+	}
+	user.PasswordHash = newHash
 }
@@ -0,0 +1,112 @@
+package services
+
+import (
+	"context"
+	"github.com/RVodassa/TaskReward/internal/domain/models"
+	repo "github.com/RVodassa/TaskReward/internal/infrastructure/postgres/repository"
+	"github.com/pkg/errors"
+	"unicode/utf8"
+)
+
+// taskStatuses сопоставляет фильтр статуса из запроса со статусом задачи в хранилище.
+var taskStatuses = map[string]string{
+	"open":     repo.StatusTaskOpen,
+	"closed":   repo.StatusTaskClose,
+	"archived": repo.StatusTaskArchived,
+}
+
+// maxTaskDescriptionLen ограничение длины описания задачи в хранилище.
+const maxTaskDescriptionLen = 255
+
+// validTask проверяет описание и бонус задачи.
+func validTask(description string, bonus uint) bool {
+	return description != "" && utf8.RuneCountInString(description) <= maxTaskDescriptionLen && bonus > 0
+}
+
+// GetAllTasks возвращает задачи в любом статусе. Фильтр status: "", "open", "closed", "archived".
+func (s *Service) GetAllTasks(ctx context.Context, status string, limit, offset uint) ([]*models.Task, error) {
+	const op = "services.GetAllTasks"
+
+	var dbStatus string
+	if status != "" {
+		var ok bool
+		dbStatus, ok = taskStatuses[status]
+		if !ok {
+			return nil, ErrInvalidTaskStatus
+		}
+	}
+
+	tasks, err := s.repo.GetAllTasks(ctx, dbStatus, limit, offset)
+	if err != nil {
+		return nil, errors.Wrap(err, op)
+	}
+
+	return tasks, nil
+}
+
+// UpdateTask частично обновляет задачу, nil поля остаются без изменений.
+func (s *Service) UpdateTask(ctx context.Context, taskID uint, description *string, bonus *uint, maxCompletions *uint) (*models.Task, error) {
+	const op = "services.UpdateTask"
+
+	task, err := s.repo.GetTaskByID(ctx, taskID)
+	if err != nil {
+		if errors.Is(err, repo.ErrTaskNotFound) {
+			return nil, ErrTaskNotFound
+		}
+		return nil, errors.Wrap(err, op)
+	}
+
+	if description != nil {
+		task.Description = *description
+	}
+	if bonus != nil {
+		task.Bonus = *bonus
+	}
+	if maxCompletions != nil {
+		task.MaxCompletions = *maxCompletions
+	}
+	if !validTask(task.Description, task.Bonus) {
+		return nil, ErrInvalidTaskData
+	}
+
+	if err = s.repo.UpdateTask(ctx, task); err != nil {
+		if errors.Is(err, repo.ErrTaskNotFound) {
+			return nil, ErrTaskNotFound
+		}
+		return nil, errors.Wrap(err, op)
+	}
+
+	return task, nil
+}
+
+// ArchiveTask переводит задачу в архив.
+func (s *Service) ArchiveTask(ctx context.Context, taskID uint) error {
+	const op = "services.ArchiveTask"
+
+	if err := s.repo.ArchiveTask(ctx, taskID); err != nil {
+		if errors.Is(err, repo.ErrTaskNotFound) {
+			return ErrTaskNotFound
+		}
+		return errors.Wrap(err, op)
+	}
+
+	return nil
+}
+
+// DeleteTask удаляет задачу, если ее еще никто не выполнял.
+func (s *Service) DeleteTask(ctx context.Context, taskID uint) error {
+	const op = "services.DeleteTask"
+
+	if err := s.repo.DeleteTask(ctx, taskID); err != nil {
+		switch {
+		case errors.Is(err, repo.ErrTaskNotFound):
+			return ErrTaskNotFound
+		case errors.Is(err, repo.ErrTaskHasCompletions):
+			return ErrTaskHasCompletions
+		default:
+			return errors.Wrap(err, op)
+		}
+	}
+
+	return nil
+}
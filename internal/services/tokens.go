@@ -0,0 +1,177 @@
+package services
+
+import (
+	"context"
+	"github.com/RVodassa/TaskReward/internal/domain/models"
+	repo "github.com/RVodassa/TaskReward/internal/infrastructure/postgres/repository"
+	"github.com/RVodassa/TaskReward/internal/services/auth"
+	"github.com/pkg/errors"
+	"time"
+)
+
+// IssueRefreshToken создает refresh токен новой цепочки для пользователя после входа.
+func (s *Service) IssueRefreshToken(ctx context.Context, userID uint) (string, error) {
+	const op = "services.IssueRefreshToken"
+
+	family, err := auth.NewTokenFamily()
+	if err != nil {
+		return "", errors.Wrap(err, op)
+	}
+
+	value, token, err := s.newRefreshToken()
+	if err != nil {
+		return "", errors.Wrap(err, op)
+	}
+	token.UserID = userID
+	token.FamilyID = family
+
+	if err = s.repo.AddRefreshToken(ctx, token); err != nil {
+		return "", errors.Wrap(err, op)
+	}
+
+	return value, nil
+}
+
+// RefreshTokens обменивает refresh токен на новый и возвращает пользователя для выпуска access токена.
+// Старый токен после обмена недействителен, его повторное использование отзывает всю цепочку.
+func (s *Service) RefreshTokens(ctx context.Context, refreshToken string) (*models.User, string, error) {
+	const op = "services.RefreshTokens"
+
+	if refreshToken == "" {
+		return nil, "", ErrInvalidRefreshToken
+	}
+
+	value, token, err := s.newRefreshToken()
+	if err != nil {
+		return nil, "", errors.Wrap(err, op)
+	}
+
+	if err = s.repo.RotateRefreshToken(ctx, auth.HashRefreshToken(refreshToken), token); err != nil {
+		switch {
+		case errors.Is(err, repo.ErrRefreshTokenReused):
+			return nil, "", ErrRefreshTokenReused
+		case errors.Is(err, repo.ErrRefreshTokenNotFound),
+			errors.Is(err, repo.ErrRefreshTokenExpired),
+			errors.Is(err, repo.ErrRefreshTokenRevoked):
+			return nil, "", ErrInvalidRefreshToken
+		default:
+			return nil, "", errors.Wrap(err, op)
+		}
+	}
+
+	user, err := s.repo.GetUserByID(ctx, token.UserID)
+	if err != nil {
+		if errors.Is(err, repo.ErrUserNotFound) {
+			return nil, "", ErrInvalidRefreshToken
+		}
+		return nil, "", errors.Wrap(err, op)
+	}
+	if user.BannedAt != nil {
+		return nil, "", ErrUserBanned
+	}
+
+	return user, value, nil
+}
+
+// newRefreshToken создает значение refresh токена и запись для хранения без пользователя и цепочки.
+func (s *Service) newRefreshToken() (string, *models.RefreshToken, error) {
+	value, hash, err := auth.NewRefreshToken()
+	if err != nil {
+		return "", nil, err
+	}
+
+	now := time.Now().UTC()
+	return value, &models.RefreshToken{
+		TokenHash: hash,
+		ExpiresAt: now.Add(s.cfg.RefreshTokenTTL),
+		CreatedAt: now,
+	}, nil
+}
+
+// Logout отзывает текущий access токен jti до его истечения и, если передан refreshToken,
+// цепочку refresh токенов этого входа.
+func (s *Service) Logout(ctx context.Context, userID uint, jti string, expiresAt time.Time, refreshToken string) error {
+	const op = "services.Logout"
+
+	if err := s.repo.RevokeAccessToken(ctx, jti, userID, expiresAt.UTC()); err != nil {
+		return errors.Wrap(err, op)
+	}
+
+	if refreshToken != "" {
+		if err := s.repo.RevokeRefreshTokenFamily(ctx, userID, auth.HashRefreshToken(refreshToken)); err != nil {
+			return errors.Wrap(err, op)
+		}
+	}
+
+	return nil
+}
+
+// LogoutAll делает недействительными все access и refresh токены пользователя.
+func (s *Service) LogoutAll(ctx context.Context, userID uint) error {
+	const op = "services.LogoutAll"
+
+	if err := s.repo.RevokeAllTokens(ctx, userID); err != nil {
+		if errors.Is(err, repo.ErrUserNotFound) {
+			return ErrUserNotFound
+		}
+		return errors.Wrap(err, op)
+	}
+
+	return nil
+}
+
+// IsAccessTokenRevoked проверяет, отозван ли access токен jti через Logout.
+func (s *Service) IsAccessTokenRevoked(ctx context.Context, jti string) (bool, error) {
+	const op = "services.IsAccessTokenRevoked"
+
+	revoked, err := s.repo.IsAccessTokenRevoked(ctx, jti)
+	if err != nil {
+		return false, errors.Wrap(err, op)
+	}
+
+	return revoked, nil
+}
+
+// ChangePassword меняет пароль пользователя после проверки текущего. Все выданные токены отзываются.
+func (s *Service) ChangePassword(ctx context.Context, userID uint, oldPassword, newPassword string) error {
+	const op = "services.ChangePassword"
+
+	if oldPassword == "" || newPassword == "" {
+		return ErrCredentialsRequired
+	}
+
+	user, err := s.repo.GetUserByID(ctx, userID)
+	if err != nil {
+		if errors.Is(err, repo.ErrUserNotFound) {
+			return ErrUserNotFound
+		}
+		return errors.Wrap(err, op)
+	}
+
+	if err = s.checkPassword(user.PasswordHash, oldPassword); err != nil {
+		if errors.Is(err, ErrIncorrectPassword) {
+			return ErrIncorrectPassword
+		}
+		return errors.Wrap(err, op)
+	}
+
+	errs := &ValidationError{}
+	s.validatePassword(errs, "new_password", newPassword, user.Login)
+	if err = errs.errOrNil(); err != nil {
+		return err
+	}
+
+	hashedPassword, err := s.hashPassword(newPassword)
+	if err != nil {
+		return errors.Wrap(err, op)
+	}
+
+	if err = s.repo.UpdatePassword(ctx, userID, hashedPassword); err != nil {
+		if errors.Is(err, repo.ErrUserNotFound) {
+			return ErrUserNotFound
+		}
+		return errors.Wrap(err, op)
+	}
+
+	return nil
+}
@@ -0,0 +1,243 @@
+package services
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"github.com/RVodassa/TaskReward/internal/domain/models"
+	repo "github.com/RVodassa/TaskReward/internal/infrastructure/postgres/repository"
+	"github.com/RVodassa/TaskReward/internal/services/auth"
+	"github.com/pkg/errors"
+	"strings"
+	"time"
+)
+
+const (
+	backupCodeCount  = 10
+	backupCodeLength = 10 // выдается как XXXXX-XXXXX
+	// maxChallengeAttempts попыток ввода кода по одному токену второго шага, затем нужен повторный вход
+	maxChallengeAttempts = 5
+)
+
+// EnrollTOTP создает секрет TOTP, ожидающий подтверждения кодом из приложения-аутентификатора.
+// Повторный вызов до подтверждения заменяет секрет.
+func (s *Service) EnrollTOTP(ctx context.Context, userID uint) (*models.TOTPEnrollment, error) {
+	const op = "services.EnrollTOTP"
+
+	user, err := s.repo.GetUserByID(ctx, userID)
+	if err != nil {
+		if errors.Is(err, repo.ErrUserNotFound) {
+			return nil, ErrUserNotFound
+		}
+		return nil, errors.Wrap(err, op)
+	}
+
+	secret, err := auth.NewTOTPSecret()
+	if err != nil {
+		return nil, errors.Wrap(err, op)
+	}
+
+	if err = s.repo.SetTOTPSecret(ctx, userID, secret); err != nil {
+		if errors.Is(err, repo.ErrTOTPAlreadyEnabled) {
+			return nil, ErrTOTPAlreadyEnabled
+		}
+		return nil, errors.Wrap(err, op)
+	}
+
+	return &models.TOTPEnrollment{
+		Secret:          secret,
+		ProvisioningURI: auth.TOTPProvisioningURI(s.cfg.TOTPIssuer, user.Login, secret),
+	}, nil
+}
+
+// ConfirmTOTP включает 2FA после проверки кода из приложения и возвращает резервные коды.
+// Резервные коды показываются один раз, хранятся только их хэши.
+func (s *Service) ConfirmTOTP(ctx context.Context, userID uint, code string) ([]string, error) {
+	const op = "services.ConfirmTOTP"
+
+	secret, enabled, err := s.repo.GetTOTPSecret(ctx, userID)
+	if err != nil {
+		if errors.Is(err, repo.ErrUserNotFound) {
+			return nil, ErrUserNotFound
+		}
+		return nil, errors.Wrap(err, op)
+	}
+	switch {
+	case enabled:
+		return nil, ErrTOTPAlreadyEnabled
+	case secret == "":
+		return nil, ErrTOTPNotEnrolled
+	}
+
+	step, ok := auth.ValidateTOTP(secret, normalizeOTP(code), time.Now())
+	if !ok {
+		return nil, ErrInvalidTOTPCode
+	}
+
+	codes := make([]string, 0, backupCodeCount)
+	hashes := make([]string, 0, backupCodeCount)
+	for i := 0; i < backupCodeCount; i++ {
+		value, err := randomCode(backupCodeLength)
+		if err != nil {
+			return nil, errors.Wrap(err, op)
+		}
+		codes = append(codes, value[:backupCodeLength/2]+"-"+value[backupCodeLength/2:])
+		hashes = append(hashes, hashBackupCode(value))
+	}
+
+	if err = s.repo.EnableTOTP(ctx, userID, step, hashes); err != nil {
+		if errors.Is(err, repo.ErrTOTPAlreadyEnabled) {
+			return nil, ErrTOTPAlreadyEnabled
+		}
+		return nil, errors.Wrap(err, op)
+	}
+
+	return codes, nil
+}
+
+// DisableTOTP выключает 2FA. Требуется текущий пароль и код из приложения либо резервный код.
+func (s *Service) DisableTOTP(ctx context.Context, userID uint, password, code string) error {
+	const op = "services.DisableTOTP"
+
+	user, err := s.repo.GetUserByID(ctx, userID)
+	if err != nil {
+		if errors.Is(err, repo.ErrUserNotFound) {
+			return ErrUserNotFound
+		}
+		return errors.Wrap(err, op)
+	}
+
+	if err = s.checkPassword(user.PasswordHash, password); err != nil {
+		if errors.Is(err, ErrIncorrectPassword) {
+			return ErrIncorrectPassword
+		}
+		return errors.Wrap(err, op)
+	}
+
+	if !user.TOTPEnabled {
+		return ErrTOTPNotEnabled
+	}
+
+	ok, err := s.checkSecondFactor(ctx, userID, code)
+	if err != nil {
+		return errors.Wrap(err, op)
+	}
+	if !ok {
+		return ErrInvalidTOTPCode
+	}
+
+	if err = s.repo.DisableTOTP(ctx, userID); err != nil {
+		if errors.Is(err, repo.ErrUserNotFound) {
+			return ErrUserNotFound
+		}
+		return errors.Wrap(err, op)
+	}
+
+	return nil
+}
+
+// VerifyTwoFactor завершает вход пользователя с включенной 2FA: проверяет код из приложения
+// или резервный код. Неверный код учитывается как неудачная попытка входа, по токену второго шага
+// challengeID принимается не больше maxChallengeAttempts попыток. Счетчик неудач по логину
+// сбрасывается только здесь, после принятого кода.
+func (s *Service) VerifyTwoFactor(ctx context.Context, userID uint, challengeID, code, ip string) (*models.User, error) {
+	const op = "services.VerifyTwoFactor"
+
+	user, err := s.repo.GetUserByID(ctx, userID)
+	if err != nil {
+		if errors.Is(err, repo.ErrUserNotFound) {
+			return nil, ErrInvalidCredentials
+		}
+		return nil, errors.Wrap(err, op)
+	}
+
+	if err = s.checkLoginLock(ctx, user.Login, ip); err != nil {
+		return nil, err
+	}
+
+	if !user.TOTPEnabled {
+		return nil, ErrTOTPNotEnabled
+	}
+
+	// Считается каждая попытка, а не только неудачная: счетчик токена не сбрасывается по времени,
+	// токен и так живет несколько минут
+	attempts, err := s.repo.RecordLoginFailure(ctx, models.LoginFailureByChallenge, challengeID, time.Time{})
+	if err != nil {
+		return nil, errors.Wrap(err, op)
+	}
+	if attempts > maxChallengeAttempts {
+		return nil, ErrChallengeExhausted
+	}
+
+	ok, err := s.checkSecondFactor(ctx, userID, code)
+	if err != nil {
+		return nil, errors.Wrap(err, op)
+	}
+	if !ok {
+		if err = s.loginFailed(ctx, user.Login, ip); !errors.Is(err, ErrInvalidCredentials) {
+			return nil, err
+		}
+		return nil, ErrInvalidTOTPCode
+	}
+
+	if err = s.repo.ResetLoginFailures(ctx, models.LoginFailureByLogin, user.Login); err != nil {
+		return nil, errors.Wrap(err, op)
+	}
+	if err = s.repo.ResetLoginFailures(ctx, models.LoginFailureByChallenge, challengeID); err != nil {
+		return nil, errors.Wrap(err, op)
+	}
+
+	if user.BannedAt != nil {
+		return nil, ErrUserBanned
+	}
+
+	return user, nil
+}
+
+// checkSecondFactor проверяет 6-значный код TOTP (каждый код принимается один раз) или погашает резервный код.
+func (s *Service) checkSecondFactor(ctx context.Context, userID uint, code string) (bool, error) {
+	const op = "services.checkSecondFactor"
+
+	code = normalizeOTP(code)
+	if code == "" {
+		return false, nil
+	}
+
+	if len(code) != backupCodeLength {
+		secret, enabled, err := s.repo.GetTOTPSecret(ctx, userID)
+		if err != nil {
+			return false, errors.Wrap(err, op)
+		}
+		if !enabled {
+			return false, nil
+		}
+
+		step, ok := auth.ValidateTOTP(secret, code, time.Now())
+		if !ok {
+			return false, nil
+		}
+
+		used, err := s.repo.UseTOTPStep(ctx, userID, step)
+		if err != nil {
+			return false, errors.Wrap(err, op)
+		}
+		return used, nil
+	}
+
+	used, err := s.repo.UseBackupCode(ctx, userID, hashBackupCode(code))
+	if err != nil {
+		return false, errors.Wrap(err, op)
+	}
+	return used, nil
+}
+
+// normalizeOTP убирает из кода пробелы и дефисы и приводит его к верхнему регистру.
+func normalizeOTP(code string) string {
+	return strings.ToUpper(strings.NewReplacer(" ", "", "-", "").Replace(code))
+}
+
+// hashBackupCode возвращает sha256 хэш нормализованного резервного кода в hex.
+func hashBackupCode(code string) string {
+	sum := sha256.Sum256([]byte(code))
+	return hex.EncodeToString(sum[:])
+}
@@ -19,6 +19,10 @@ import (
 // @in header
 // @name Authorization
 // @description Укажите свой токен 'Bearer JWT_TOKEN'.
+// @securityDefinitions.apikey ApiKeyAuth
+// @in header
+// @name X-API-Key
+// @description API ключ сервиса 'trk_<префикс>_<секрет>', создается администратором.
 // @host localhost:8080
 
 func main() {
@@ -0,0 +1,16 @@
+ALTER TABLE tasks ADD COLUMN user_id INTEGER REFERENCES users(id);
+ALTER TABLE tasks ADD COLUMN completed_at TIMESTAMP;
+
+-- Возвращаем первое выполнение задачи в таблицу tasks
+UPDATE tasks t
+SET user_id = c.user_id, completed_at = c.completed_at, status = 'завершено'
+FROM (
+    SELECT DISTINCT ON (task_id) task_id, user_id, completed_at
+    FROM task_completions
+    ORDER BY task_id, completed_at
+) c
+WHERE t.id = c.task_id;
+
+ALTER TABLE tasks DROP COLUMN max_completions;
+
+DROP TABLE IF EXISTS task_completions;
@@ -0,0 +1,24 @@
+CREATE TABLE task_completions (
+                       task_id INTEGER NOT NULL REFERENCES tasks(id),
+                       user_id INTEGER NOT NULL REFERENCES users(id),
+                       completed_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+                       bonus_awarded INTEGER NOT NULL,
+                       PRIMARY KEY (task_id, user_id)
+);
+
+CREATE INDEX idx_task_completions_user_id ON task_completions(user_id);
+
+-- 0 означает отсутствие ограничения на кол-во выполнений
+ALTER TABLE tasks ADD COLUMN max_completions INTEGER NOT NULL DEFAULT 0;
+
+-- Переносим ранее выполненные задачи в историю выполнений
+INSERT INTO task_completions (task_id, user_id, completed_at, bonus_awarded)
+SELECT id, user_id, COALESCE(completed_at, CURRENT_TIMESTAMP), bonus
+FROM tasks
+WHERE user_id IS NOT NULL;
+
+-- Задачи снова доступны остальным пользователям
+UPDATE tasks SET status = 'не завершено' WHERE status = 'завершено';
+
+ALTER TABLE tasks DROP COLUMN user_id;
+ALTER TABLE tasks DROP COLUMN completed_at;
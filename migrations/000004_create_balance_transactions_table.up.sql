@@ -0,0 +1,24 @@
+CREATE TABLE balance_transactions (
+                       id BIGSERIAL PRIMARY KEY,
+                       user_id INTEGER NOT NULL REFERENCES users(id),
+                       amount INTEGER NOT NULL,
+                       balance_after INTEGER NOT NULL,
+                       type VARCHAR(32) NOT NULL,
+                       reference_id INTEGER,
+                       admin_id INTEGER REFERENCES users(id),
+                       comment VARCHAR(255) NOT NULL DEFAULT '',
+                       created_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE INDEX idx_balance_transactions_user_id ON balance_transactions(user_id, id DESC);
+
+-- Восстанавливаем историю начислений по уже выполненным задачам
+INSERT INTO balance_transactions (user_id, amount, balance_after, type, reference_id, created_at)
+SELECT user_id,
+       bonus_awarded,
+       SUM(bonus_awarded) OVER (PARTITION BY user_id ORDER BY completed_at, task_id),
+       'task_bonus',
+       task_id,
+       completed_at
+FROM task_completions
+ORDER BY completed_at, task_id;
@@ -0,0 +1,22 @@
+CREATE TABLE rewards (
+                       id SERIAL PRIMARY KEY,
+                       name VARCHAR(255) NOT NULL,
+                       description VARCHAR(1000) NOT NULL DEFAULT '',
+                       cost INTEGER NOT NULL CHECK (cost > 0),
+                       stock INTEGER NOT NULL DEFAULT 0 CHECK (stock >= 0),
+                       active BOOLEAN NOT NULL DEFAULT TRUE,
+                       created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE TABLE reward_orders (
+                       id SERIAL PRIMARY KEY,
+                       user_id INTEGER NOT NULL REFERENCES users(id),
+                       reward_id INTEGER NOT NULL REFERENCES rewards(id),
+                       cost INTEGER NOT NULL,
+                       status VARCHAR(20) NOT NULL DEFAULT 'pending' CHECK (status IN ('pending', 'fulfilled', 'cancelled')),
+                       created_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+                       updated_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE INDEX idx_reward_orders_user_id ON reward_orders(user_id, id DESC);
+CREATE INDEX idx_reward_orders_status ON reward_orders(status, id DESC);
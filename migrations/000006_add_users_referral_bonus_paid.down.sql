@@ -0,0 +1,2 @@
+DROP INDEX IF EXISTS idx_users_refer_id;
+ALTER TABLE users DROP COLUMN IF EXISTS referral_bonus_paid;
@@ -0,0 +1,7 @@
+ALTER TABLE users ADD COLUMN referral_bonus_paid BOOLEAN NOT NULL DEFAULT FALSE;
+
+-- Пользователи, уже выполнявшие задачи, не приносят пригласившему бонус за первое выполнение
+UPDATE users SET referral_bonus_paid = TRUE
+WHERE id IN (SELECT DISTINCT user_id FROM task_completions);
+
+CREATE INDEX idx_users_refer_id ON users(refer_id);
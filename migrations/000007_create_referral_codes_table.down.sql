@@ -0,0 +1,2 @@
+ALTER TABLE users DROP COLUMN IF EXISTS referral_code_id;
+DROP TABLE IF EXISTS referral_codes;
@@ -0,0 +1,14 @@
+CREATE TABLE referral_codes (
+                       id SERIAL PRIMARY KEY,
+                       user_id INTEGER NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+                       code VARCHAR(32) NOT NULL UNIQUE,
+                       max_uses INTEGER NOT NULL DEFAULT 0 CHECK (max_uses >= 0), -- 0 - без ограничений
+                       uses INTEGER NOT NULL DEFAULT 0 CHECK (uses >= 0),
+                       expires_at TIMESTAMP,
+                       active BOOLEAN NOT NULL DEFAULT TRUE,
+                       created_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE INDEX idx_referral_codes_user_id ON referral_codes(user_id, id DESC);
+
+ALTER TABLE users ADD COLUMN referral_code_id INTEGER REFERENCES referral_codes(id) ON DELETE SET NULL;
@@ -0,0 +1,13 @@
+CREATE TABLE refresh_tokens (
+                       id SERIAL PRIMARY KEY,
+                       user_id INTEGER NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+                       family_id VARCHAR(64) NOT NULL, -- цепочка токенов, полученных ротацией от одного входа
+                       token_hash VARCHAR(64) NOT NULL UNIQUE, -- sha256 токена, сам токен не хранится
+                       expires_at TIMESTAMP NOT NULL,
+                       created_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+                       rotated_at TIMESTAMP, -- токен обменян на новый
+                       revoked_at TIMESTAMP
+);
+
+CREATE INDEX idx_refresh_tokens_family_id ON refresh_tokens(family_id);
+CREATE INDEX idx_refresh_tokens_user_id ON refresh_tokens(user_id);
@@ -0,0 +1,12 @@
+-- Версия токенов пользователя: увеличение делает недействительными все выданные access токены
+ALTER TABLE users ADD COLUMN token_version INTEGER NOT NULL DEFAULT 0;
+ALTER TABLE users ADD COLUMN banned_at TIMESTAMP;
+
+-- Отозванные до истечения срока access токены, записи старше expires_at можно удалять
+CREATE TABLE revoked_tokens (
+                       jti VARCHAR(64) PRIMARY KEY,
+                       user_id INTEGER NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+                       expires_at TIMESTAMP NOT NULL
+);
+
+CREATE INDEX idx_revoked_tokens_expires_at ON revoked_tokens(expires_at);
@@ -0,0 +1,9 @@
+-- Счетчики неудачных попыток входа по логину и по IP адресу
+CREATE TABLE login_failures (
+                       kind VARCHAR(10) NOT NULL CHECK (kind IN ('login', 'ip')),
+                       subject VARCHAR(255) NOT NULL,
+                       failures INTEGER NOT NULL DEFAULT 0,
+                       last_failure_at TIMESTAMP NOT NULL,
+                       locked_until TIMESTAMP,
+                       PRIMARY KEY (kind, subject)
+);
@@ -0,0 +1,12 @@
+ALTER TABLE users ADD COLUMN totp_secret VARCHAR(64); -- base32, задается при подключении 2FA
+ALTER TABLE users ADD COLUMN totp_enabled BOOLEAN NOT NULL DEFAULT FALSE;
+ALTER TABLE users ADD COLUMN totp_last_step BIGINT; -- последний принятый шаг TOTP, защита от повтора кода
+
+CREATE TABLE totp_backup_codes (
+                       id SERIAL PRIMARY KEY,
+                       user_id INTEGER NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+                       code_hash VARCHAR(64) NOT NULL, -- sha256 кода, сам код не хранится
+                       used_at TIMESTAMP
+);
+
+CREATE INDEX idx_totp_backup_codes_user_id ON totp_backup_codes(user_id);
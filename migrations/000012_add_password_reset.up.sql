@@ -0,0 +1,13 @@
+ALTER TABLE users ADD COLUMN email VARCHAR(255); -- необязательный, хранится в нижнем регистре
+ALTER TABLE users ADD CONSTRAINT users_email_key UNIQUE (email);
+
+CREATE TABLE password_reset_tokens (
+                       id SERIAL PRIMARY KEY,
+                       user_id INTEGER NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+                       token_hash VARCHAR(64) NOT NULL UNIQUE, -- sha256 токена, сам токен не хранится
+                       expires_at TIMESTAMP NOT NULL,
+                       created_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+                       used_at TIMESTAMP -- токен одноразовый
+);
+
+CREATE INDEX idx_password_reset_tokens_user_id ON password_reset_tokens(user_id);
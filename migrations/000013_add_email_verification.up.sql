@@ -0,0 +1,13 @@
+ALTER TABLE users ADD COLUMN email_verified_at TIMESTAMP; -- email подтвержден, сбрасывается при смене email
+
+CREATE TABLE email_verification_tokens (
+                       id SERIAL PRIMARY KEY,
+                       user_id INTEGER NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+                       email VARCHAR(255) NOT NULL, -- подтверждаемый адрес, после смены email токен недействителен
+                       token_hash VARCHAR(64) NOT NULL UNIQUE, -- sha256 токена, сам токен не хранится
+                       expires_at TIMESTAMP NOT NULL,
+                       created_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+                       used_at TIMESTAMP
+);
+
+CREATE INDEX idx_email_verification_tokens_user_id ON email_verification_tokens(user_id);
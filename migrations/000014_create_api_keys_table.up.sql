@@ -0,0 +1,12 @@
+CREATE TABLE api_keys (
+                       id SERIAL PRIMARY KEY,
+                       name VARCHAR(100) NOT NULL,
+                       prefix VARCHAR(16) NOT NULL UNIQUE, -- открытая часть ключа для поиска
+                       secret_hash VARCHAR(64) NOT NULL, -- sha256 секретной части, сам ключ не хранится
+                       scopes TEXT[] NOT NULL,
+                       created_by INTEGER NOT NULL REFERENCES users(id) ON DELETE CASCADE, -- ключ действует от имени администратора
+                       created_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+                       expires_at TIMESTAMP,
+                       last_used_at TIMESTAMP,
+                       revoked_at TIMESTAMP
+);
@@ -0,0 +1,2 @@
+-- Таблица лидеров за день, неделю и месяц суммирует бонусы выполнений начиная с границы периода
+CREATE INDEX idx_task_completions_completed_at ON task_completions(completed_at);
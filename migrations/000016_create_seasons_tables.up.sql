@@ -0,0 +1,34 @@
+CREATE TABLE seasons (
+                       id SERIAL PRIMARY KEY,
+                       name VARCHAR(100) NOT NULL,
+                       starts_at TIMESTAMP NOT NULL,
+                       ends_at TIMESTAMP NOT NULL,
+                       prizes INTEGER[] NOT NULL DEFAULT '{}', -- призы за места: первый элемент - 1 место и т.д.
+                       closed_at TIMESTAMP, -- итоги подведены, призы начислены
+                       created_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+                       CHECK (ends_at > starts_at)
+);
+
+CREATE INDEX idx_seasons_period ON seasons(starts_at, ends_at);
+
+-- Баллы пользователей в сезоне, не зависят от баланса и не списываются за награды
+CREATE TABLE season_scores (
+                       season_id INTEGER NOT NULL REFERENCES seasons(id) ON DELETE CASCADE,
+                       user_id INTEGER NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+                       score BIGINT NOT NULL DEFAULT 0,
+                       PRIMARY KEY (season_id, user_id)
+);
+
+CREATE INDEX idx_season_scores_score ON season_scores(season_id, score DESC);
+
+-- Итоговая таблица завершенного сезона
+CREATE TABLE season_standings (
+                       season_id INTEGER NOT NULL REFERENCES seasons(id) ON DELETE CASCADE,
+                       user_id INTEGER NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+                       rank INTEGER NOT NULL,
+                       score BIGINT NOT NULL,
+                       prize INTEGER NOT NULL DEFAULT 0,
+                       PRIMARY KEY (season_id, user_id)
+);
+
+CREATE INDEX idx_season_standings_rank ON season_standings(season_id, rank, user_id);
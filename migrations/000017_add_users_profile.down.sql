@@ -0,0 +1,4 @@
+ALTER TABLE users DROP COLUMN IF EXISTS leaderboard_excluded_at;
+ALTER TABLE users DROP COLUMN IF EXISTS leaderboard_visibility;
+ALTER TABLE users DROP COLUMN IF EXISTS avatar_url;
+ALTER TABLE users DROP COLUMN IF EXISTS display_name;
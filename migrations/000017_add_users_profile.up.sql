@@ -0,0 +1,7 @@
+ALTER TABLE users ADD COLUMN display_name VARCHAR(50);
+ALTER TABLE users ADD COLUMN avatar_url VARCHAR(512);
+-- public - имя и аватар в таблицах лидеров, anonymous - место без имени, ID и аватара, hidden - не участвует в рейтингах
+ALTER TABLE users ADD COLUMN leaderboard_visibility VARCHAR(16) NOT NULL DEFAULT 'public'
+    CHECK (leaderboard_visibility IN ('public', 'anonymous', 'hidden'));
+-- Администратор исключил пользователя из таблиц лидеров, например за накрутку
+ALTER TABLE users ADD COLUMN leaderboard_excluded_at TIMESTAMP;
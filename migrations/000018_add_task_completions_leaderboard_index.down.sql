@@ -0,0 +1,2 @@
+CREATE INDEX IF NOT EXISTS idx_task_completions_completed_at ON task_completions(completed_at);
+DROP INDEX IF EXISTS idx_task_completions_leaderboard;
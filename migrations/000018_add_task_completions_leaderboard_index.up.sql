@@ -0,0 +1,5 @@
+-- Таблицы лидеров без кэша суммируют bonus_awarded по пользователям с фильтром по completed_at:
+-- покрывающий индекс позволяет читать выполнения периода только из индекса, без обращения к таблице.
+-- Заменяет idx_task_completions_completed_at с тем же первым столбцом.
+CREATE INDEX idx_task_completions_leaderboard ON task_completions(completed_at, user_id) INCLUDE (bonus_awarded);
+DROP INDEX IF EXISTS idx_task_completions_completed_at;
@@ -0,0 +1,3 @@
+-- Попытки ввода кода второго фактора по одному токену второго шага входа, subject - jti токена
+ALTER TABLE login_failures DROP CONSTRAINT login_failures_kind_check;
+ALTER TABLE login_failures ADD CONSTRAINT login_failures_kind_check CHECK (kind IN ('login', 'ip', 'challenge'));
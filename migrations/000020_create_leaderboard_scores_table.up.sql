@@ -0,0 +1,12 @@
+-- Суммы бонусов пользователей за периоды таблиц лидеров, пополняются при выполнении задач.
+-- Место пользователя и соседи читаются по индексу на сумме вместо агрегирования task_completions.
+-- Таблица заполняется при запуске сервиса: границы дня, недели и месяца зависят от LEADERBOARD_TIMEZONE.
+CREATE TABLE leaderboard_scores (
+                       period VARCHAR(10) NOT NULL CHECK (period IN ('day', 'week', 'month', 'all')),
+                       period_start TIMESTAMP NOT NULL, -- начало периода в UTC, для all - '-infinity'
+                       user_id INTEGER NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+                       score BIGINT NOT NULL DEFAULT 0,
+                       PRIMARY KEY (period, period_start, user_id)
+);
+
+CREATE INDEX idx_leaderboard_scores_score ON leaderboard_scores(period, period_start, score DESC, user_id);